@@ -69,10 +69,11 @@ func main() {
 
 	// 初始获取设备列表
 	logger.Info("Fetching devices from API...")
-	if devices, err := rep.GetDevices(); err != nil {
+	if devices, profiles, err := rep.GetDevices(); err != nil {
 		logger.WithError(err).Warn("Failed to fetch initial device list, starting with empty list")
 	} else {
 		logger.WithField("count", len(devices)).Info("Received device list")
+		col.SetCredentialProfiles(profiles)
 		col.SetDevices(devices)
 	}
 
@@ -85,10 +86,11 @@ func main() {
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				if devices, err := rep.GetDevices(); err != nil {
+				if devices, profiles, err := rep.GetDevices(); err != nil {
 					logger.WithError(err).Error("Failed to sync devices")
 				} else {
 					logger.WithField("count", len(devices)).Debug("Synced device list")
+					col.SetCredentialProfiles(profiles)
 					col.SetDevices(devices)
 				}
 			}
@@ -125,6 +127,99 @@ func main() {
 		}
 	}()
 
+	// 启动拓扑上报
+	go func() {
+		if err := rep.StartTopology(ctx, col.Topology()); err != nil {
+			logger.WithError(err).Error("Topology reporter stopped with error")
+		}
+	}()
+
+	// 启动终端定位表上报
+	go func() {
+		if err := rep.StartEndpoints(ctx, col.Endpoints()); err != nil {
+			logger.WithError(err).Error("Endpoint reporter stopped with error")
+		}
+	}()
+
+	// 启动状态事件上报
+	go func() {
+		if err := rep.StartEvents(ctx, col.Events()); err != nil {
+			logger.WithError(err).Error("Event reporter stopped with error")
+		}
+	}()
+
+	// 启动配置快照上报 (ssh 探针备份的运行配置)
+	go func() {
+		if err := rep.StartConfigs(ctx, col.Configs()); err != nil {
+			logger.WithError(err).Error("Config reporter stopped with error")
+		}
+	}()
+
+	// 启动设备指纹上报
+	go func() {
+		if err := rep.StartFingerprints(ctx, col.Fingerprints()); err != nil {
+			logger.WithError(err).Error("Fingerprint reporter stopped with error")
+		}
+	}()
+
+	// 启动硬件清单上报
+	go func() {
+		if err := rep.StartInventory(ctx, col.Inventory()); err != nil {
+			logger.WithError(err).Error("Inventory reporter stopped with error")
+		}
+	}()
+
+	// 启动 SNMP Trap 接收及上报
+	if cfg.Traps.Enabled {
+		go func() {
+			if err := col.StartTraps(ctx); err != nil {
+				logger.WithError(err).Error("Trap receiver stopped with error")
+			}
+		}()
+		go func() {
+			if err := rep.StartTraps(ctx, col.Traps()); err != nil {
+				logger.WithError(err).Error("Trap reporter stopped with error")
+			}
+		}()
+	}
+
+	// 启动 Syslog 接收及上报
+	if cfg.Syslog.Enabled {
+		go func() {
+			if err := col.StartSyslog(ctx); err != nil {
+				logger.WithError(err).Error("Syslog receiver stopped with error")
+			}
+		}()
+		go func() {
+			if err := rep.StartSyslog(ctx, col.Syslog()); err != nil {
+				logger.WithError(err).Error("Syslog reporter stopped with error")
+			}
+		}()
+	}
+
+	// 启动 NetFlow / IPFIX 接收及汇总上报
+	if cfg.Flows.Enabled {
+		go func() {
+			if err := col.StartFlows(ctx); err != nil {
+				logger.WithError(err).Error("Flow receiver stopped with error")
+			}
+		}()
+		go func() {
+			if err := rep.StartFlows(ctx, col.Flows()); err != nil {
+				logger.WithError(err).Error("Flow reporter stopped with error")
+			}
+		}()
+	}
+
+	// 启动 gNMI 流式遥测订阅, 更新合并到设备指标
+	if cfg.GNMI.Enabled {
+		go func() {
+			if err := col.StartGNMI(ctx); err != nil {
+				logger.WithError(err).Error("gNMI subscriptions stopped with error")
+			}
+		}()
+	}
+
 	// 等待信号
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
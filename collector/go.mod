@@ -7,13 +7,19 @@ toolchain go1.24.5
 require (
 	github.com/go-ping/ping v1.1.0
 	github.com/gosnmp/gosnmp v1.42.1
+	github.com/openconfig/gnmi v0.10.0
 	github.com/sirupsen/logrus v1.9.3
+	golang.org/x/crypto v0.21.0
+	google.golang.org/grpc v1.64.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/google/uuid v1.6.0 // indirect
-	golang.org/x/net v0.19.0 // indirect
-	golang.org/x/sync v0.5.0 // indirect
-	golang.org/x/sys v0.15.0 // indirect
+	golang.org/x/net v0.22.0 // indirect
+	golang.org/x/sync v0.6.0 // indirect
+	golang.org/x/sys v0.18.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240318140521-94a12d6c2237 // indirect
+	google.golang.org/protobuf v1.34.1 // indirect
 )
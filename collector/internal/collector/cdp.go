@@ -0,0 +1,109 @@
+package collector
+
+import (
+	"encoding/binary"
+	"net"
+	"sort"
+	"strconv"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// CISCO-CDP-MIB cdpCacheTable, 索引: cdpCacheIfIndex.cdpCacheDeviceIndex
+const (
+	oidCdpCacheAddressType  = ".1.3.6.1.4.1.9.9.23.1.2.1.1.3"
+	oidCdpCacheAddress      = ".1.3.6.1.4.1.9.9.23.1.2.1.1.4"
+	oidCdpCacheDeviceId     = ".1.3.6.1.4.1.9.9.23.1.2.1.1.6"
+	oidCdpCacheDevicePort   = ".1.3.6.1.4.1.9.9.23.1.2.1.1.7"
+	oidCdpCachePlatform     = ".1.3.6.1.4.1.9.9.23.1.2.1.1.8"
+	oidCdpCacheCapabilities = ".1.3.6.1.4.1.9.9.23.1.2.1.1.9"
+)
+
+// cdpCapabilityNames CDP能力位 (cdpCacheCapabilities 为4字节位图)
+var cdpCapabilityNames = []struct {
+	bit  uint32
+	name string
+}{
+	{0x01, "router"},
+	{0x02, "transparentBridge"},
+	{0x04, "sourceRouteBridge"},
+	{0x08, "switch"},
+	{0x10, "host"},
+	{0x20, "igmp"},
+	{0x40, "repeater"},
+	{0x80, "phone"},
+	{0x100, "remotelyManaged"},
+}
+
+// collectCDP 采集CDP邻居, 输出与LLDP一致的 Neighbor 记录
+// 本地端口直接由 cdpCacheIfIndex 通过 ifNames 解析, 与LLDP同一链路可在服务端按本地端口合并
+func (c *Collector) collectCDP(snmp *gosnmp.GoSNMP, ifNames map[int]string) ([]Neighbor, error) {
+	table, err := walkTable(snmp,
+		oidCdpCacheAddressType, oidCdpCacheAddress, oidCdpCacheDeviceId,
+		oidCdpCacheDevicePort, oidCdpCachePlatform, oidCdpCacheCapabilities,
+	)
+	if err != nil {
+		return nil, err // 非Cisco设备或未启用CDP
+	}
+
+	neighbors := make([]Neighbor, 0, len(table))
+	for index := range table {
+		idx, err := parseIndex(index)
+		if err != nil || len(idx) != 2 {
+			continue
+		}
+		ifIndex := idx[0]
+
+		localPort, ok := ifNames[ifIndex]
+		if !ok {
+			localPort = strconv.Itoa(ifIndex)
+		}
+		deviceID := table.rowString(index, oidCdpCacheDeviceId)
+
+		n := Neighbor{
+			LocalPort:           localPort,
+			LocalPortIndex:      ifIndex,
+			RemotePort:          table.rowString(index, oidCdpCacheDevicePort),
+			RemoteChassisID:     deviceID,
+			RemoteChassisIDType: "cdpDeviceId",
+			RemoteSystemName:    deviceID,
+			RemotePlatform:      table.rowString(index, oidCdpCachePlatform),
+			RemoteCapabilities:  decodeCDPCapabilities(pduBytes(table[index][oidCdpCacheCapabilities])),
+			LinkType:            "ethernet",
+			Protocol:            "cdp",
+		}
+
+		// cdpCacheAddressType 1 = ip
+		if addrType, _ := table.rowInt(index, oidCdpCacheAddressType); addrType == 1 {
+			if raw := pduBytes(table[index][oidCdpCacheAddress]); len(raw) == net.IPv4len {
+				n.RemoteIP = net.IP(raw).String()
+			}
+		}
+
+		neighbors = append(neighbors, n)
+	}
+
+	sort.Slice(neighbors, func(i, j int) bool {
+		if neighbors[i].LocalPortIndex != neighbors[j].LocalPortIndex {
+			return neighbors[i].LocalPortIndex < neighbors[j].LocalPortIndex
+		}
+		return neighbors[i].RemoteChassisID < neighbors[j].RemoteChassisID
+	})
+
+	return neighbors, nil
+}
+
+// decodeCDPCapabilities 解码CDP能力位图
+func decodeCDPCapabilities(raw []byte) []string {
+	if len(raw) != 4 {
+		return nil
+	}
+	bits := binary.BigEndian.Uint32(raw)
+	var caps []string
+	for _, c := range cdpCapabilityNames {
+		if bits&c.bit != 0 {
+			caps = append(caps, c.name)
+		}
+	}
+	return caps
+}
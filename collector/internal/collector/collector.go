@@ -5,92 +5,176 @@ import (
 	"sync"
 	"time"
 
-	"github.com/go-ping/ping"
-	"github.com/gosnmp/gosnmp"
 	"github.com/netvis/collector/internal/config"
 	"github.com/sirupsen/logrus"
 )
 
 // DeviceMetrics 设备指标数据
 type DeviceMetrics struct {
-	DeviceID    string    `json:"deviceId"`
-	IP          string    `json:"ip"`
-	Status      string    `json:"status"`
-	Latency     float64   `json:"latency"`
-	PacketLoss  float64   `json:"packetLoss"`
-	CPUUsage    float64   `json:"cpuUsage"`
-	MemoryUsage float64   `json:"memoryUsage"`
-	Uptime      int64     `json:"uptime"`
-	Interfaces  []IfStats `json:"interfaces"`
-	CollectedAt time.Time `json:"collectedAt"`
+	DeviceID    string         `json:"deviceId"`
+	IP          string         `json:"ip"`
+	Status      string         `json:"status"`           // online / degraded / unreachable / snmp-failed / unknown
+	Reason      string         `json:"reason,omitempty"` // 非 online 时的原因
+	Latency     float64        `json:"latency"`          // 平均RTT (ms)
+	MinLatency  float64        `json:"minLatency"`
+	MaxLatency  float64        `json:"maxLatency"`
+	StdDevRTT   float64        `json:"stddevRtt"`
+	Jitter      float64        `json:"jitter"` // 相邻RTT差值绝对值的平均 (ms)
+	PacketLoss  float64        `json:"packetLoss"`
+	CPUUsage    float64        `json:"cpuUsage"`
+	MemoryUsage float64        `json:"memoryUsage"`
+	Temperature float64        `json:"temperature,omitempty"` // 设备最高温度 (°C), 取自厂商私有 MIB
+	Uptime      int64          `json:"uptime"`
+	Interfaces  []IfStats      `json:"interfaces"`
+	Storage     []StorageUsage `json:"storage,omitempty"` // 内存及各文件系统用量 (HOST-RESOURCES-MIB)
+	Checks      []CheckResult  `json:"checks,omitempty"`
+	CollectedAt time.Time      `json:"collectedAt"`
 }
 
 // IfStats 接口统计
 type IfStats struct {
-	Name      string `json:"name"`
-	InBytes   int64  `json:"inBytes"`
-	OutBytes  int64  `json:"outBytes"`
-	InErrors  int64  `json:"inErrors"`
-	OutErrors int64  `json:"outErrors"`
-	Status    string `json:"status"`
+	Index       int    `json:"index"`
+	Name        string `json:"name"`
+	Descr       string `json:"descr,omitempty"`
+	Alias       string `json:"alias,omitempty"`
+	Type        int    `json:"type,omitempty"`  // IANAifType
+	Speed       uint64 `json:"speed,omitempty"` // bps
+	AdminStatus string `json:"adminStatus,omitempty"`
+	OperStatus  string `json:"operStatus,omitempty"`
+	Status      string `json:"status"`
+
+	// 原始计数器 (HighCapacity 为 true 时取自 ifHC* 64位计数器)
+	HighCapacity     bool  `json:"highCapacity"`
+	InBytes          int64 `json:"inBytes"`
+	OutBytes         int64 `json:"outBytes"`
+	InUcastPkts      int64 `json:"inUcastPkts"`
+	OutUcastPkts     int64 `json:"outUcastPkts"`
+	InMulticastPkts  int64 `json:"inMulticastPkts"`
+	OutMulticastPkts int64 `json:"outMulticastPkts"`
+	InBroadcastPkts  int64 `json:"inBroadcastPkts"`
+	OutBroadcastPkts int64 `json:"outBroadcastPkts"`
+	InDiscards       int64 `json:"inDiscards"`
+	OutDiscards      int64 `json:"outDiscards"`
+	InErrors         int64 `json:"inErrors"`
+	OutErrors        int64 `json:"outErrors"`
+	pkts32           uint8 // 取自32位列的包计数器, 位0-2为入方向 单播/组播/广播, 位3-5为出方向
+
+	// 与上次样本比较得到的速率, RateInterval 为 0 表示尚无可用速率
+	InBps          float64 `json:"inBps"`
+	OutBps         float64 `json:"outBps"`
+	InPps          float64 `json:"inPps"`
+	OutPps         float64 `json:"outPps"`
+	InUtilization  float64 `json:"inUtilization"`  // %
+	OutUtilization float64 `json:"outUtilization"` // %
+	RateInterval   float64 `json:"rateInterval"`   // 秒
+
+	Optical *OpticalDOM `json:"optical,omitempty"` // 光模块 DOM 读数, 电口及不支持诊断的模块为空
 }
 
 // Device 待采集设备
 type Device struct {
-	ID        string `json:"id"`
-	IP        string `json:"ip"`
-	Type      string `json:"type"`
-	Community string `json:"community"`
+	ID                  string               `json:"id"`
+	IP                  string               `json:"ip"`
+	Type                string               `json:"type"`
+	Vendor              string               `json:"vendor,omitempty"` // cisco / huawei / h3c / juniper / ruijie
+	Community           string               `json:"community"`
+	SNMPVersion         string               `json:"snmpVersion,omitempty"`         // 覆盖全局 snmp.version
+	SNMPv3              *config.SNMPv3Config `json:"snmpv3,omitempty"`              // 覆盖全局 snmp.v3
+	CredentialProfileID string               `json:"credentialProfileId,omitempty"` // 引用服务端凭据模板
+	Interval            int                  `json:"interval,omitempty"`            // 采集间隔(秒), 覆盖探针默认值
+	Intervals           map[string]int       `json:"intervals,omitempty"`           // 按探针名覆盖采集间隔(秒)
+	GNMI                *GNMITarget          `json:"gnmi,omitempty"`                // gNMI 订阅参数, 非空时建立订阅
 }
 
 // Collector 采集器
 type Collector struct {
-	config   *config.Config
-	devices  []Device
-	logger   *logrus.Logger
-	metrics  chan DeviceMetrics
-	stopChan chan struct{}
-	wg       sync.WaitGroup
+	config      *config.Config
+	profiles    map[string]CredentialProfile
+	profilesMu  sync.RWMutex
+	devicesByIP map[string]Device
+	devicesMu   sync.RWMutex
+	logger      *logrus.Logger
+	metrics     chan DeviceMetrics
+	topology    chan TopologyData
+	endpoints   chan EndpointTable
+	events      chan StateEvent
+	traps       chan TrapEvent
+	syslog      chan SyslogMessage
+	flows       chan FlowAggregate
+	configs     chan ConfigSnapshot
+	fingerprint chan Fingerprint
+	inventory   chan Inventory
+	engines     *engineCache
+	rates       *rateTracker
+	probes      []probeSpec
+	scheduler   *scheduler
+	gnmi        *gnmiManager
+	stopChan    chan struct{}
+	wg          sync.WaitGroup
 }
 
 // New 创建采集器实例
 func New(cfg *config.Config, logger *logrus.Logger) *Collector {
-	return &Collector{
-		config:   cfg,
-		devices:  make([]Device, 0),
-		logger:   logger,
-		metrics:  make(chan DeviceMetrics, 1000),
-		stopChan: make(chan struct{}),
+	c := &Collector{
+		config:      cfg,
+		profiles:    make(map[string]CredentialProfile),
+		logger:      logger,
+		metrics:     make(chan DeviceMetrics, 1000),
+		topology:    make(chan TopologyData, 1000),
+		endpoints:   make(chan EndpointTable, 1000),
+		events:      make(chan StateEvent, 1000),
+		traps:       make(chan TrapEvent, 1000),
+		syslog:      make(chan SyslogMessage, 10000),
+		flows:       make(chan FlowAggregate, 10000),
+		configs:     make(chan ConfigSnapshot, 100),
+		fingerprint: make(chan Fingerprint, 1000),
+		inventory:   make(chan Inventory, 100),
+		engines:     newEngineCache(),
+		rates:       newRateTracker(),
+		stopChan:    make(chan struct{}),
 	}
+	c.probes = c.buildProbes()
+	c.scheduler = newScheduler(c)
+	c.gnmi = newGNMIManager(c)
+	return c
 }
 
-// SetDevices 设置待采集设备列表
+// SetDevices 设置待采集设备列表, 可在运行中随时调用
 func (c *Collector) SetDevices(devices []Device) {
-	c.devices = devices
+	byIP := make(map[string]Device, len(devices))
+	for _, d := range devices {
+		byIP[d.IP] = d
+	}
+	c.devicesMu.Lock()
+	c.devicesByIP = byIP
+	c.devicesMu.Unlock()
+
+	c.scheduler.setDevices(devices)
+	c.gnmi.setDevices(devices)
+}
+
+// deviceByIP 按管理地址查找设备, 用于 trap / syslog 等被动接收数据的归属
+func (c *Collector) deviceByIP(ip string) (Device, bool) {
+	c.devicesMu.RLock()
+	defer c.devicesMu.RUnlock()
+	d, ok := c.devicesByIP[ip]
+	return d, ok
 }
 
 // Start 启动采集器
+// 每个 设备+探针 按各自间隔独立调度, 首次执行时间在一个间隔内随机分散
 func (c *Collector) Start(ctx context.Context) error {
 	c.logger.Info("Starting collector...")
 
-	ticker := time.NewTicker(c.config.Collector.Interval)
-	defer ticker.Stop()
-
-	// 立即执行一次采集
-	c.collect()
-
-	for {
-		select {
-		case <-ctx.Done():
-			c.logger.Info("Collector stopped by context")
-			return nil
-		case <-c.stopChan:
-			c.logger.Info("Collector stopped")
-			return nil
-		case <-ticker.C:
-			c.collect()
-		}
+	c.scheduler.run(ctx, c.stopChan)
+
+	select {
+	case <-ctx.Done():
+		c.logger.Info("Collector stopped by context")
+	default:
+		c.logger.Info("Collector stopped")
 	}
+	return nil
 }
 
 // Stop 停止采集器
@@ -104,257 +188,49 @@ func (c *Collector) Metrics() <-chan DeviceMetrics {
 	return c.metrics
 }
 
-// collect 执行一次采集
-func (c *Collector) collect() {
-	c.logger.WithField("devices", len(c.devices)).Info("Starting collection cycle")
-
-	sem := make(chan struct{}, c.config.Collector.Concurrency)
-
-	for _, device := range c.devices {
-		c.wg.Add(1)
-		go func(d Device) {
-			defer c.wg.Done()
-			sem <- struct{}{}
-			defer func() { <-sem }()
-
-			metrics := c.collectDevice(d)
-			select {
-			case c.metrics <- metrics:
-			default:
-				c.logger.Warn("Metrics channel full, dropping metrics")
-			}
-		}(device)
-	}
-
-	c.wg.Wait()
-	c.logger.Info("Collection cycle completed")
+// Topology 获取拓扑数据通道
+func (c *Collector) Topology() <-chan TopologyData {
+	return c.topology
 }
 
-// collectDevice 采集单个设备
-func (c *Collector) collectDevice(device Device) DeviceMetrics {
-	start := time.Now()
-
-	metrics := DeviceMetrics{
-		DeviceID:    device.ID,
-		IP:          device.IP,
-		CollectedAt: time.Now(),
-	}
-
-	// Ping检测
-	latency, packetLoss, err := c.pingDevice(device.IP)
-	if err != nil {
-		c.logger.WithError(err).WithField("ip", device.IP).Warn("Ping failed")
-		metrics.Status = "offline"
-		return metrics
-	}
-
-	metrics.Latency = latency
-	metrics.PacketLoss = packetLoss
-	metrics.Status = "online"
-
-	// SNMP采集 (如果设备支持)
-	if device.Community != "" {
-		snmpMetrics, err := c.collectSNMP(device)
-		if err != nil {
-			c.logger.WithError(err).WithField("ip", device.IP).Warn("SNMP collection failed")
-		} else {
-			metrics.CPUUsage = snmpMetrics.CPUUsage
-			metrics.MemoryUsage = snmpMetrics.MemoryUsage
-			metrics.Uptime = snmpMetrics.Uptime
-			metrics.Interfaces = snmpMetrics.Interfaces
-
-			// Report Topology if neighbors found
-			if len(snmpMetrics.Neighbors) > 0 {
-				c.reportTopology(device, snmpMetrics.Neighbors)
-			}
-		}
-	}
-
-	c.logger.WithFields(logrus.Fields{
-		"ip":       device.IP,
-		"status":   metrics.Status,
-		"latency":  metrics.Latency,
-		"duration": time.Since(start),
-	}).Debug("Device collected")
-
-	return metrics
+// Endpoints 获取终端定位表通道
+func (c *Collector) Endpoints() <-chan EndpointTable {
+	return c.endpoints
 }
 
-// pingDevice 执行Ping检测
-func (c *Collector) pingDevice(ip string) (latency float64, packetLoss float64, err error) {
-	pinger, err := ping.NewPinger(ip)
-	if err != nil {
-		return 0, 100, err
-	}
-
-	// 配置Ping参数
-	pinger.Count = 3
-	pinger.Timeout = time.Second * 5
-	pinger.SetPrivileged(false) // 非特权模式，使用UDP
-
-	err = pinger.Run()
-	if err != nil {
-		return 0, 100, err
-	}
-
-	stats := pinger.Statistics()
-
-	// 计算平均延迟(毫秒)
-	latency = float64(stats.AvgRtt.Microseconds()) / 1000.0
-
-	// 计算丢包率(百分比)
-	packetLoss = stats.PacketLoss
-
-	return latency, packetLoss, nil
+// Events 获取状态变化事件通道
+func (c *Collector) Events() <-chan StateEvent {
+	return c.events
 }
 
-// SNMPMetrics SNMP采集结果
-type SNMPMetrics struct {
-	CPUUsage    float64
-	MemoryUsage float64
-	Uptime      int64
-	Interfaces  []IfStats
-	Neighbors   []Neighbor
+// Traps 获取 SNMP Trap/Inform 事件通道
+func (c *Collector) Traps() <-chan TrapEvent {
+	return c.traps
 }
 
-// SNMP OID 常量
-const (
-	// 系统信息
-	oidSysUpTime = ".1.3.6.1.2.1.1.3.0" // sysUpTimeInstance (timeticks)
-
-	// 接口表
-	oidIfDescr      = ".1.3.6.1.2.1.2.2.1.2"  // ifDescr
-	oidIfInOctets   = ".1.3.6.1.2.1.2.2.1.10" // ifInOctets
-	oidIfOutOctets  = ".1.3.6.1.2.1.2.2.1.16" // ifOutOctets
-	oidIfInErrors   = ".1.3.6.1.2.1.2.2.1.14" // ifInErrors
-	oidIfOutErrors  = ".1.3.6.1.2.1.2.2.1.20" // ifOutErrors
-	oidIfOperStatus = ".1.3.6.1.2.1.2.2.1.8"  // ifOperStatus
-
-	// 主机资源 (HOST-RESOURCES-MIB)
-	oidHrProcessorLoad = ".1.3.6.1.2.1.25.3.3.1.2" // hrProcessorLoad
-	oidHrStorageUsed   = ".1.3.6.1.2.1.25.2.3.1.6" // hrStorageUsed
-	oidHrStorageSize   = ".1.3.6.1.2.1.25.2.3.1.5" // hrStorageSize
-)
-
-// collectSNMP 执行SNMP采集
-func (c *Collector) collectSNMP(device Device) (*SNMPMetrics, error) {
-	// 创建SNMP客户端
-	snmp := &gosnmp.GoSNMP{
-		Target:    device.IP,
-		Port:      161,
-		Community: device.Community,
-		Version:   gosnmp.Version2c,
-		Timeout:   time.Duration(5) * time.Second,
-		Retries:   2,
-	}
-
-	err := snmp.Connect()
-	if err != nil {
-		return nil, err
-	}
-	defer snmp.Conn.Close()
-
-	metrics := &SNMPMetrics{}
-
-	// 获取系统运行时间
-	result, err := snmp.Get([]string{oidSysUpTime})
-	if err == nil && len(result.Variables) > 0 {
-		if uptime, ok := result.Variables[0].Value.(uint32); ok {
-			metrics.Uptime = int64(uptime) / 100 // timeticks to seconds
-		}
-	}
-
-	// 获取CPU使用率 (平均所有处理器)
-	cpuResult, err := snmp.WalkAll(oidHrProcessorLoad)
-	if err == nil && len(cpuResult) > 0 {
-		var totalCPU float64
-		for _, pdu := range cpuResult {
-			if val, ok := pdu.Value.(int); ok {
-				totalCPU += float64(val)
-			}
-		}
-		metrics.CPUUsage = totalCPU / float64(len(cpuResult))
-	}
-
-	// 获取内存使用率 (简化: 取第一个存储设备)
-	usedResult, _ := snmp.WalkAll(oidHrStorageUsed)
-	sizeResult, _ := snmp.WalkAll(oidHrStorageSize)
-	if len(usedResult) > 0 && len(sizeResult) > 0 {
-		if used, ok := usedResult[0].Value.(int); ok {
-			if size, ok := sizeResult[0].Value.(int); ok {
-				if size > 0 {
-					metrics.MemoryUsage = float64(used) / float64(size) * 100
-				}
-			}
-		}
-	}
-
-	// 获取接口信息
-	interfaces := c.collectInterfaces(snmp)
-	metrics.Interfaces = interfaces
-
-	// LLDP采集 (Topology Discovery)
-	neighbors, err := c.collectLLDP(snmp)
-	if err == nil {
-		metrics.Neighbors = neighbors
-	}
-
-	return metrics, nil
+// Syslog 获取 syslog 消息通道
+func (c *Collector) Syslog() <-chan SyslogMessage {
+	return c.syslog
 }
 
-// collectInterfaces 采集接口统计
-func (c *Collector) collectInterfaces(snmp *gosnmp.GoSNMP) []IfStats {
-	var interfaces []IfStats
+// Flows 获取流量汇总通道
+func (c *Collector) Flows() <-chan FlowAggregate {
+	return c.flows
+}
 
-	// 获取接口名称
-	descrResult, err := snmp.WalkAll(oidIfDescr)
-	if err != nil {
-		return interfaces
-	}
+// Configs 获取配置快照通道
+func (c *Collector) Configs() <-chan ConfigSnapshot {
+	return c.configs
+}
 
-	for i, pdu := range descrResult {
-		ifIndex := i + 1
-		name := ""
-		if bytes, ok := pdu.Value.([]byte); ok {
-			name = string(bytes)
-		} else if str, ok := pdu.Value.(string); ok {
-			name = str
-		}
-
-		ifStats := IfStats{Name: name}
-
-		// 获取入流量
-		inOctets, _ := snmp.Get([]string{oidIfInOctets + "." + string(rune(ifIndex))})
-		if len(inOctets.Variables) > 0 {
-			if val, ok := inOctets.Variables[0].Value.(uint); ok {
-				ifStats.InBytes = int64(val)
-			}
-		}
-
-		// 获取出流量
-		outOctets, _ := snmp.Get([]string{oidIfOutOctets + "." + string(rune(ifIndex))})
-		if len(outOctets.Variables) > 0 {
-			if val, ok := outOctets.Variables[0].Value.(uint); ok {
-				ifStats.OutBytes = int64(val)
-			}
-		}
-
-		// 获取接口状态
-		operStatus, _ := snmp.Get([]string{oidIfOperStatus + "." + string(rune(ifIndex))})
-		if len(operStatus.Variables) > 0 {
-			if val, ok := operStatus.Variables[0].Value.(int); ok {
-				if val == 1 {
-					ifStats.Status = "up"
-				} else {
-					ifStats.Status = "down"
-				}
-			}
-		}
-
-		interfaces = append(interfaces, ifStats)
-	}
+// Fingerprints 获取设备指纹通道
+func (c *Collector) Fingerprints() <-chan Fingerprint {
+	return c.fingerprint
+}
 
-	return interfaces
+// Inventory 获取硬件清单通道
+func (c *Collector) Inventory() <-chan Inventory {
+	return c.inventory
 }
 
 // TopologyData 拓扑数据
@@ -367,68 +243,52 @@ type TopologyData struct {
 
 // Neighbor 邻居信息
 type Neighbor struct {
-	LocalPort        string `json:"localPort"`
-	RemotePort       string `json:"remotePort"`
-	RemoteChassisID  string `json:"remoteChassisId"`
-	RemoteSystemName string `json:"remoteSystemName"`
-	RemoteIP         string `json:"remoteIp"` // Optional
-	LinkType         string `json:"linkType"`
+	LocalPort           string   `json:"localPort"`
+	LocalPortIndex      int      `json:"localPortIndex,omitempty"`
+	RemotePort          string   `json:"remotePort"`
+	RemotePortDescr     string   `json:"remotePortDescr,omitempty"`
+	RemoteChassisID     string   `json:"remoteChassisId"`
+	RemoteChassisIDType string   `json:"remoteChassisIdType,omitempty"` // macAddress / networkAddress / local ...
+	RemoteSystemName    string   `json:"remoteSystemName"`
+	RemoteSystemDescr   string   `json:"remoteSystemDescr,omitempty"`
+	RemotePlatform      string   `json:"remotePlatform,omitempty"`
+	RemoteCapabilities  []string `json:"remoteCapabilities,omitempty"`
+	RemoteIP            string   `json:"remoteIp"` // Optional
+	LinkType            string   `json:"linkType"`
+	Protocol            string   `json:"protocol,omitempty"` // lldp / cdp
 }
 
-const (
-	// LLDP OIDs
-	oidLldpRemChassisId = ".1.0.8802.1.1.2.1.4.1.1.5"
-	oidLldpRemPortId    = ".1.0.8802.1.1.2.1.4.1.1.7"
-	oidLldpRemSysName   = ".1.0.8802.1.1.2.1.4.1.1.9"
-)
-
-// collectLLDP 采集LLDP邻居
-func (c *Collector) collectLLDP(snmp *gosnmp.GoSNMP) ([]Neighbor, error) {
-	neighbors := make([]Neighbor, 0)
-
-	// Walk lldpRemChassisId to get indices
-	results, err := snmp.WalkAll(oidLldpRemChassisId)
-	if err != nil {
-		return neighbors, err // LLDP not supported or enabled
+// reportTopology 上报拓扑 (由 reporter 去重后发送至 /collector/topology)
+func (c *Collector) reportTopology(device Device, neighbors []Neighbor) {
+	data := TopologyData{
+		CollectorID: c.config.Collector.ID,
+		DeviceID:    device.ID,
+		IP:          device.IP,
+		Neighbors:   neighbors,
 	}
 
-	for _, pdu := range results {
-		// OIDSuffix contains the index: timeMark.localPortNum.remIndex
-		// We need to parse this to query other OIDs
-		// For simplicity/robustness in this demo, accessing directly if possible or just extracting string values
-
-		// Note in real implementation: Parsing the OID suffix is crucial to correlate columns.
-		// Here we simplify by assuming sequential walk order (not guaranteed but common).
-		// Better: Store by index map.
-
-		chassisId := ""
-		if val, ok := pdu.Value.([]byte); ok {
-			chassisId = string(val) // Hex string often
-		} else if str, ok := pdu.Value.(string); ok {
-			chassisId = str
-		}
-
-		neighbors = append(neighbors, Neighbor{
-			RemoteChassisID: chassisId,
-			LinkType:        "ethernet",
-		})
+	select {
+	case c.topology <- data:
+	default:
+		c.logger.WithField("device", device.IP).Warn("Topology channel full, dropping topology data")
 	}
-
-	// This is a simplified "Walk" implementation.
-	// A full implementation requires correlating columns by index.
-	// Given the scope, we stub the correlation logic but use real Walk calls.
-
-	return neighbors, nil
 }
 
-// reportTopology 上报拓扑
-func (c *Collector) reportTopology(device Device, neighbors []Neighbor) {
-	// TODO: Implement HTTP POST directly or via reporter package
-	// For now, we log it. In production, this would use c.config.APIEndpoint + "/topology"
-	c.logger.WithFields(logrus.Fields{
-		"device":    device.IP,
-		"neighbors": len(neighbors),
-	}).Info("Topology data collected")
-
-	// Stub: In real code, we'd use a reporter client to POST to /api/collector/topology
+// reportFingerprint 上报设备指纹 (由 reporter 去重后发送至 /collector/fingerprint)
+func (c *Collector) reportFingerprint(device Device, info SystemInfo) {
+	fp := buildFingerprint(device, info)
+	fp.CollectorID = c.config.Collector.ID
+	if fp.TypeMismatch {
+		c.logger.WithFields(logrus.Fields{
+			"device":   device.IP,
+			"type":     device.Type,
+			"detected": fp.DeviceType,
+		}).Debug("Detected device type differs from configured type")
+	}
+
+	select {
+	case c.fingerprint <- fp:
+	default:
+		c.logger.WithField("device", device.IP).Warn("Fingerprint channel full, dropping fingerprint")
+	}
 }
@@ -0,0 +1,652 @@
+package collector
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// ENTITY-SENSOR-MIB entPhySensorTable (按 entPhysicalIndex 索引)
+const (
+	oidEntPhySensorType      = ".1.3.6.1.2.1.99.1.1.1.1"
+	oidEntPhySensorScale     = ".1.3.6.1.2.1.99.1.1.1.2"
+	oidEntPhySensorPrecision = ".1.3.6.1.2.1.99.1.1.1.3"
+	oidEntPhySensorValue     = ".1.3.6.1.2.1.99.1.1.1.4"
+	oidEntPhySensorStatus    = ".1.3.6.1.2.1.99.1.1.1.5"
+
+	// ARISTA-ENTITY-SENSOR-MIB aristaEntSensorThresholdTable (与 entPhySensorValue 同单位)
+	oidAristaSensorLowWarning   = ".1.3.6.1.4.1.30065.3.12.1.1.1.1"
+	oidAristaSensorLowCritical  = ".1.3.6.1.4.1.30065.3.12.1.1.1.2"
+	oidAristaSensorHighWarning  = ".1.3.6.1.4.1.30065.3.12.1.1.1.3"
+	oidAristaSensorHighCritical = ".1.3.6.1.4.1.30065.3.12.1.1.1.4"
+
+	// CISCO-ENTITY-SENSOR-MIB entSensorValueTable / entSensorThresholdTable
+	oidCiscoSensorType           = ".1.3.6.1.4.1.9.9.91.1.1.1.1.1"
+	oidCiscoSensorScale          = ".1.3.6.1.4.1.9.9.91.1.1.1.1.2"
+	oidCiscoSensorPrecision      = ".1.3.6.1.4.1.9.9.91.1.1.1.1.3"
+	oidCiscoSensorValue          = ".1.3.6.1.4.1.9.9.91.1.1.1.1.4"
+	oidCiscoSensorStatus         = ".1.3.6.1.4.1.9.9.91.1.1.1.1.5"
+	oidCiscoSensorThresholdSev   = ".1.3.6.1.4.1.9.9.91.1.2.1.1.2"
+	oidCiscoSensorThresholdRel   = ".1.3.6.1.4.1.9.9.91.1.2.1.1.3"
+	oidCiscoSensorThresholdValue = ".1.3.6.1.4.1.9.9.91.1.2.1.1.4"
+
+	// HUAWEI-ENTITY-EXTENT-MIB hwOpticalModuleInfoTable (按 entPhysicalIndex 索引)
+	oidHwOpticalMode           = ".1.3.6.1.4.1.2011.5.25.31.1.1.3.1.1"  // 1 = notSupported (电口)
+	oidHwOpticalTemperature    = ".1.3.6.1.4.1.2011.5.25.31.1.1.3.1.5"  // °C
+	oidHwOpticalVoltage        = ".1.3.6.1.4.1.2011.5.25.31.1.1.3.1.6"  // mV
+	oidHwOpticalBiasCurrent    = ".1.3.6.1.4.1.2011.5.25.31.1.1.3.1.7"  // µA
+	oidHwOpticalRxPower        = ".1.3.6.1.4.1.2011.5.25.31.1.1.3.1.8"  // µW
+	oidHwOpticalTxPower        = ".1.3.6.1.4.1.2011.5.25.31.1.1.3.1.9"  // µW
+	oidHwOpticalRxLowThreshold = ".1.3.6.1.4.1.2011.5.25.31.1.1.3.1.13" // 0.01 dBm
+	oidHwOpticalRxHighThresh   = ".1.3.6.1.4.1.2011.5.25.31.1.1.3.1.14"
+	oidHwOpticalTxLowThreshold = ".1.3.6.1.4.1.2011.5.25.31.1.1.3.1.15"
+	oidHwOpticalTxHighThresh   = ".1.3.6.1.4.1.2011.5.25.31.1.1.3.1.16"
+
+	// HH3C-TRANSCEIVER-INFO-MIB hh3cTransceiverInfoTable (按 ifIndex 索引)
+	oidHH3CTransceiverTxPower     = ".1.3.6.1.4.1.25506.2.70.1.1.1.9"  // 0.01 dBm
+	oidHH3CTransceiverRxPower     = ".1.3.6.1.4.1.25506.2.70.1.1.1.12" // 0.01 dBm
+	oidHH3CTransceiverTemperature = ".1.3.6.1.4.1.25506.2.70.1.1.1.15" // °C
+	oidHH3CTransceiverVoltage     = ".1.3.6.1.4.1.25506.2.70.1.1.1.16" // 0.01 V
+	oidHH3CTransceiverBias        = ".1.3.6.1.4.1.25506.2.70.1.1.1.17" // 0.01 mA
+
+	// JUNIPER-DOM-MIB jnxDomCurrentTable (按 ifIndex 索引)
+	oidJnxDomRxPower     = ".1.3.6.1.4.1.2636.3.60.1.1.1.1.5"  // 0.01 dBm
+	oidJnxDomBias        = ".1.3.6.1.4.1.2636.3.60.1.1.1.1.6"  // µA
+	oidJnxDomTxPower     = ".1.3.6.1.4.1.2636.3.60.1.1.1.1.7"  // 0.01 dBm
+	oidJnxDomTemperature = ".1.3.6.1.4.1.2636.3.60.1.1.1.1.8"  // °C
+	oidJnxDomVoltage     = ".1.3.6.1.4.1.2636.3.60.1.1.1.1.25" // mV
+)
+
+// 光模块 DOM 指标
+const (
+	domRxPower     = "rxPower"     // dBm
+	domTxPower     = "txPower"     // dBm
+	domBiasCurrent = "biasCurrent" // mA
+	domTemperature = "temperature" // °C
+	domVoltage     = "voltage"     // V
+	domPower       = "power"       // 收发方向待按传感器名称判断
+)
+
+// domNoLight 光功率为 0 时的 dBm 下限 (无光)
+const domNoLight = -40.0
+
+// OpticalDOM 光模块数字诊断 (DOM) 读数, 多通道模块取第一通道, 告警覆盖全部通道
+type OpticalDOM struct {
+	TxPower     *float64       `json:"txPower,omitempty"`     // dBm
+	RxPower     *float64       `json:"rxPower,omitempty"`     // dBm
+	BiasCurrent *float64       `json:"biasCurrent,omitempty"` // mA
+	Temperature *float64       `json:"temperature,omitempty"` // °C
+	Voltage     *float64       `json:"voltage,omitempty"`     // V
+	Alarms      []OpticalAlarm `json:"alarms,omitempty"`
+}
+
+// OpticalAlarm 超出模块上报阈值的读数
+type OpticalAlarm struct {
+	Metric    string  `json:"metric"` // rxPower / txPower / biasCurrent / temperature / voltage
+	Sensor    string  `json:"sensor,omitempty"`
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+	Severity  string  `json:"severity"`  // warning / critical
+	Direction string  `json:"direction"` // high / low
+}
+
+// domSensor 单个 DOM 读数, entity 非 0 时需经实体树关联到接口
+type domSensor struct {
+	entity     int
+	ifIndex    int
+	name       string
+	metric     string
+	value      float64
+	thresholds []domThreshold
+}
+
+// domThreshold 读数满足 relation 时告警
+type domThreshold struct {
+	severity string
+	relation string // < / <= / > / >=
+	value    float64
+}
+
+// domProfile 按 sysObjectID 前缀选择的 DOM 采集方式
+type domProfile struct {
+	name    string
+	prefix  string
+	collect func(snmp *gosnmp.GoSNMP) []domSensor
+}
+
+// domProfiles 厂商 DOM 采集配置, 按最长前缀匹配; 未匹配或厂商 MIB 无数据时使用 ENTITY-SENSOR-MIB
+var domProfiles = []domProfile{
+	{name: "cisco", prefix: ".1.3.6.1.4.1.9.", collect: collectCiscoDOM},
+	{name: "huawei", prefix: ".1.3.6.1.4.1.2011.", collect: collectHuaweiDOM},
+	{name: "h3c", prefix: ".1.3.6.1.4.1.25506.", collect: collectH3CDOM},
+	{name: "juniper", prefix: ".1.3.6.1.4.1.2636.", collect: collectJuniperDOM},
+	{name: "arista", prefix: ".1.3.6.1.4.1.30065.", collect: collectAristaDOM},
+}
+
+func lookupDOMProfile(sysObjectID string) *domProfile {
+	if sysObjectID == "" {
+		return nil
+	}
+	oid := "." + strings.Trim(sysObjectID, ".") + "."
+	var best *domProfile
+	for i := range domProfiles {
+		p := &domProfiles[i]
+		if strings.HasPrefix(oid, p.prefix) && (best == nil || len(p.prefix) > len(best.prefix)) {
+			best = p
+		}
+	}
+	return best
+}
+
+// collectDOM 采集光模块 DOM 并附加到对应接口
+func (c *Collector) collectDOM(snmp *gosnmp.GoSNMP, sysObjectID string, interfaces []IfStats) {
+	var sensors []domSensor
+	if profile := lookupDOMProfile(sysObjectID); profile != nil {
+		sensors = profile.collect(snmp)
+	}
+	if len(sensors) == 0 {
+		sensors = collectEntitySensorDOM(snmp, nil)
+	}
+	if len(sensors) == 0 {
+		return
+	}
+
+	sensors = resolveDOMInterfaces(snmp, sensors, interfaces)
+	attachDOM(sensors, interfaces)
+}
+
+// resolveDOMInterfaces 将按实体索引的读数关联到接口, 并按名称确定功率的收发方向
+// 依次尝试实体自身及其上级的 entAliasMappingTable 映射与接口名称, 最后匹配传感器名称中的接口名
+// (如 "Te1/1/1 Receive Power Sensor"、"DOM Rx Power Sensor for Ethernet1")
+func resolveDOMInterfaces(snmp *gosnmp.GoSNMP, sensors []domSensor, interfaces []IfStats) []domSensor {
+	var names map[int]string
+	var parents, aliases map[int]int
+	for _, s := range sensors {
+		if s.entity != 0 {
+			names, parents = make(map[int]string), make(map[int]int)
+			if table, err := walkTable(snmp, oidEntPhysicalName, oidEntPhysicalContainedIn); err == nil {
+				for index := range table {
+					n, err := strconv.Atoi(index)
+					if err != nil {
+						continue
+					}
+					names[n] = strings.TrimSpace(table.rowString(index, oidEntPhysicalName))
+					if v, ok := table.rowInt(index, oidEntPhysicalContainedIn); ok {
+						parents[n] = int(v)
+					}
+				}
+			}
+			aliases = entityIfIndexes(snmp)
+			break
+		}
+	}
+
+	byName := make(map[string]int, len(interfaces)*2)
+	for _, ifs := range interfaces {
+		if ifs.Descr != "" {
+			byName[ifs.Descr] = ifs.Index
+		}
+		if ifs.Name != "" {
+			byName[ifs.Name] = ifs.Index
+		}
+	}
+
+	out := sensors[:0]
+	for _, s := range sensors {
+		if s.entity != 0 {
+			if s.name == "" {
+				s.name = names[s.entity]
+			}
+			// 包含关系深度有限, 防止异常数据成环
+			for idx, depth := s.entity, 0; idx > 0 && depth < 8 && s.ifIndex == 0; idx, depth = parents[idx], depth+1 {
+				if ifIndex, ok := aliases[idx]; ok {
+					s.ifIndex = ifIndex
+				} else if ifIndex, ok := byName[names[idx]]; ok {
+					s.ifIndex = ifIndex
+				}
+			}
+			for _, token := range strings.Fields(s.name) {
+				if s.ifIndex != 0 {
+					break
+				}
+				s.ifIndex = byName[token]
+			}
+		}
+		if s.ifIndex == 0 {
+			continue
+		}
+		if s.metric == domPower {
+			if s.metric = powerDirection(s.name); s.metric == "" {
+				continue
+			}
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// powerDirection 按传感器名称判断收/发光功率
+func powerDirection(name string) string {
+	lower := strings.ToLower(name)
+	for _, token := range strings.FieldsFunc(lower, func(r rune) bool { return r == ' ' || r == '-' || r == '_' }) {
+		switch token {
+		case "rx", "receive", "received", "input":
+			return domRxPower
+		case "tx", "transmit", "transmitted", "output":
+			return domTxPower
+		}
+	}
+	return ""
+}
+
+// attachDOM 按接口汇总读数, 每个指标取实体索引最小的通道, 并评估全部阈值
+func attachDOM(sensors []domSensor, interfaces []IfStats) {
+	sort.SliceStable(sensors, func(i, j int) bool { return sensors[i].entity < sensors[j].entity })
+
+	byIfIndex := make(map[int]*IfStats, len(interfaces))
+	for i := range interfaces {
+		byIfIndex[interfaces[i].Index] = &interfaces[i]
+	}
+
+	for _, s := range sensors {
+		ifs, ok := byIfIndex[s.ifIndex]
+		if !ok {
+			continue
+		}
+		if ifs.Optical == nil {
+			ifs.Optical = &OpticalDOM{}
+		}
+		dom := ifs.Optical
+
+		value := round2(s.value)
+		var field **float64
+		switch s.metric {
+		case domRxPower:
+			field = &dom.RxPower
+		case domTxPower:
+			field = &dom.TxPower
+		case domBiasCurrent:
+			field = &dom.BiasCurrent
+		case domTemperature:
+			field = &dom.Temperature
+		case domVoltage:
+			field = &dom.Voltage
+		default:
+			continue
+		}
+		if *field == nil {
+			*field = &value
+		}
+		if alarm, ok := s.alarm(); ok {
+			dom.Alarms = append(dom.Alarms, alarm)
+		}
+	}
+}
+
+// alarm 返回读数越过的最严重阈值
+func (s domSensor) alarm() (OpticalAlarm, bool) {
+	var worst *domThreshold
+	for i := range s.thresholds {
+		t := &s.thresholds[i]
+		var crossed bool
+		switch t.relation {
+		case "<":
+			crossed = s.value < t.value
+		case "<=":
+			crossed = s.value <= t.value
+		case ">":
+			crossed = s.value > t.value
+		case ">=":
+			crossed = s.value >= t.value
+		}
+		if crossed && (worst == nil || (t.severity == "critical" && worst.severity != "critical")) {
+			worst = t
+		}
+	}
+	if worst == nil {
+		return OpticalAlarm{}, false
+	}
+
+	direction := "high"
+	if strings.HasPrefix(worst.relation, "<") {
+		direction = "low"
+	}
+	return OpticalAlarm{
+		Metric:    s.metric,
+		Sensor:    s.name,
+		Value:     round2(s.value),
+		Threshold: round2(worst.value),
+		Severity:  worst.severity,
+		Direction: direction,
+	}, true
+}
+
+// sensorValue 按 SensorDataScale (9 = units, 每级 10^3) 及小数位换算读数
+func sensorValue(raw, scale, precision int64) float64 {
+	return float64(raw) * math.Pow(10, float64((scale-9)*3)-float64(precision))
+}
+
+// sensorMetric 将 ENTITY-SENSOR-MIB / CISCO-ENTITY-SENSOR-MIB 的传感器类型及读数换算为 DOM 指标
+func sensorMetric(sensorType int64, value float64) (string, float64, bool) {
+	switch sensorType {
+	case 4: // voltsDC
+		return domVoltage, value, true
+	case 5: // amperes
+		return domBiasCurrent, value * 1000, true
+	case 6: // watts
+		return domPower, milliwattsToDBm(value * 1000), true
+	case 8: // celsius
+		return domTemperature, value, true
+	case 14: // dBm (仅 CISCO-ENTITY-SENSOR-MIB)
+		return domPower, value, true
+	}
+	return "", 0, false
+}
+
+func milliwattsToDBm(mw float64) float64 {
+	if mw <= 0 {
+		return domNoLight
+	}
+	return math.Max(10*math.Log10(mw), domNoLight)
+}
+
+// collectEntitySensorDOM ENTITY-SENSOR-MIB, thresholds 提供按实体索引的阈值 (可为 nil)
+// 读数需经实体树关联到接口, 无法关联的机框/电源等传感器被丢弃
+func collectEntitySensorDOM(snmp *gosnmp.GoSNMP, thresholds func(entity string, sensorType, scale, precision int64) []domThreshold) []domSensor {
+	table, err := walkTable(snmp, oidEntPhySensorType, oidEntPhySensorScale, oidEntPhySensorPrecision,
+		oidEntPhySensorValue, oidEntPhySensorStatus)
+	if err != nil {
+		return nil
+	}
+
+	var sensors []domSensor
+	for index := range table {
+		entity, err := strconv.Atoi(index)
+		if err != nil {
+			continue
+		}
+		// entPhySensorOperStatus: 1 = ok
+		if status, ok := table.rowInt(index, oidEntPhySensorStatus); ok && status != 1 {
+			continue
+		}
+		sensorType, _ := table.rowInt(index, oidEntPhySensorType)
+		scale, _ := table.rowInt(index, oidEntPhySensorScale)
+		precision, _ := table.rowInt(index, oidEntPhySensorPrecision)
+		raw, ok := table.rowInt(index, oidEntPhySensorValue)
+		if !ok {
+			continue
+		}
+		metric, value, ok := sensorMetric(sensorType, sensorValue(raw, scale, precision))
+		if !ok {
+			continue
+		}
+		s := domSensor{entity: entity, metric: metric, value: value}
+		if thresholds != nil {
+			s.thresholds = thresholds(index, sensorType, scale, precision)
+		}
+		sensors = append(sensors, s)
+	}
+	return sensors
+}
+
+// collectAristaDOM ENTITY-SENSOR-MIB 读数及 ARISTA-ENTITY-SENSOR-MIB 阈值
+func collectAristaDOM(snmp *gosnmp.GoSNMP) []domSensor {
+	limits, err := walkTable(snmp, oidAristaSensorLowWarning, oidAristaSensorLowCritical,
+		oidAristaSensorHighWarning, oidAristaSensorHighCritical)
+	if err != nil {
+		limits = snmpTable{}
+	}
+
+	columns := []struct {
+		oid      string
+		severity string
+		relation string
+	}{
+		{oidAristaSensorLowWarning, "warning", "<"},
+		{oidAristaSensorLowCritical, "critical", "<"},
+		{oidAristaSensorHighWarning, "warning", ">"},
+		{oidAristaSensorHighCritical, "critical", ">"},
+	}
+	return collectEntitySensorDOM(snmp, func(entity string, sensorType, scale, precision int64) []domThreshold {
+		var out []domThreshold
+		for _, col := range columns {
+			raw, ok := limits.rowInt(entity, col.oid)
+			// 未设置的阈值为 ±1000000000 量级的哨兵值
+			if !ok || raw <= -1000000000 || raw >= 1000000000 {
+				continue
+			}
+			if _, value, ok := sensorMetric(sensorType, sensorValue(raw, scale, precision)); ok {
+				out = append(out, domThreshold{severity: col.severity, relation: col.relation, value: value})
+			}
+		}
+		return out
+	})
+}
+
+// collectCiscoDOM CISCO-ENTITY-SENSOR-MIB, 阈值与读数使用相同的 scale / precision
+func collectCiscoDOM(snmp *gosnmp.GoSNMP) []domSensor {
+	table, err := walkTable(snmp, oidCiscoSensorType, oidCiscoSensorScale, oidCiscoSensorPrecision,
+		oidCiscoSensorValue, oidCiscoSensorStatus)
+	if err != nil {
+		return nil
+	}
+	limits, err := walkTable(snmp, oidCiscoSensorThresholdSev, oidCiscoSensorThresholdRel, oidCiscoSensorThresholdValue)
+	if err != nil {
+		limits = snmpTable{}
+	}
+
+	// 阈值表索引: entPhysicalIndex.entSensorThresholdIndex
+	thresholds := make(map[string][]string)
+	for index := range limits {
+		if i := strings.IndexByte(index, '.'); i > 0 {
+			thresholds[index[:i]] = append(thresholds[index[:i]], index)
+		}
+	}
+
+	var sensors []domSensor
+	for index := range table {
+		entity, err := strconv.Atoi(index)
+		if err != nil {
+			continue
+		}
+		// entSensorStatus: 1 = ok
+		if status, ok := table.rowInt(index, oidCiscoSensorStatus); ok && status != 1 {
+			continue
+		}
+		sensorType, _ := table.rowInt(index, oidCiscoSensorType)
+		scale, _ := table.rowInt(index, oidCiscoSensorScale)
+		precision, _ := table.rowInt(index, oidCiscoSensorPrecision)
+		raw, ok := table.rowInt(index, oidCiscoSensorValue)
+		if !ok {
+			continue
+		}
+		metric, value, ok := sensorMetric(sensorType, sensorValue(raw, scale, precision))
+		if !ok {
+			continue
+		}
+
+		s := domSensor{entity: entity, metric: metric, value: value}
+		for _, ti := range thresholds[index] {
+			severity, _ := limits.rowInt(ti, oidCiscoSensorThresholdSev)
+			relation, _ := limits.rowInt(ti, oidCiscoSensorThresholdRel)
+			tv, ok := limits.rowInt(ti, oidCiscoSensorThresholdValue)
+			if !ok {
+				continue
+			}
+			_, threshold, _ := sensorMetric(sensorType, sensorValue(tv, scale, precision))
+			t := domThreshold{severity: "warning", value: threshold}
+			// CiscoSensorThresholdSeverity: 10 minor / 20 major / 30 critical
+			if severity >= 20 {
+				t.severity = "critical"
+			}
+			// CiscoSensorThresholdRelation: 1 lessThan / 2 lessOrEqual / 3 greaterThan / 4 greaterOrEqual
+			switch relation {
+			case 1:
+				t.relation = "<"
+			case 2:
+				t.relation = "<="
+			case 3:
+				t.relation = ">"
+			case 4:
+				t.relation = ">="
+			default:
+				continue
+			}
+			s.thresholds = append(s.thresholds, t)
+		}
+		sensors = append(sensors, s)
+	}
+	return sensors
+}
+
+// collectHuaweiDOM HUAWEI-ENTITY-EXTENT-MIB 光模块信息表, 收发功率阈值为告警门限
+func collectHuaweiDOM(snmp *gosnmp.GoSNMP) []domSensor {
+	table, err := walkTable(snmp, oidHwOpticalMode, oidHwOpticalTemperature, oidHwOpticalVoltage,
+		oidHwOpticalBiasCurrent, oidHwOpticalRxPower, oidHwOpticalTxPower,
+		oidHwOpticalRxLowThreshold, oidHwOpticalRxHighThresh, oidHwOpticalTxLowThreshold, oidHwOpticalTxHighThresh)
+	if err != nil {
+		return nil
+	}
+
+	valid := func(v int64) bool { return v != -1 && v != math.MaxInt32 }
+	limit := func(index, column, relation string) []domThreshold {
+		if v, ok := table.rowInt(index, column); ok && valid(v) {
+			return []domThreshold{{severity: "critical", relation: relation, value: float64(v) / 100}}
+		}
+		return nil
+	}
+
+	var sensors []domSensor
+	for index := range table {
+		entity, err := strconv.Atoi(index)
+		if err != nil {
+			continue
+		}
+		if mode, ok := table.rowInt(index, oidHwOpticalMode); !ok || mode == 1 {
+			continue
+		}
+		add := func(column, metric string, convert func(int64) float64, thresholds ...[]domThreshold) {
+			v, ok := table.rowInt(index, column)
+			if !ok || !valid(v) {
+				return
+			}
+			s := domSensor{entity: entity, metric: metric, value: convert(v)}
+			for _, t := range thresholds {
+				s.thresholds = append(s.thresholds, t...)
+			}
+			sensors = append(sensors, s)
+		}
+		microwatts := func(v int64) float64 { return milliwattsToDBm(float64(v) / 1000) }
+		add(oidHwOpticalTemperature, domTemperature, func(v int64) float64 { return float64(v) })
+		add(oidHwOpticalVoltage, domVoltage, func(v int64) float64 { return float64(v) / 1000 })
+		add(oidHwOpticalBiasCurrent, domBiasCurrent, func(v int64) float64 { return float64(v) / 1000 })
+		add(oidHwOpticalRxPower, domRxPower, microwatts,
+			limit(index, oidHwOpticalRxLowThreshold, "<"), limit(index, oidHwOpticalRxHighThresh, ">"))
+		add(oidHwOpticalTxPower, domTxPower, microwatts,
+			limit(index, oidHwOpticalTxLowThreshold, "<"), limit(index, oidHwOpticalTxHighThresh, ">"))
+	}
+	return sensors
+}
+
+// collectH3CDOM HH3C-TRANSCEIVER-INFO-MIB (无阈值)
+func collectH3CDOM(snmp *gosnmp.GoSNMP) []domSensor {
+	table, err := walkTable(snmp, oidHH3CTransceiverTxPower, oidHH3CTransceiverRxPower,
+		oidHH3CTransceiverTemperature, oidHH3CTransceiverVoltage, oidHH3CTransceiverBias)
+	if err != nil {
+		return nil
+	}
+
+	columns := []struct {
+		oid     string
+		metric  string
+		divisor float64
+	}{
+		{oidHH3CTransceiverTxPower, domTxPower, 100},
+		{oidHH3CTransceiverRxPower, domRxPower, 100},
+		{oidHH3CTransceiverTemperature, domTemperature, 1},
+		{oidHH3CTransceiverVoltage, domVoltage, 100},
+		{oidHH3CTransceiverBias, domBiasCurrent, 100},
+	}
+	var sensors []domSensor
+	for index := range table {
+		ifIndex, err := strconv.Atoi(index)
+		if err != nil {
+			continue
+		}
+		for _, col := range columns {
+			// 不支持诊断的模块返回 0x7FFFFFFF
+			if v, ok := table.rowInt(index, col.oid); ok && v != math.MaxInt32 {
+				sensors = append(sensors, domSensor{ifIndex: ifIndex, metric: col.metric, value: float64(v) / col.divisor})
+			}
+		}
+	}
+	return sensors
+}
+
+// collectJuniperDOM JUNIPER-DOM-MIB, 含告警 (critical) 与预警 (warning) 门限
+func collectJuniperDOM(snmp *gosnmp.GoSNMP) []domSensor {
+	// 各指标的读数列及 高告警 / 低告警 / 高预警 / 低预警 列 (列号 +0..+3)
+	metrics := []struct {
+		oid       string
+		metric    string
+		divisor   float64
+		threshold int // 首个阈值列号, 0 表示无阈值
+	}{
+		{oidJnxDomRxPower, domRxPower, 100, 9},
+		{oidJnxDomBias, domBiasCurrent, 1000, 13},
+		{oidJnxDomTxPower, domTxPower, 100, 17},
+		{oidJnxDomTemperature, domTemperature, 1, 21},
+		{oidJnxDomVoltage, domVoltage, 1000, 0},
+	}
+	const entry = ".1.3.6.1.4.1.2636.3.60.1.1.1.1."
+
+	columns := make([]string, 0, 21)
+	for _, m := range metrics {
+		columns = append(columns, m.oid)
+		for i := 0; m.threshold > 0 && i < 4; i++ {
+			columns = append(columns, entry+strconv.Itoa(m.threshold+i))
+		}
+	}
+	table, err := walkTable(snmp, columns...)
+	if err != nil {
+		return nil
+	}
+
+	limits := []struct {
+		severity string
+		relation string
+	}{
+		{"critical", ">"},
+		{"critical", "<"},
+		{"warning", ">"},
+		{"warning", "<"},
+	}
+	var sensors []domSensor
+	for index := range table {
+		ifIndex, err := strconv.Atoi(index)
+		if err != nil {
+			continue
+		}
+		for _, m := range metrics {
+			v, ok := table.rowInt(index, m.oid)
+			if !ok {
+				continue
+			}
+			s := domSensor{ifIndex: ifIndex, metric: m.metric, value: float64(v) / m.divisor}
+			for i := 0; m.threshold > 0 && i < 4; i++ {
+				if t, ok := table.rowInt(index, entry+strconv.Itoa(m.threshold+i)); ok {
+					s.thresholds = append(s.thresholds, domThreshold{
+						severity: limits[i].severity,
+						relation: limits[i].relation,
+						value:    float64(t) / m.divisor,
+					})
+				}
+			}
+			sensors = append(sensors, s)
+		}
+	}
+	return sensors
+}
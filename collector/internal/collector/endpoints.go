@@ -0,0 +1,293 @@
+package collector
+
+import (
+	"net"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// ARP / 转发表 OID
+const (
+	// IP-MIB ipNetToMediaTable, 索引: ifIndex.a.b.c.d
+	oidIpNetToMediaPhysAddress = ".1.3.6.1.2.1.4.22.1.2"
+	oidIpNetToMediaType        = ".1.3.6.1.2.1.4.22.1.4"
+
+	// IP-MIB ipNetToPhysicalTable, 索引: ifIndex.addrType.addrLen.addr
+	oidIpNetToPhysicalPhysAddress = ".1.3.6.1.2.1.4.35.1.4"
+	oidIpNetToPhysicalType        = ".1.3.6.1.2.1.4.35.1.6"
+
+	// BRIDGE-MIB
+	oidDot1dBasePortIfIndex = ".1.3.6.1.2.1.17.1.4.1.2" // 索引: bridgePort
+	oidDot1dTpFdbPort       = ".1.3.6.1.2.1.17.4.3.1.2" // 索引: mac
+	oidDot1dTpFdbStatus     = ".1.3.6.1.2.1.17.4.3.1.3"
+
+	// Q-BRIDGE-MIB
+	oidDot1qTpFdbPort   = ".1.3.6.1.2.1.17.7.1.2.2.1.2" // 索引: fdbId.mac
+	oidDot1qTpFdbStatus = ".1.3.6.1.2.1.17.7.1.2.2.1.3"
+	oidDot1qVlanFdbId   = ".1.3.6.1.2.1.17.7.1.4.2.1.3" // 索引: timeMark.vlanIndex
+)
+
+// arpTypeNames ipNetToMediaType / ipNetToPhysicalType 取值
+var arpTypeNames = map[int64]string{
+	1: "other",
+	2: "invalid",
+	3: "dynamic",
+	4: "static",
+	5: "local",
+}
+
+// fdbStatusNames dot1dTpFdbStatus / dot1qTpFdbStatus 取值
+var fdbStatusNames = map[int64]string{
+	1: "other",
+	2: "invalid",
+	3: "learned",
+	4: "self",
+	5: "mgmt",
+}
+
+// ARPEntry ARP表项 (IP -> MAC)
+type ARPEntry struct {
+	IP        string `json:"ip"`
+	MAC       string `json:"mac"`
+	IfIndex   int    `json:"ifIndex"`
+	Interface string `json:"interface,omitempty"`
+	Type      string `json:"type,omitempty"` // dynamic / static / ...
+}
+
+// FDBEntry MAC转发表项 (MAC -> 端口)
+type FDBEntry struct {
+	MAC        string `json:"mac"`
+	VLAN       int    `json:"vlan,omitempty"`
+	BridgePort int    `json:"bridgePort"`
+	IfIndex    int    `json:"ifIndex,omitempty"`
+	Interface  string `json:"interface,omitempty"`
+	Status     string `json:"status,omitempty"` // learned / self / ...
+}
+
+// EndpointTable 单台设备的终端定位表快照 (ARP + MAC转发表)
+// 由 reporter 与上次成功上报的快照比较后以增量形式发送
+type EndpointTable struct {
+	CollectorID string     `json:"collectorId"`
+	DeviceID    string     `json:"deviceId"`
+	IP          string     `json:"ip"`
+	ARP         []ARPEntry `json:"arp"`
+	FDB         []FDBEntry `json:"fdb"`
+	CollectedAt time.Time  `json:"collectedAt"`
+}
+
+// collectsEndpoints 仅对网络设备采集终端定位表
+func collectsEndpoints(deviceType string) bool {
+	switch deviceType {
+	case "router", "switch", "firewall":
+		return true
+	default:
+		return false
+	}
+}
+
+// collectARP 采集ARP表, 优先 ipNetToPhysicalTable (支持IPv6), 为空时回退 ipNetToMediaTable
+// 任一表遍历失败 (包括中途超时) 时返回 nil (区别于空表), 本周期不比较增量, 避免把未取回的表项当作已删除
+func (c *Collector) collectARP(snmp *gosnmp.GoSNMP, ifNames map[int]string) []ARPEntry {
+	entries := make([]ARPEntry, 0)
+
+	table, err := walkTable(snmp, oidIpNetToPhysicalPhysAddress, oidIpNetToPhysicalType)
+	if err != nil {
+		c.logger.WithError(err).WithField("ip", snmp.Target).Debug("ARP table walk failed")
+		return nil
+	}
+	for index := range table {
+		idx, err := parseIndex(index)
+		// ifIndex.addrType.addrLen.addr[addrLen]
+		if err != nil || len(idx) < 3 || len(idx) != 3+idx[2] {
+			continue
+		}
+		ip := ipFromIndex(idx[3:])
+		if ip == "" {
+			continue
+		}
+		entries = appendARP(entries, table, index, oidIpNetToPhysicalPhysAddress, oidIpNetToPhysicalType, idx[0], ip, ifNames)
+	}
+
+	if len(entries) == 0 {
+		table, err = walkTable(snmp, oidIpNetToMediaPhysAddress, oidIpNetToMediaType)
+		if err != nil {
+			c.logger.WithError(err).WithField("ip", snmp.Target).Debug("ARP table walk failed")
+			return nil
+		}
+		for index := range table {
+			idx, err := parseIndex(index)
+			if err != nil || len(idx) != 5 {
+				continue
+			}
+			entries = appendARP(entries, table, index, oidIpNetToMediaPhysAddress, oidIpNetToMediaType, idx[0], ipFromIndex(idx[1:]), ifNames)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].IP != entries[j].IP {
+			return entries[i].IP < entries[j].IP
+		}
+		return entries[i].IfIndex < entries[j].IfIndex
+	})
+	return entries
+}
+
+func appendARP(entries []ARPEntry, table snmpTable, index, macCol, typeCol string, ifIndex int, ip string, ifNames map[int]string) []ARPEntry {
+	mac := pduBytes(table[index][macCol])
+	if len(mac) != 6 {
+		return entries
+	}
+	typ, _ := table.rowInt(index, typeCol)
+	if typ == 2 { // invalid
+		return entries
+	}
+	return append(entries, ARPEntry{
+		IP:        ip,
+		MAC:       formatMAC(mac),
+		IfIndex:   ifIndex,
+		Interface: ifNames[ifIndex],
+		Type:      arpTypeNames[typ],
+	})
+}
+
+// collectFDB 采集MAC转发表, 优先 dot1qTpFdbTable (含VLAN), 为空时回退 dot1dTpFdbTable
+// 转发表或端口/VLAN映射表遍历失败时返回 nil (区别于空表), 映射不完整会改变表项的接口与VLAN
+func (c *Collector) collectFDB(snmp *gosnmp.GoSNMP, ifNames map[int]string) []FDBEntry {
+	entries := make([]FDBEntry, 0)
+	logger := c.logger.WithField("ip", snmp.Target)
+
+	portIfIndex := make(map[int]int)
+	ports, err := walkTable(snmp, oidDot1dBasePortIfIndex)
+	if err != nil {
+		logger.WithError(err).Debug("Bridge port table walk failed")
+		return nil
+	}
+	for index := range ports {
+		port, err := strconv.Atoi(index)
+		if err != nil {
+			continue
+		}
+		if ifIndex, ok := ports.rowInt(index, oidDot1dBasePortIfIndex); ok {
+			portIfIndex[port] = int(ifIndex)
+		}
+	}
+
+	// fdbId 通常等于VLAN ID, 设备提供 dot1qVlanFdbId 时以其映射为准
+	fdbVLAN := make(map[int]int)
+	vlans, err := walkTable(snmp, oidDot1qVlanFdbId)
+	if err != nil {
+		logger.WithError(err).Debug("VLAN FDB table walk failed")
+		return nil
+	}
+	for index := range vlans {
+		idx, err := parseIndex(index)
+		if err != nil || len(idx) != 2 {
+			continue
+		}
+		if fdbID, ok := vlans.rowInt(index, oidDot1qVlanFdbId); ok {
+			fdbVLAN[int(fdbID)] = idx[1]
+		}
+	}
+
+	table, err := walkTable(snmp, oidDot1qTpFdbPort, oidDot1qTpFdbStatus)
+	if err != nil {
+		logger.WithError(err).Debug("FDB table walk failed")
+		return nil
+	}
+	for index := range table {
+		idx, err := parseIndex(index)
+		if err != nil || len(idx) != 7 {
+			continue
+		}
+		vlan, ok := fdbVLAN[idx[0]]
+		if !ok {
+			vlan = idx[0]
+		}
+		entries = appendFDB(entries, table, index, oidDot1qTpFdbPort, oidDot1qTpFdbStatus, idx[1:], vlan, portIfIndex, ifNames)
+	}
+
+	if len(entries) == 0 {
+		table, err = walkTable(snmp, oidDot1dTpFdbPort, oidDot1dTpFdbStatus)
+		if err != nil {
+			logger.WithError(err).Debug("FDB table walk failed")
+			return nil
+		}
+		for index := range table {
+			idx, err := parseIndex(index)
+			if err != nil || len(idx) != 6 {
+				continue
+			}
+			entries = appendFDB(entries, table, index, oidDot1dTpFdbPort, oidDot1dTpFdbStatus, idx, 0, portIfIndex, ifNames)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].VLAN != entries[j].VLAN {
+			return entries[i].VLAN < entries[j].VLAN
+		}
+		return entries[i].MAC < entries[j].MAC
+	})
+	return entries
+}
+
+func appendFDB(entries []FDBEntry, table snmpTable, index, portCol, statusCol string, macIdx []int, vlan int, portIfIndex map[int]int, ifNames map[int]string) []FDBEntry {
+	port, ok := table.rowInt(index, portCol)
+	if !ok || port == 0 { // 端口0表示未知端口
+		return entries
+	}
+	status, _ := table.rowInt(index, statusCol)
+	if status == 2 || status == 4 { // invalid / self
+		return entries
+	}
+
+	mac := make([]byte, 6)
+	for i, b := range macIdx {
+		mac[i] = byte(b)
+	}
+	ifIndex := portIfIndex[int(port)]
+
+	return append(entries, FDBEntry{
+		MAC:        formatMAC(mac),
+		VLAN:       vlan,
+		BridgePort: int(port),
+		IfIndex:    ifIndex,
+		Interface:  ifNames[ifIndex],
+		Status:     fdbStatusNames[status],
+	})
+}
+
+// ipFromIndex 将OID索引中的地址字节还原为IP
+func ipFromIndex(parts []int) string {
+	if len(parts) != net.IPv4len && len(parts) != net.IPv6len {
+		return ""
+	}
+	ip := make(net.IP, len(parts))
+	for i, p := range parts {
+		if p < 0 || p > 255 {
+			return ""
+		}
+		ip[i] = byte(p)
+	}
+	return ip.String()
+}
+
+// reportEndpoints 上报终端定位表快照
+func (c *Collector) reportEndpoints(device Device, arp []ARPEntry, fdb []FDBEntry) {
+	data := EndpointTable{
+		CollectorID: c.config.Collector.ID,
+		DeviceID:    device.ID,
+		IP:          device.IP,
+		ARP:         arp,
+		FDB:         fdb,
+		CollectedAt: time.Now(),
+	}
+
+	select {
+	case c.endpoints <- data:
+	default:
+		c.logger.WithField("device", device.IP).Warn("Endpoint channel full, dropping endpoint table")
+	}
+}
@@ -0,0 +1,253 @@
+package collector
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/netvis/collector/internal/config"
+)
+
+func init() {
+	RegisterProbe("entity", newEntityProbe)
+}
+
+// ENTITY-MIB entPhysicalTable
+const (
+	oidEntPhysicalDescr        = ".1.3.6.1.2.1.47.1.1.1.1.2"
+	oidEntPhysicalVendorType   = ".1.3.6.1.2.1.47.1.1.1.1.3"
+	oidEntPhysicalContainedIn  = ".1.3.6.1.2.1.47.1.1.1.1.4"
+	oidEntPhysicalClass        = ".1.3.6.1.2.1.47.1.1.1.1.5"
+	oidEntPhysicalParentRelPos = ".1.3.6.1.2.1.47.1.1.1.1.6"
+	oidEntPhysicalName         = ".1.3.6.1.2.1.47.1.1.1.1.7"
+	oidEntPhysicalHardwareRev  = ".1.3.6.1.2.1.47.1.1.1.1.8"
+	oidEntPhysicalFirmwareRev  = ".1.3.6.1.2.1.47.1.1.1.1.9"
+	oidEntPhysicalSoftwareRev  = ".1.3.6.1.2.1.47.1.1.1.1.10"
+	oidEntPhysicalSerialNum    = ".1.3.6.1.2.1.47.1.1.1.1.11"
+	oidEntPhysicalMfgName      = ".1.3.6.1.2.1.47.1.1.1.1.12"
+	oidEntPhysicalModelName    = ".1.3.6.1.2.1.47.1.1.1.1.13"
+	oidEntPhysicalIsFRU        = ".1.3.6.1.2.1.47.1.1.1.1.16"
+
+	// entAliasMappingIdentifier: 实体 -> ifIndex 实例 (.1.3.6.1.2.1.2.2.1.1.<ifIndex>)
+	oidEntAliasMappingIdentifier = ".1.3.6.1.2.1.47.1.3.2.1.2"
+	oidIfIndexPrefix             = ".1.3.6.1.2.1.2.2.1.1."
+)
+
+// entPhysicalClasses PhysicalClass 枚举
+var entPhysicalClasses = map[int64]string{
+	1:  "other",
+	2:  "unknown",
+	3:  "chassis",
+	4:  "backplane",
+	5:  "container",
+	6:  "powerSupply",
+	7:  "fan",
+	8:  "sensor",
+	9:  "module",
+	10: "port",
+	11: "stack",
+	12: "cpu",
+}
+
+// transceiverPattern 光模块识别 (模块类实体的描述/名称/型号)
+var transceiverPattern = regexp.MustCompile(`(?i)\b(Q?SFP(28|56|-?DD)?\+?|XFP|CFP\d?|GBIC|OSFP|transceiver|optic(al)?)\b`)
+
+// InventoryItem 物理实体 (机框、板卡、电源、风扇、光模块等)
+// ContainedIn 为父实体索引 (0 表示顶层), 与 ParentRelPos 共同构成包含关系树
+type InventoryItem struct {
+	Index        int    `json:"index"`
+	Class        string `json:"class"`
+	Name         string `json:"name,omitempty"`
+	Descr        string `json:"descr,omitempty"`
+	ContainedIn  int    `json:"containedIn"`
+	ParentRelPos int    `json:"parentRelPos"`
+	VendorType   string `json:"vendorType,omitempty"`
+	HardwareRev  string `json:"hardwareRev,omitempty"`
+	FirmwareRev  string `json:"firmwareRev,omitempty"`
+	SoftwareRev  string `json:"softwareRev,omitempty"`
+	SerialNumber string `json:"serialNumber,omitempty"`
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Model        string `json:"model,omitempty"` // entPhysicalModelName (部件号)
+	FRU          bool   `json:"fru,omitempty"`   // 可现场更换
+	Transceiver  bool   `json:"transceiver,omitempty"`
+	IfIndex      int    `json:"ifIndex,omitempty"` // entAliasMappingTable 映射的接口
+}
+
+// Inventory 设备硬件清单, Hash 未变化时不重复上报
+type Inventory struct {
+	CollectorID string          `json:"collectorId"`
+	DeviceID    string          `json:"deviceId"`
+	IP          string          `json:"ip"`
+	Items       []InventoryItem `json:"items"`
+	Hash        string          `json:"hash"`
+	CollectedAt time.Time       `json:"collectedAt"`
+}
+
+// entityProbe 通过 ENTITY-MIB 采集硬件清单, 清单很少变化, 通常配置较长的采集间隔
+type entityProbe struct {
+	c     *Collector
+	name  string
+	types deviceTypeFilter
+}
+
+func newEntityProbe(c *Collector, cfg config.ProbeConfig) (Probe, error) {
+	return &entityProbe{c: c, name: cfg.Name, types: newDeviceTypeFilter(cfg.DeviceTypes)}, nil
+}
+
+func (p *entityProbe) Name() string { return p.name }
+
+func (p *entityProbe) Applicable(device Device) bool {
+	return p.types.match(device.Type) && p.c.snmpEnabled(device)
+}
+
+func (p *entityProbe) Collect(ctx context.Context, device Device, sample *DeviceMetrics) error {
+	snmp, err := p.c.newSNMPClient(device)
+	if err != nil {
+		return err
+	}
+	if err := snmp.Connect(); err != nil {
+		return err
+	}
+	defer snmp.Conn.Close()
+	defer p.c.engines.save(snmp)
+
+	items, err := collectEntities(snmp)
+	if err != nil {
+		// 引擎参数失效时丢弃缓存, 下一周期重新发现
+		if isEngineReport(err) {
+			p.c.engines.invalidate(snmp)
+		}
+		return err
+	}
+	// 未实现 ENTITY-MIB 的设备 (如多数服务器) 不上报
+	if len(items) == 0 {
+		return nil
+	}
+	p.c.reportInventory(device, items)
+	return nil
+}
+
+// collectEntities 遍历 entPhysicalTable, 按实体索引排序
+func collectEntities(snmp *gosnmp.GoSNMP) ([]InventoryItem, error) {
+	table, err := walkTable(snmp,
+		oidEntPhysicalDescr, oidEntPhysicalVendorType, oidEntPhysicalContainedIn, oidEntPhysicalClass,
+		oidEntPhysicalParentRelPos, oidEntPhysicalName, oidEntPhysicalHardwareRev, oidEntPhysicalFirmwareRev,
+		oidEntPhysicalSoftwareRev, oidEntPhysicalSerialNum, oidEntPhysicalMfgName, oidEntPhysicalModelName,
+		oidEntPhysicalIsFRU)
+	if err != nil {
+		return nil, err
+	}
+
+	// 别名映射表可选, 失败时不影响清单
+	ifIndexes := entityIfIndexes(snmp)
+
+	items := make([]InventoryItem, 0, len(table))
+	for index := range table {
+		n, err := strconv.Atoi(index)
+		if err != nil {
+			continue
+		}
+		item := InventoryItem{
+			Index:        n,
+			Class:        "unknown",
+			Name:         strings.TrimSpace(table.rowString(index, oidEntPhysicalName)),
+			Descr:        strings.TrimSpace(table.rowString(index, oidEntPhysicalDescr)),
+			HardwareRev:  strings.TrimSpace(table.rowString(index, oidEntPhysicalHardwareRev)),
+			FirmwareRev:  strings.TrimSpace(table.rowString(index, oidEntPhysicalFirmwareRev)),
+			SoftwareRev:  strings.TrimSpace(table.rowString(index, oidEntPhysicalSoftwareRev)),
+			SerialNumber: strings.TrimSpace(table.rowString(index, oidEntPhysicalSerialNum)),
+			Manufacturer: strings.TrimSpace(table.rowString(index, oidEntPhysicalMfgName)),
+			Model:        strings.TrimSpace(table.rowString(index, oidEntPhysicalModelName)),
+			IfIndex:      ifIndexes[n],
+		}
+		if v, ok := table.rowInt(index, oidEntPhysicalClass); ok {
+			if class, ok := entPhysicalClasses[v]; ok {
+				item.Class = class
+			}
+		}
+		if v, ok := table.rowInt(index, oidEntPhysicalContainedIn); ok {
+			item.ContainedIn = int(v)
+		}
+		if v, ok := table.rowInt(index, oidEntPhysicalParentRelPos); ok {
+			item.ParentRelPos = int(v)
+		}
+		if v, ok := table.rowInt(index, oidEntPhysicalIsFRU); ok {
+			item.FRU = v == 1
+		}
+		// zeroDotZero 表示未知类型
+		if vt := strings.Trim(table.rowString(index, oidEntPhysicalVendorType), "."); vt != "0.0" && vt != "" {
+			item.VendorType = "." + vt
+		}
+		item.Transceiver = isTransceiver(item)
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].Index < items[j].Index })
+	return items, nil
+}
+
+// entityIfIndexes 遍历 entAliasMappingTable, 返回 entPhysicalIndex -> ifIndex
+func entityIfIndexes(snmp *gosnmp.GoSNMP) map[int]int {
+	ifIndexes := make(map[int]int)
+	aliases, err := walkTable(snmp, oidEntAliasMappingIdentifier)
+	if err != nil {
+		return ifIndexes
+	}
+	for index, row := range aliases {
+		target := pduString(row[oidEntAliasMappingIdentifier])
+		if !strings.HasPrefix(target, oidIfIndexPrefix) {
+			continue
+		}
+		parts, err := parseIndex(index) // entPhysicalIndex.entAliasLogicalIndexOrZero
+		if err != nil {
+			continue
+		}
+		if ifIndex, err := strconv.Atoi(strings.TrimPrefix(target, oidIfIndexPrefix)); err == nil {
+			ifIndexes[parts[0]] = ifIndex
+		}
+	}
+	return ifIndexes
+}
+
+// isTransceiver 按描述、名称或型号识别光模块, 端口类实体 (如 "SFP port") 不计入
+func isTransceiver(item InventoryItem) bool {
+	switch item.Class {
+	case "module", "other", "unknown":
+	default:
+		return false
+	}
+	return transceiverPattern.MatchString(item.Descr) || transceiverPattern.MatchString(item.Name) ||
+		transceiverPattern.MatchString(item.Model)
+}
+
+// inventoryHash 计算清单摘要 (条目已按索引排序)
+func inventoryHash(items []InventoryItem) string {
+	body, _ := json.Marshal(items)
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// reportInventory 上报硬件清单 (由 reporter 按 Hash 去重后发送至 /collector/inventory)
+func (c *Collector) reportInventory(device Device, items []InventoryItem) {
+	inv := Inventory{
+		CollectorID: c.config.Collector.ID,
+		DeviceID:    device.ID,
+		IP:          device.IP,
+		Items:       items,
+		Hash:        inventoryHash(items),
+		CollectedAt: time.Now(),
+	}
+
+	select {
+	case c.inventory <- inv:
+	default:
+		c.logger.WithField("device", device.IP).Warn("Inventory channel full, dropping inventory")
+	}
+}
@@ -0,0 +1,135 @@
+package collector
+
+import (
+	"time"
+)
+
+// 状态事件类别
+const (
+	EventKindDevice    = "device"
+	EventKindInterface = "interface"
+)
+
+// StateEvent 经抖动抑制确认的状态变化事件, 与周期指标分开上报
+type StateEvent struct {
+	CollectorID   string    `json:"collectorId"`
+	DeviceID      string    `json:"deviceId"`
+	IP            string    `json:"ip"`
+	Kind          string    `json:"kind"`  // device / interface
+	Event         string    `json:"event"` // down / up / change
+	IfIndex       int       `json:"ifIndex,omitempty"`
+	Interface     string    `json:"interface,omitempty"`
+	State         string    `json:"state"`
+	PreviousState string    `json:"previousState"`
+	Duration      float64   `json:"duration"` // 上一状态持续时长(秒)
+	Reason        string    `json:"reason,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// ifaceState 接口状态跟踪
+type ifaceState struct {
+	flap      flapState
+	state     string // 最近一次确认的 ifOperStatus
+	changedAt time.Time
+}
+
+// applyDeviceStatus 更新设备状态, 保持期内不切换 (调用方持有 state.mu)
+func (c *Collector) applyDeviceStatus(state *deviceState, status, reason string, now time.Time) []StateEvent {
+	prev := state.sample.Status
+	if status == prev {
+		state.sample.Reason = reason
+		return nil
+	}
+
+	// 首次判定或失去判定依据时不产生事件
+	if prev == StatusUnknown || status == StatusUnknown {
+		state.sample.Status, state.sample.Reason, state.changedAt = status, reason, now
+		return nil
+	}
+	if hold := c.config.Dampening.HoldDown; hold > 0 && now.Sub(state.changedAt) < hold {
+		return nil
+	}
+
+	event := c.newStateEvent(state.device, EventKindDevice, status, prev, now.Sub(state.changedAt), now)
+	event.Reason = reason
+	switch {
+	case status == StatusUnreachable:
+		event.Event = "down"
+	case prev == StatusUnreachable:
+		event.Event = "up"
+	}
+
+	state.sample.Status, state.sample.Reason, state.changedAt = status, reason, now
+	return []StateEvent{event}
+}
+
+// applyInterfaceStatus 跟踪接口 ifOperStatus 变化 (调用方持有 state.mu)
+// 管理关闭及没有 ifIndex (仅由 gNMI 按名称上报) 的接口不跟踪, 重新启用后重新开始计时
+func (c *Collector) applyInterfaceStatus(state *deviceState, interfaces []IfStats, now time.Time) []StateEvent {
+	var events []StateEvent
+	d := c.config.Dampening
+
+	seen := make(map[int]bool, len(interfaces))
+	for _, ifs := range interfaces {
+		if ifs.Index == 0 || ifs.OperStatus == "" || ifs.AdminStatus == "down" {
+			continue
+		}
+		seen[ifs.Index] = true
+
+		st, ok := state.ifaces[ifs.Index]
+		if !ok {
+			st = &ifaceState{state: ifs.OperStatus, changedAt: now}
+			state.ifaces[ifs.Index] = st
+		}
+		up := ifs.OperStatus == "up"
+		if st.flap.known && up != st.flap.ok && d.HoldDown > 0 && now.Sub(st.changedAt) < d.HoldDown {
+			continue
+		}
+		if !st.flap.observe(up, d) {
+			continue
+		}
+
+		event := c.newStateEvent(state.device, EventKindInterface, ifs.OperStatus, st.state, now.Sub(st.changedAt), now)
+		event.Event = "down"
+		if up {
+			event.Event = "up"
+		}
+		event.IfIndex = ifs.Index
+		event.Interface = ifs.Name
+		events = append(events, event)
+
+		st.state, st.changedAt = ifs.OperStatus, now
+	}
+
+	for index := range state.ifaces {
+		if !seen[index] {
+			delete(state.ifaces, index)
+		}
+	}
+	return events
+}
+
+func (c *Collector) newStateEvent(device Device, kind, current, previous string, duration time.Duration, now time.Time) StateEvent {
+	return StateEvent{
+		CollectorID:   c.config.Collector.ID,
+		DeviceID:      device.ID,
+		IP:            device.IP,
+		Kind:          kind,
+		Event:         "change",
+		State:         current,
+		PreviousState: previous,
+		Duration:      round2(duration.Seconds()),
+		Timestamp:     now,
+	}
+}
+
+// reportEvents 发送状态变化事件
+func (c *Collector) reportEvents(events []StateEvent) {
+	for _, event := range events {
+		select {
+		case c.events <- event:
+		default:
+			c.logger.WithField("device", event.IP).Warn("Event channel full, dropping state event")
+		}
+	}
+}
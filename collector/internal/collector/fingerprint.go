@@ -0,0 +1,319 @@
+package collector
+
+import (
+	"bufio"
+	_ "embed"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 系统组 OID (SNMPv2-MIB)
+const (
+	oidSysDescr    = ".1.3.6.1.2.1.1.1.0" // sysDescr
+	oidSysContact  = ".1.3.6.1.2.1.1.4.0" // sysContact
+	oidSysName     = ".1.3.6.1.2.1.1.5.0" // sysName
+	oidSysLocation = ".1.3.6.1.2.1.1.6.0" // sysLocation
+)
+
+// SystemInfo SNMP 系统组
+type SystemInfo struct {
+	Descr    string
+	ObjectID string
+	Name     string
+	Contact  string
+	Location string
+}
+
+// Fingerprint 设备指纹, 服务端据此补全设备厂商/型号
+type Fingerprint struct {
+	CollectorID  string    `json:"collectorId"`
+	DeviceID     string    `json:"deviceId"`
+	IP           string    `json:"ip"`
+	SysDescr     string    `json:"sysDescr"`
+	SysObjectID  string    `json:"sysObjectId"`
+	SysName      string    `json:"sysName,omitempty"`
+	SysContact   string    `json:"sysContact,omitempty"`
+	SysLocation  string    `json:"sysLocation,omitempty"`
+	Vendor       string    `json:"vendor,omitempty"`     // 厂商标识: cisco / huawei / h3c ...
+	VendorName   string    `json:"vendorName,omitempty"` // 企业号登记的厂商名称
+	Model        string    `json:"model,omitempty"`
+	OS           string    `json:"os,omitempty"`
+	Version      string    `json:"version,omitempty"`
+	DeviceType   string    `json:"deviceType,omitempty"`   // 推断的设备类型, 无法判断时为空
+	TypeMismatch bool      `json:"typeMismatch,omitempty"` // 推断类型与服务端登记的 Device.Type 不一致
+	CollectedAt  time.Time `json:"collectedAt"`
+}
+
+//go:embed fingerprintdb/enterprises.tsv
+var enterprisesTSV string
+
+//go:embed fingerprintdb/sysobjectids.tsv
+var sysObjectIDsTSV string
+
+type enterprise struct {
+	vendor string
+	name   string
+}
+
+type objectIDEntry struct {
+	prefix     string
+	model      string
+	deviceType string
+	os         string
+}
+
+var (
+	enterprises   = parseEnterprises(enterprisesTSV)
+	objectIDTable = parseObjectIDs(sysObjectIDsTSV)
+)
+
+// descrRule 按 sysDescr 识别操作系统并提取型号/版本
+// 第一个正则用于识别, 其余正则仅补充提取; 命名分组 model / version / release / image
+type descrRule struct {
+	vendor     string
+	os         string
+	deviceType string
+	patterns   []*regexp.Regexp
+}
+
+var descrRules = []descrRule{
+	{vendor: "cisco", os: "NX-OS", deviceType: "switch", patterns: []*regexp.Regexp{
+		regexp.MustCompile(`Cisco NX-OS(?:\(tm\))? (?P<model>\w+).*?Version (?P<version>[^\s,]+)`),
+	}},
+	{vendor: "cisco", os: "IOS-XR", deviceType: "router", patterns: []*regexp.Regexp{
+		regexp.MustCompile(`Cisco IOS XR Software.*?Version (?P<version>[^\s,\[]+)`),
+	}},
+	{vendor: "cisco", os: "IOS-XE", patterns: []*regexp.Regexp{
+		regexp.MustCompile(`Cisco IOS[ -]XE Software.*?Version (?P<version>[^\s,]+)`),
+	}},
+	{vendor: "cisco", os: "IOS-XE", patterns: []*regexp.Regexp{
+		regexp.MustCompile(`Cisco IOS Software.*?\((?P<image>[\w-]*IOSXE[\w-]*)\), Version (?P<version>[^\s,]+)`),
+	}},
+	{vendor: "cisco", os: "IOS", patterns: []*regexp.Regexp{
+		regexp.MustCompile(`Cisco (?:IOS|Internetwork Operating System) Software.*?\((?P<image>[^)]*)\), Version (?P<version>[^\s,]+)`),
+	}},
+	{vendor: "cisco", os: "ASA", deviceType: "firewall", patterns: []*regexp.Regexp{
+		regexp.MustCompile(`Cisco Adaptive Security Appliance Version (?P<version>\S+)`),
+	}},
+	{vendor: "huawei", os: "VRP", patterns: []*regexp.Regexp{
+		regexp.MustCompile(`(?s)VRP \(R\) software, Version (?P<version>[\d.]+)(?: \((?P<model>\S+) (?P<release>V\d+R\d+\w*)\))?`),
+	}},
+	{vendor: "h3c", os: "Comware", patterns: []*regexp.Regexp{
+		regexp.MustCompile(`Comware (?:Platform )?Software.*?Version (?P<version>[\d.]+)(?:, (?:Release|ESS|Feature) (?P<release>\w+))?`),
+		regexp.MustCompile(`(?m)^\s*(?:H3C|HPE?) (?P<model>[A-Z]+\d[\w-]*)`),
+	}},
+	{vendor: "juniper", os: "JUNOS", patterns: []*regexp.Regexp{
+		regexp.MustCompile(`Juniper Networks, Inc\. (?P<model>\S+) .*?JUNOS (?P<version>[^\s,]+)`),
+	}},
+	{vendor: "ruijie", os: "RGOS", patterns: []*regexp.Regexp{
+		regexp.MustCompile(`Ruijie[^(\n]*\((?P<model>[^)]+)\)`),
+		regexp.MustCompile(`RGOS (?P<version>[\w.()-]+)`),
+	}},
+	{vendor: "arista", os: "EOS", deviceType: "switch", patterns: []*regexp.Regexp{
+		regexp.MustCompile(`Arista Networks EOS version (?P<version>\S+) running on an Arista Networks (?P<model>\S+)`),
+	}},
+	{vendor: "paloalto", os: "PAN-OS", deviceType: "firewall", patterns: []*regexp.Regexp{
+		regexp.MustCompile(`Palo Alto Networks (?P<model>PA-\w+)`),
+	}},
+	{vendor: "fortinet", os: "FortiOS", deviceType: "firewall", patterns: []*regexp.Regexp{
+		regexp.MustCompile(`^(?P<model>Forti\w+-\w+)(?: v(?P<version>[\w.,]+))?`),
+	}},
+	{vendor: "mikrotik", os: "RouterOS", deviceType: "router", patterns: []*regexp.Regexp{
+		regexp.MustCompile(`^RouterOS (?P<model>\S+)`),
+	}},
+	{os: "Linux", deviceType: "server", patterns: []*regexp.Regexp{
+		regexp.MustCompile(`^Linux \S+ (?P<version>\S+)`),
+	}},
+	{os: "FreeBSD", deviceType: "server", patterns: []*regexp.Regexp{
+		regexp.MustCompile(`^FreeBSD \S+ (?P<version>\S+)`),
+	}},
+	{vendor: "microsoft", os: "Windows", deviceType: "server", patterns: []*regexp.Regexp{
+		regexp.MustCompile(`Software: Windows(?: Version (?P<version>[\d.]+))?(?: \(Build (?P<release>\d+))?`),
+	}},
+}
+
+// vendorDeviceTypes 仅凭厂商即可判断设备类型的情况
+var vendorDeviceTypes = map[string]string{
+	"fortinet":   "firewall",
+	"paloalto":   "firewall",
+	"checkpoint": "firewall",
+	"sonicwall":  "firewall",
+	"watchguard": "firewall",
+	"hillstone":  "firewall",
+	"microsoft":  "server",
+	"net-snmp":   "server",
+}
+
+// modelDeviceTypes 型号关键字推断设备类型 (按顺序匹配)
+var modelDeviceTypes = []struct {
+	pattern    *regexp.Regexp
+	deviceType string
+}{
+	{regexp.MustCompile(`(?i)^(ASA|FPR|USG|SecPath|F10\d\d|NGFW|SRX)`), "firewall"},
+	{regexp.MustCompile(`(?i)^(WS-C|C\d{4}|CAT\d|N\dK|Nexus|Catalyst|S\d{4}|CE\d{4}|EX\d|QFX|LS-|RG-S|DCS-)`), "switch"},
+	{regexp.MustCompile(`(?i)^(ISR|ASR|CSR|AR\d|NE\d|MSR|SR\d|MX\d|PTX|ACX|RSR)`), "router"},
+}
+
+// buildFingerprint 由系统组解析厂商、型号、操作系统及版本
+// 厂商取 sysObjectID 企业号, 型号/类型优先取 OID 数据库, 其余由 sysDescr 规则补充
+func buildFingerprint(device Device, info SystemInfo) Fingerprint {
+	fp := Fingerprint{
+		DeviceID:    device.ID,
+		IP:          device.IP,
+		SysDescr:    info.Descr,
+		SysObjectID: info.ObjectID,
+		SysName:     info.Name,
+		SysContact:  info.Contact,
+		SysLocation: info.Location,
+		CollectedAt: time.Now(),
+	}
+
+	oid := "." + strings.Trim(info.ObjectID, ".")
+	if e, ok := enterprises[enterpriseNumber(oid)]; ok {
+		fp.Vendor, fp.VendorName = e.vendor, e.name
+	}
+	if entry := lookupObjectID(oid); entry != nil {
+		fp.Model, fp.DeviceType, fp.OS = entry.model, entry.deviceType, entry.os
+	}
+
+	for _, rule := range descrRules {
+		if rule.vendor != "" && fp.Vendor != "" && rule.vendor != fp.Vendor {
+			continue
+		}
+		fields := matchDescr(rule.patterns, info.Descr)
+		if fields == nil {
+			continue
+		}
+		if fp.Vendor == "" {
+			fp.Vendor = rule.vendor
+		}
+		fp.OS = rule.os // sysDescr 比 OID 数据库更具体 (如 IOS 与 IOS-XE)
+		fp.Version = joinNonEmpty(fields["version"], fields["release"])
+		if fp.Model == "" {
+			fp.Model = fields["model"]
+		}
+		if fp.Model == "" {
+			// Cisco 镜像名 C2960-LANBASEK9-M / CAT9K_IOSXE 的平台部分
+			if parts := strings.FieldsFunc(fields["image"], func(r rune) bool { return r == '-' || r == '_' }); len(parts) > 0 {
+				fp.Model = parts[0]
+			}
+		}
+		if fp.DeviceType == "" {
+			fp.DeviceType = rule.deviceType
+		}
+		break
+	}
+
+	if fp.DeviceType == "" {
+		fp.DeviceType = vendorDeviceTypes[fp.Vendor]
+	}
+	if fp.DeviceType == "" && fp.Model != "" {
+		for _, m := range modelDeviceTypes {
+			if m.pattern.MatchString(fp.Model) {
+				fp.DeviceType = m.deviceType
+				break
+			}
+		}
+	}
+	fp.TypeMismatch = fp.DeviceType != "" && device.Type != "" && !strings.EqualFold(fp.DeviceType, device.Type)
+	return fp
+}
+
+// matchDescr 第一个正则匹配时返回所有正则提取到的命名分组
+func matchDescr(patterns []*regexp.Regexp, descr string) map[string]string {
+	fields := make(map[string]string)
+	for i, re := range patterns {
+		m := re.FindStringSubmatch(descr)
+		if m == nil {
+			if i == 0 {
+				return nil
+			}
+			continue
+		}
+		for j, name := range re.SubexpNames() {
+			if name != "" && m[j] != "" && fields[name] == "" {
+				fields[name] = strings.TrimSpace(m[j])
+			}
+		}
+	}
+	return fields
+}
+
+// enterpriseNumber 取 .1.3.6.1.4.1.<n> 中的企业号
+func enterpriseNumber(oid string) int {
+	const prefix = ".1.3.6.1.4.1."
+	if !strings.HasPrefix(oid, prefix) {
+		return -1
+	}
+	rest := strings.TrimPrefix(oid, prefix)
+	if i := strings.IndexByte(rest, '.'); i >= 0 {
+		rest = rest[:i]
+	}
+	n, err := strconv.Atoi(rest)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// lookupObjectID 按最长前缀匹配 OID 数据库
+func lookupObjectID(oid string) *objectIDEntry {
+	var best *objectIDEntry
+	for i := range objectIDTable {
+		e := &objectIDTable[i]
+		if (oid == e.prefix || strings.HasPrefix(oid, e.prefix+".")) && (best == nil || len(e.prefix) > len(best.prefix)) {
+			best = e
+		}
+	}
+	return best
+}
+
+func parseEnterprises(data string) map[int]enterprise {
+	out := make(map[int]enterprise)
+	for _, fields := range tsvRecords(data, 3) {
+		n, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		out[n] = enterprise{vendor: fields[1], name: fields[2]}
+	}
+	return out
+}
+
+func parseObjectIDs(data string) []objectIDEntry {
+	var out []objectIDEntry
+	for _, fields := range tsvRecords(data, 4) {
+		out = append(out, objectIDEntry{prefix: fields[0], model: fields[1], deviceType: fields[2], os: fields[3]})
+	}
+	return out
+}
+
+// tsvRecords 解析制表符分隔的数据文件, 跳过空行与 # 注释, 字段不足时补空
+func tsvRecords(data string, columns int) [][]string {
+	var records [][]string
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		for len(fields) < columns {
+			fields = append(fields, "")
+		}
+		records = append(records, fields[:columns])
+	}
+	return records
+}
+
+func joinNonEmpty(parts ...string) string {
+	var out []string
+	for _, p := range parts {
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return strings.Join(out, " ")
+}
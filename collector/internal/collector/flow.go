@@ -0,0 +1,459 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// flowMaxKeys 单个接口在一个窗口内跟踪的 talker/应用上限, 超出部分计入 other
+const flowMaxKeys = 10000
+
+// 流方向: 入接口计为 in, 出接口计为 out
+const (
+	flowDirectionIn  = "in"
+	flowDirectionOut = "out"
+)
+
+// flowRecord 解码后的单条流, 字节/包数已按采样率放大
+// L2 字段仅 sFlow 原始报文头样本提供
+type flowRecord struct {
+	SrcMAC    net.HardwareAddr
+	DstMAC    net.HardwareAddr
+	VLAN      uint16
+	EtherType uint16
+	SrcAddr   net.IP
+	DstAddr   net.IP
+	SrcPort   uint16
+	DstPort   uint16
+	Protocol  uint8
+	InIf      uint32
+	OutIf     uint32
+	Bytes     uint64
+	Packets   uint64
+}
+
+// FlowTalker 主机流量 (按源地址)
+type FlowTalker struct {
+	Address string `json:"address"`
+	Bytes   uint64 `json:"bytes"`
+	Packets uint64 `json:"packets"`
+	Flows   uint64 `json:"flows"`
+}
+
+// FlowApp 应用流量 (按协议+服务端口)
+type FlowApp struct {
+	Protocol string `json:"protocol"`
+	Port     uint16 `json:"port"`
+	Name     string `json:"name,omitempty"`
+	Bytes    uint64 `json:"bytes"`
+	Packets  uint64 `json:"packets"`
+	Flows    uint64 `json:"flows"`
+}
+
+// FlowAggregate 单个接口单方向在一个窗口内的流量汇总
+type FlowAggregate struct {
+	CollectorID string       `json:"collectorId"`
+	DeviceID    string       `json:"deviceId,omitempty"` // 导出方未匹配到已知设备时为空
+	Exporter    string       `json:"exporter"`
+	IfIndex     uint32       `json:"ifIndex"`
+	Direction   string       `json:"direction"` // in / out
+	WindowStart time.Time    `json:"windowStart"`
+	WindowEnd   time.Time    `json:"windowEnd"`
+	Bytes       uint64       `json:"bytes"`
+	Packets     uint64       `json:"packets"`
+	Flows       uint64       `json:"flows"`
+	TopTalkers  []FlowTalker `json:"topTalkers"`
+	TopApps     []FlowApp    `json:"topApps"`
+}
+
+// flowKey 汇总维度
+type flowKey struct {
+	exporter  string
+	ifIndex   uint32
+	direction string
+}
+
+type appKey struct {
+	protocol uint8
+	port     uint16
+}
+
+type flowBucket struct {
+	bytes, packets, flows uint64
+	talkers               map[string]*FlowTalker
+	apps                  map[appKey]*FlowApp
+}
+
+// flowAggregator 按 导出方+接口+方向 汇总流量, 每个窗口输出一次
+type flowAggregator struct {
+	mu      sync.Mutex
+	start   time.Time
+	buckets map[flowKey]*flowBucket
+}
+
+func newFlowAggregator() *flowAggregator {
+	return &flowAggregator{start: time.Now(), buckets: make(map[flowKey]*flowBucket)}
+}
+
+// add 计入一条流, 入/出接口各计一次
+func (a *flowAggregator) add(exporter string, rec flowRecord) {
+	if rec.Bytes == 0 && rec.Packets == 0 {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if rec.InIf != 0 {
+		a.bucket(flowKey{exporter, rec.InIf, flowDirectionIn}).add(rec)
+	}
+	if rec.OutIf != 0 {
+		a.bucket(flowKey{exporter, rec.OutIf, flowDirectionOut}).add(rec)
+	}
+}
+
+func (a *flowAggregator) bucket(key flowKey) *flowBucket {
+	b, ok := a.buckets[key]
+	if !ok {
+		b = &flowBucket{talkers: make(map[string]*FlowTalker), apps: make(map[appKey]*FlowApp)}
+		a.buckets[key] = b
+	}
+	return b
+}
+
+func (b *flowBucket) add(rec flowRecord) {
+	b.bytes += rec.Bytes
+	b.packets += rec.Packets
+	b.flows++
+
+	addr := "other"
+	if rec.SrcAddr != nil && (len(b.talkers) < flowMaxKeys || b.talkers[rec.SrcAddr.String()] != nil) {
+		addr = rec.SrcAddr.String()
+	}
+	t, ok := b.talkers[addr]
+	if !ok {
+		t = &FlowTalker{Address: addr}
+		b.talkers[addr] = t
+	}
+	t.Bytes += rec.Bytes
+	t.Packets += rec.Packets
+	t.Flows++
+
+	key := appKey{protocol: rec.Protocol, port: servicePort(rec)}
+	app, ok := b.apps[key]
+	if !ok {
+		if len(b.apps) >= flowMaxKeys {
+			key = appKey{}
+			app = b.apps[key]
+		}
+		if app == nil {
+			app = &FlowApp{Protocol: protocolName(key.protocol), Port: key.port, Name: appName(key.protocol, key.port)}
+			b.apps[key] = app
+		}
+	}
+	app.Bytes += rec.Bytes
+	app.Packets += rec.Packets
+	app.Flows++
+}
+
+// flush 取出当前窗口的汇总并开始新窗口
+func (a *flowAggregator) flush(c *Collector, now time.Time, topN int) []FlowAggregate {
+	a.mu.Lock()
+	buckets, start := a.buckets, a.start
+	a.buckets, a.start = make(map[flowKey]*flowBucket), now
+	a.mu.Unlock()
+
+	out := make([]FlowAggregate, 0, len(buckets))
+	for key, b := range buckets {
+		agg := FlowAggregate{
+			CollectorID: c.config.Collector.ID,
+			Exporter:    key.exporter,
+			IfIndex:     key.ifIndex,
+			Direction:   key.direction,
+			WindowStart: start,
+			WindowEnd:   now,
+			Bytes:       b.bytes,
+			Packets:     b.packets,
+			Flows:       b.flows,
+			TopTalkers:  topTalkers(b.talkers, topN),
+			TopApps:     topApps(b.apps, topN),
+		}
+		if d, ok := c.deviceByIP(key.exporter); ok {
+			agg.DeviceID = d.ID
+		}
+		out = append(out, agg)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Exporter != out[j].Exporter {
+			return out[i].Exporter < out[j].Exporter
+		}
+		if out[i].IfIndex != out[j].IfIndex {
+			return out[i].IfIndex < out[j].IfIndex
+		}
+		return out[i].Direction < out[j].Direction
+	})
+	return out
+}
+
+func topTalkers(m map[string]*FlowTalker, n int) []FlowTalker {
+	list := make([]FlowTalker, 0, len(m))
+	for _, t := range m {
+		list = append(list, *t)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Bytes != list[j].Bytes {
+			return list[i].Bytes > list[j].Bytes
+		}
+		return list[i].Address < list[j].Address
+	})
+	if len(list) > n {
+		list = list[:n]
+	}
+	return list
+}
+
+func topApps(m map[appKey]*FlowApp, n int) []FlowApp {
+	list := make([]FlowApp, 0, len(m))
+	for _, a := range m {
+		list = append(list, *a)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Bytes != list[j].Bytes {
+			return list[i].Bytes > list[j].Bytes
+		}
+		if list[i].Protocol != list[j].Protocol {
+			return list[i].Protocol < list[j].Protocol
+		}
+		return list[i].Port < list[j].Port
+	})
+	if len(list) > n {
+		list = list[:n]
+	}
+	return list
+}
+
+// servicePort 推断服务端口: 取已知端口, 否则取较小的端口
+func servicePort(rec flowRecord) uint16 {
+	if rec.Protocol != 6 && rec.Protocol != 17 && rec.Protocol != 132 {
+		return 0
+	}
+	_, srcKnown := wellKnownPorts[rec.SrcPort]
+	_, dstKnown := wellKnownPorts[rec.DstPort]
+	switch {
+	case dstKnown:
+		return rec.DstPort
+	case srcKnown:
+		return rec.SrcPort
+	case rec.SrcPort != 0 && rec.SrcPort < rec.DstPort:
+		return rec.SrcPort
+	default:
+		return rec.DstPort
+	}
+}
+
+// protocolNames 常见IP协议号
+var protocolNames = map[uint8]string{
+	1:   "icmp",
+	2:   "igmp",
+	6:   "tcp",
+	17:  "udp",
+	47:  "gre",
+	50:  "esp",
+	51:  "ah",
+	58:  "icmpv6",
+	89:  "ospf",
+	112: "vrrp",
+	132: "sctp",
+}
+
+func protocolName(p uint8) string {
+	if name, ok := protocolNames[p]; ok {
+		return name
+	}
+	return strconv.Itoa(int(p))
+}
+
+// wellKnownPorts 常见服务端口
+var wellKnownPorts = map[uint16]string{
+	20:    "ftp-data",
+	21:    "ftp",
+	22:    "ssh",
+	23:    "telnet",
+	25:    "smtp",
+	53:    "dns",
+	67:    "dhcp",
+	68:    "dhcp",
+	80:    "http",
+	110:   "pop3",
+	123:   "ntp",
+	143:   "imap",
+	161:   "snmp",
+	162:   "snmp-trap",
+	179:   "bgp",
+	389:   "ldap",
+	443:   "https",
+	445:   "smb",
+	514:   "syslog",
+	587:   "smtp",
+	636:   "ldaps",
+	993:   "imaps",
+	995:   "pop3s",
+	1433:  "mssql",
+	1521:  "oracle",
+	1723:  "pptp",
+	3306:  "mysql",
+	3389:  "rdp",
+	5060:  "sip",
+	5432:  "postgresql",
+	5900:  "vnc",
+	6379:  "redis",
+	8080:  "http-alt",
+	8443:  "https-alt",
+	27017: "mongodb",
+}
+
+func appName(protocol uint8, port uint16) string {
+	if port == 0 {
+		if protocol == 0 {
+			return "other"
+		}
+		return protocolName(protocol)
+	}
+	return wellKnownPorts[port]
+}
+
+// StartFlows 启动 NetFlow/IPFIX 与 sFlow 接收, 按窗口汇总后发送, 阻塞直到 ctx 结束
+func (c *Collector) StartFlows(ctx context.Context) error {
+	cfg := c.config.Flows
+	agg := newFlowAggregator()
+	decoder := newNetFlowDecoder(cfg.TemplateTimeout, cfg.MaxTemplates)
+
+	var conns []net.PacketConn
+	listen := func(addr, name string) (net.PacketConn, error) {
+		if addr == "" {
+			return nil, nil
+		}
+		conn, err := net.ListenPacket("udp", addr)
+		if err != nil {
+			for _, open := range conns {
+				open.Close()
+			}
+			return nil, fmt.Errorf("%s listen: %w", name, err)
+		}
+		conns = append(conns, conn)
+		return conn, nil
+	}
+	netflowConn, err := listen(cfg.NetFlow, "netflow")
+	if err != nil {
+		return err
+	}
+	sflowConn, err := listen(cfg.SFlow, "sflow")
+	if err != nil {
+		return err
+	}
+	if len(conns) == 0 {
+		return fmt.Errorf("no flow listener configured")
+	}
+
+	go func() {
+		ticker := time.NewTicker(cfg.Window)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				for _, conn := range conns {
+					conn.Close()
+				}
+				return
+			case now := <-ticker.C:
+				c.reportFlows(agg.flush(c, now, cfg.TopN))
+				decoder.expire(now)
+			}
+		}
+	}()
+
+	c.logger.WithFields(logrus.Fields{
+		"netflow": cfg.NetFlow,
+		"sflow":   cfg.SFlow,
+	}).Info("Starting flow collector...")
+
+	var wg sync.WaitGroup
+	if netflowConn != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.serveFlowConn(ctx, netflowConn, func(source string, data []byte) {
+				records, err := decoder.decode(source, data, time.Now())
+				if err != nil {
+					c.logger.WithError(err).WithField("exporter", source).Debug("Invalid flow packet")
+				}
+				for _, rec := range records {
+					agg.add(source, rec)
+				}
+			})
+		}()
+	}
+	if sflowConn != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.serveFlowConn(ctx, sflowConn, func(source string, data []byte) {
+				dg, err := decodeSFlow(data)
+				if err != nil {
+					c.logger.WithError(err).WithField("exporter", source).Debug("Invalid sFlow datagram")
+				}
+				if dg == nil {
+					return
+				}
+				// 按代理地址归属设备, 经中继转发时源地址不是设备本身
+				agent := dg.Agent
+				if agent == "" || agent == "0.0.0.0" {
+					agent = source
+				}
+				for _, rec := range dg.Flows {
+					agg.add(agent, rec)
+				}
+				if len(dg.Counters) > 0 {
+					c.applyInterfaceCounters(agent, dg.Counters, time.Now())
+				}
+			})
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// serveFlowConn 读取UDP报文直到 ctx 结束
+func (c *Collector) serveFlowConn(ctx context.Context, conn net.PacketConn, handle func(source string, data []byte)) {
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.logger.WithError(err).Warn("Flow read failed")
+			continue
+		}
+		handle(hostOf(addr), buf[:n])
+	}
+}
+
+// reportFlows 发送窗口汇总
+func (c *Collector) reportFlows(aggregates []FlowAggregate) {
+	for _, agg := range aggregates {
+		select {
+		case c.flows <- agg:
+		default:
+			c.logger.WithField("exporter", agg.Exporter).Warn("Flow channel full, dropping aggregate")
+		}
+	}
+}
@@ -0,0 +1,398 @@
+package collector
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/netvis/collector/internal/config"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// GNMITarget 服务端按设备下发的 gNMI 参数, 为空的字段使用 gnmi 全局配置
+type GNMITarget struct {
+	Port          int        `json:"port,omitempty"`
+	Username      string     `json:"username,omitempty"`
+	Password      string     `json:"password,omitempty"`
+	Subscriptions []GNMIPath `json:"subscriptions,omitempty"`
+}
+
+// GNMIPath 设备级订阅路径
+type GNMIPath struct {
+	Path     string `json:"path"`
+	Mode     string `json:"mode,omitempty"`     // sample / on_change
+	Interval int    `json:"interval,omitempty"` // 采样间隔(秒)
+}
+
+// gnmiTarget 解析后的订阅目标, 参数变化时重建会话
+type gnmiTarget struct {
+	device        Device
+	address       string
+	username      string
+	password      string
+	subscriptions []config.GNMISubscription
+}
+
+// gnmiManager 按设备维护 gNMI 订阅会话
+type gnmiManager struct {
+	c        *Collector
+	mu       sync.Mutex
+	ctx      context.Context // StartGNMI 之前为 nil
+	dialOpts []grpc.DialOption
+	devices  []Device
+	sessions map[string]*gnmiSession // deviceID -> 会话
+}
+
+type gnmiSession struct {
+	target gnmiTarget
+	cancel context.CancelFunc
+}
+
+func newGNMIManager(c *Collector) *gnmiManager {
+	return &gnmiManager{c: c, sessions: make(map[string]*gnmiSession)}
+}
+
+// StartGNMI 启动 gNMI 订阅, 设备列表变化时自动增删会话, 阻塞直到 ctx 结束
+func (c *Collector) StartGNMI(ctx context.Context) error {
+	opts, err := gnmiDialOptions(c.config.GNMI)
+	if err != nil {
+		return err
+	}
+
+	c.logger.Info("Starting gNMI subscriptions...")
+
+	m := c.gnmi
+	m.mu.Lock()
+	m.ctx, m.dialOpts = ctx, opts
+	m.sync()
+	m.mu.Unlock()
+
+	<-ctx.Done()
+
+	m.mu.Lock()
+	for id, s := range m.sessions {
+		s.cancel()
+		delete(m.sessions, id)
+	}
+	m.ctx = nil
+	m.mu.Unlock()
+	return nil
+}
+
+func gnmiDialOptions(cfg config.GNMIConfig) ([]grpc.DialOption, error) {
+	if !cfg.TLS {
+		return []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("gnmi ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("gnmi ca: no certificates in %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))}, nil
+}
+
+// setDevices 更新设备列表, 已启动时同步会话
+func (m *gnmiManager) setDevices(devices []Device) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.devices = devices
+	if m.ctx != nil {
+		m.sync()
+	}
+}
+
+// sync 按设备列表启停会话 (调用方持有 m.mu)
+func (m *gnmiManager) sync() {
+	desired := make(map[string]gnmiTarget)
+	for _, device := range m.devices {
+		if target, ok := m.c.resolveGNMITarget(device); ok {
+			desired[device.ID] = target
+		}
+	}
+
+	for id, s := range m.sessions {
+		if target, ok := desired[id]; ok && reflect.DeepEqual(target, s.target) {
+			continue
+		}
+		s.cancel()
+		delete(m.sessions, id)
+	}
+	for id, target := range desired {
+		if _, ok := m.sessions[id]; ok {
+			continue
+		}
+		ctx, cancel := context.WithCancel(m.ctx)
+		m.sessions[id] = &gnmiSession{target: target, cancel: cancel}
+		go m.run(ctx, target)
+	}
+}
+
+// resolveGNMITarget 解析设备的订阅参数: 设备下发配置 > deviceTypes > 全局配置
+func (c *Collector) resolveGNMITarget(device Device) (gnmiTarget, bool) {
+	cfg := c.config.GNMI
+	subs, typed := cfg.DeviceTypes[device.Type]
+	if device.GNMI == nil && !typed {
+		return gnmiTarget{}, false
+	}
+	if len(subs) == 0 {
+		subs = cfg.Subscriptions
+	}
+
+	target := gnmiTarget{
+		device:        device,
+		username:      cfg.Username,
+		password:      cfg.Password,
+		subscriptions: subs,
+	}
+	port := cfg.Port
+	if g := device.GNMI; g != nil {
+		if g.Port > 0 {
+			port = g.Port
+		}
+		if g.Username != "" {
+			target.username, target.password = g.Username, g.Password
+		}
+		if len(g.Subscriptions) > 0 {
+			target.subscriptions = make([]config.GNMISubscription, 0, len(g.Subscriptions))
+			for _, p := range g.Subscriptions {
+				target.subscriptions = append(target.subscriptions, config.GNMISubscription{
+					Path:     p.Path,
+					Mode:     p.Mode,
+					Interval: time.Duration(p.Interval) * time.Second,
+				})
+			}
+		}
+	}
+	target.address = net.JoinHostPort(device.IP, strconv.Itoa(port))
+	return target, true
+}
+
+// run 保持订阅, 断开后按指数退避重连; 收到过同步完成的会话重连时退避从初始值开始
+func (m *gnmiManager) run(ctx context.Context, target gnmiTarget) {
+	cfg := m.c.config.GNMI
+	backoff := cfg.BackoffMin
+	logger := m.c.logger.WithFields(logrus.Fields{"ip": target.device.IP, "address": target.address})
+
+	for {
+		synced, err := m.c.subscribeGNMI(ctx, target)
+		if ctx.Err() != nil {
+			return
+		}
+		if synced {
+			backoff = cfg.BackoffMin
+		}
+
+		wait := backoff + time.Duration(rand.Int63n(int64(backoff)/2+1))
+		logger.WithError(err).WithField("retry", wait).Warn("gNMI subscription lost")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		if backoff *= 2; backoff > cfg.BackoffMax {
+			backoff = cfg.BackoffMax
+		}
+	}
+}
+
+// subscribeGNMI 建立一次 STREAM 订阅并持续接收, 返回是否收到过 sync_response
+func (c *Collector) subscribeGNMI(ctx context.Context, target gnmiTarget) (bool, error) {
+	cfg := c.config.GNMI
+
+	req, err := gnmiSubscribeRequest(target.subscriptions, cfg.Encoding)
+	if err != nil {
+		return false, err
+	}
+
+	c.gnmi.mu.Lock()
+	opts := c.gnmi.dialOpts
+	c.gnmi.mu.Unlock()
+	conn, err := grpc.NewClient(target.address, opts...)
+	if err != nil {
+		return false, fmt.Errorf("dial: %w", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if target.username != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "username", target.username, "password", target.password)
+	}
+
+	stream, err := pb.NewGNMIClient(conn).Subscribe(ctx)
+	if err != nil {
+		return false, fmt.Errorf("subscribe: %w", err)
+	}
+	if err := stream.Send(req); err != nil {
+		return false, fmt.Errorf("send subscription: %w", err)
+	}
+
+	responses := make(chan *pb.SubscribeResponse)
+	errc := make(chan error, 1)
+	go func() {
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				errc <- err
+				return
+			}
+			select {
+			case responses <- resp:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	state := newGNMIState()
+	flush := time.NewTicker(cfg.FlushInterval)
+	defer flush.Stop()
+	timeout := time.NewTimer(cfg.Timeout)
+	defer timeout.Stop()
+	synced, received := false, false
+
+	for {
+		select {
+		case <-ctx.Done():
+			return synced, ctx.Err()
+		case <-timeout.C:
+			if !received {
+				return false, errors.New("no response from target")
+			}
+		case err := <-errc:
+			c.applyGNMI(target.device.ID, state)
+			if errors.Is(err, io.EOF) {
+				err = errors.New("stream closed by target")
+			}
+			return synced, err
+		case resp := <-responses:
+			received = true
+			switch r := resp.Response.(type) {
+			case *pb.SubscribeResponse_Update:
+				state.notification(r.Update)
+			case *pb.SubscribeResponse_SyncResponse:
+				if !synced {
+					synced = true
+					c.logger.WithField("ip", target.device.IP).Debug("gNMI initial sync complete")
+				}
+				c.applyGNMI(target.device.ID, state)
+			}
+		case <-flush.C:
+			c.applyGNMI(target.device.ID, state)
+		}
+	}
+}
+
+// gnmiSubscribeRequest 构造 STREAM 模式订阅请求
+func gnmiSubscribeRequest(subs []config.GNMISubscription, encoding string) (*pb.SubscribeRequest, error) {
+	enc, ok := pb.Encoding_value[strings.ToUpper(encoding)]
+	if !ok {
+		return nil, fmt.Errorf("unknown gnmi encoding %q", encoding)
+	}
+
+	list := &pb.SubscriptionList{
+		Mode:     pb.SubscriptionList_STREAM,
+		Encoding: pb.Encoding(enc),
+	}
+	for _, s := range subs {
+		path, err := parseGNMIPath(s.Path)
+		if err != nil {
+			return nil, err
+		}
+		sub := &pb.Subscription{Path: path}
+		switch strings.ToLower(s.Mode) {
+		case config.GNMIModeOnChange:
+			sub.Mode = pb.SubscriptionMode_ON_CHANGE
+		case config.GNMIModeSample, "":
+			sub.Mode = pb.SubscriptionMode_SAMPLE
+			sub.SampleInterval = uint64(s.Interval.Nanoseconds())
+		default:
+			return nil, fmt.Errorf("unknown gnmi subscription mode %q", s.Mode)
+		}
+		list.Subscription = append(list.Subscription, sub)
+	}
+	return &pb.SubscribeRequest{Request: &pb.SubscribeRequest_Subscribe{Subscribe: list}}, nil
+}
+
+// parseGNMIPath 解析 /a/b[k=v]/c 形式的路径, 可带 origin 前缀 (如 openconfig:/interfaces)
+func parseGNMIPath(s string) (*pb.Path, error) {
+	path := &pb.Path{}
+	if i := strings.Index(s, ":/"); i > 0 && !strings.ContainsAny(s[:i], "/[") {
+		path.Origin, s = s[:i], s[i+1:]
+	}
+
+	for _, part := range splitGNMIPath(strings.Trim(s, "/")) {
+		name := part
+		var keys map[string]string
+		if i := strings.IndexByte(part, '['); i >= 0 {
+			name = part[:i]
+			rest := part[i:]
+			keys = make(map[string]string)
+			for rest != "" {
+				end := strings.IndexByte(rest, ']')
+				if rest[0] != '[' || end < 0 {
+					return nil, fmt.Errorf("invalid gnmi path element %q", part)
+				}
+				kv := strings.SplitN(rest[1:end], "=", 2)
+				if len(kv) != 2 {
+					return nil, fmt.Errorf("invalid gnmi path key in %q", part)
+				}
+				keys[kv[0]] = kv[1]
+				rest = rest[end+1:]
+			}
+		}
+		if name == "" {
+			return nil, fmt.Errorf("invalid gnmi path %q", s)
+		}
+		path.Elem = append(path.Elem, &pb.PathElem{Name: name, Key: keys})
+	}
+	return path, nil
+}
+
+// splitGNMIPath 按 / 切分, 忽略键值中的 /
+func splitGNMIPath(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '/':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
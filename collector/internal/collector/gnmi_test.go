@@ -0,0 +1,257 @@
+package collector
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/netvis/collector/internal/collector/gnmitest"
+	"github.com/netvis/collector/internal/config"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/sirupsen/logrus"
+)
+
+const testConfig = `
+collector:
+  id: test
+gnmi:
+  enabled: true
+  timeout: 2s
+  backoffMin: 10ms
+  backoffMax: 50ms
+  flushInterval: 10ms
+`
+
+// newTestCollector 按 yaml 配置创建采集器 (经 config.Load 填充默认值)
+func newTestCollector(t *testing.T, cfgYAML string) *Collector {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(cfgYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return New(cfg, logger)
+}
+
+// newGNMITestCollector 创建订阅 target 的采集器, interfaces 为订阅前已由 SNMP 采集到的接口, 测试结束时停止订阅
+func newGNMITestCollector(t *testing.T, target *gnmitest.Target, interfaces ...IfStats) (*Collector, Device) {
+	t.Helper()
+	c := newTestCollector(t, testConfig)
+	device := Device{ID: "dev-1", IP: "127.0.0.1", Type: "router", GNMI: &GNMITarget{
+		Port:     target.Port(),
+		Username: target.Username,
+		Password: target.Password,
+	}}
+	c.SetDevices([]Device{device})
+	c.scheduler.states[device.ID].sample.Interfaces = interfaces
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.StartGNMI(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return c, device
+}
+
+// waitSample 周期 flush 设备样本, 直到 match 返回 true
+func waitSample(t *testing.T, c *Collector, deviceID string, match func(DeviceMetrics) bool) DeviceMetrics {
+	t.Helper()
+	c.scheduler.mu.Lock()
+	state := c.scheduler.states[deviceID]
+	c.scheduler.mu.Unlock()
+
+	deadline := time.After(5 * time.Second)
+	tick := time.NewTicker(10 * time.Millisecond)
+	defer tick.Stop()
+	var last DeviceMetrics
+	for {
+		c.flushSample(state)
+		select {
+		case sample := <-c.Metrics():
+			if last = sample; match(sample) {
+				return sample
+			}
+		case <-tick.C:
+		case <-deadline:
+			t.Fatalf("timed out waiting for sample, last: %+v", last)
+		}
+	}
+}
+
+func waitSubscriptions(t *testing.T, target *gnmitest.Target, n int) {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for target.Subscriptions() != n {
+		select {
+		case <-target.Changed():
+		case <-deadline:
+			t.Fatalf("timed out waiting for %d subscriptions, have %d", n, target.Subscriptions())
+		}
+	}
+}
+
+func findIface(sample DeviceMetrics, name string) (IfStats, bool) {
+	for _, ifs := range sample.Interfaces {
+		if ifs.Name == name {
+			return ifs, true
+		}
+	}
+	return IfStats{}, false
+}
+
+func TestGNMISubscribeMerge(t *testing.T) {
+	start := time.Now().Add(-time.Minute)
+	initial := gnmitest.Notification(
+		gnmitest.Uint("/interfaces/interface[name=Ethernet1]/state/ifindex", 1),
+		gnmitest.String("/interfaces/interface[name=Ethernet1]/state/oper-status", "UP"),
+		gnmitest.Uint("/interfaces/interface[name=Ethernet1]/state/counters/in-octets", 1000),
+		gnmitest.Uint("/interfaces/interface[name=Ethernet1]/state/counters/out-octets", 2000),
+		gnmitest.Double("/system/cpus/cpu[index=ALL]/state/total/instant", 42),
+		gnmitest.JSONIETF("/system/memory/state", map[string]interface{}{
+			"openconfig-system:physical": 1000,
+			"openconfig-system:used":     250,
+		}),
+	)
+	initial.Timestamp = start.UnixNano()
+
+	target, err := gnmitest.New(initial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Close()
+	target.Username, target.Password = "admin", "secret"
+
+	// SNMP 已采集到的同名接口: 订阅未推送的字段应保留
+	c, device := newGNMITestCollector(t, target,
+		IfStats{Index: 1, Name: "Ethernet1", Descr: "Ethernet1 uplink", Speed: 1e9, Status: "down"},
+		IfStats{Index: 2, Name: "Ethernet2", Status: "up"},
+	)
+
+	waitSubscriptions(t, target, 1)
+	sample := waitSample(t, c, device.ID, func(s DeviceMetrics) bool { return s.CPUUsage > 0 })
+
+	if sample.CPUUsage != 42 {
+		t.Errorf("cpu usage = %v, want 42", sample.CPUUsage)
+	}
+	if sample.MemoryUsage != 25 {
+		t.Errorf("memory usage = %v, want 25", sample.MemoryUsage)
+	}
+	if len(sample.Interfaces) != 2 {
+		t.Fatalf("interfaces = %+v, want 2", sample.Interfaces)
+	}
+	eth1, _ := findIface(sample, "Ethernet1")
+	if eth1.Status != "up" || eth1.InBytes != 1000 || eth1.OutBytes != 2000 || !eth1.HighCapacity {
+		t.Errorf("Ethernet1 not overlaid: %+v", eth1)
+	}
+	if eth1.Descr != "Ethernet1 uplink" || eth1.Speed != 1e9 {
+		t.Errorf("Ethernet1 lost SNMP fields: %+v", eth1)
+	}
+	if eth1.RateInterval != 0 {
+		t.Errorf("first counters produced a rate: %+v", eth1)
+	}
+
+	// 默认订阅路径与用户凭据
+	reqs := target.Requests()
+	if len(reqs) != 1 || len(reqs[0].Subscription) != len(config.DefaultGNMISubscriptions) {
+		t.Fatalf("subscription requests = %v", reqs)
+	}
+	if reqs[0].Encoding != pb.Encoding_JSON_IETF {
+		t.Errorf("encoding = %v, want JSON_IETF", reqs[0].Encoding)
+	}
+
+	// 10 秒后的计数器推送计算速率
+	update := gnmitest.Notification(
+		gnmitest.Uint("/interfaces/interface[name=Ethernet1]/state/counters/in-octets", 11000),
+		gnmitest.Uint("/interfaces/interface[name=Ethernet1]/state/counters/out-octets", 2000),
+	)
+	update.Timestamp = start.Add(10 * time.Second).UnixNano()
+	target.Send(update)
+
+	sample = waitSample(t, c, device.ID, func(s DeviceMetrics) bool {
+		ifs, _ := findIface(s, "Ethernet1")
+		return ifs.InBytes == 11000
+	})
+	eth1, _ = findIface(sample, "Ethernet1")
+	if eth1.RateInterval != 10 || eth1.InBps != 8000 || eth1.OutBps != 0 {
+		t.Errorf("Ethernet1 rates = %+v", eth1)
+	}
+	if _, ok := findIface(sample, "Ethernet2"); !ok {
+		t.Errorf("Ethernet2 dropped: %+v", sample.Interfaces)
+	}
+
+	// 接口被删除
+	target.Send(&pb.Notification{
+		Timestamp: start.Add(20 * time.Second).UnixNano(),
+		Delete:    []*pb.Path{gnmitest.Path("/interfaces/interface[name=Ethernet1]")},
+	})
+	waitSample(t, c, device.ID, func(s DeviceMetrics) bool {
+		_, ok := findIface(s, "Ethernet1")
+		return !ok
+	})
+}
+
+func TestGNMIReconnect(t *testing.T) {
+	target, err := gnmitest.New(gnmitest.Notification(
+		gnmitest.Double("/system/cpus/cpu[index=ALL]/state/total/instant", 10),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Close()
+
+	c, device := newGNMITestCollector(t, target)
+	waitSubscriptions(t, target, 1)
+	waitSample(t, c, device.ID, func(s DeviceMetrics) bool { return s.CPUUsage == 10 })
+
+	// 断开后按退避重连, 新订阅重新收到初始通知
+	target.SetInitial(gnmitest.Notification(
+		gnmitest.Double("/system/cpus/cpu[index=ALL]/state/total/instant", 20),
+	))
+	target.Disconnect()
+	waitSample(t, c, device.ID, func(s DeviceMetrics) bool { return s.CPUUsage == 20 })
+	if n := len(target.Requests()); n != 2 {
+		t.Errorf("subscription requests = %d, want 2", n)
+	}
+}
+
+func TestGNMIAuthFailure(t *testing.T) {
+	target, err := gnmitest.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Close()
+	target.Username, target.Password = "admin", "secret"
+
+	c := newTestCollector(t, testConfig)
+	opts, err := gnmiDialOptions(c.config.GNMI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.gnmi.dialOpts = opts
+
+	device := Device{ID: "dev-1", IP: "127.0.0.1", GNMI: &GNMITarget{Port: target.Port(), Username: "admin", Password: "wrong"}}
+	resolved, ok := c.resolveGNMITarget(device)
+	if !ok {
+		t.Fatal("device with gnmi parameters not resolved")
+	}
+	synced, err := c.subscribeGNMI(context.Background(), resolved)
+	if synced || err == nil {
+		t.Fatalf("subscribe with wrong password: synced=%v err=%v", synced, err)
+	}
+	if len(target.Requests()) != 0 {
+		t.Errorf("unauthenticated subscription accepted")
+	}
+}
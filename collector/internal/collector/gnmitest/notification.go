@@ -0,0 +1,60 @@
+package gnmitest
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// Notification 构造当前时间戳的通知
+func Notification(updates ...*pb.Update) *pb.Notification {
+	return &pb.Notification{Timestamp: time.Now().UnixNano(), Update: updates}
+}
+
+// Uint 无符号整数叶子
+func Uint(path string, v uint64) *pb.Update {
+	return &pb.Update{Path: Path(path), Val: &pb.TypedValue{Value: &pb.TypedValue_UintVal{UintVal: v}}}
+}
+
+// String 字符串叶子
+func String(path string, v string) *pb.Update {
+	return &pb.Update{Path: Path(path), Val: &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: v}}}
+}
+
+// Double 浮点叶子
+func Double(path string, v float64) *pb.Update {
+	return &pb.Update{Path: Path(path), Val: &pb.TypedValue{Value: &pb.TypedValue_DoubleVal{DoubleVal: v}}}
+}
+
+// JSONIETF 以 JSON_IETF 编码的子树
+func JSONIETF(path string, v interface{}) *pb.Update {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return &pb.Update{Path: Path(path), Val: &pb.TypedValue{Value: &pb.TypedValue_JsonIetfVal{JsonIetfVal: data}}}
+}
+
+// Path 解析 /a/b[k=v]/c 形式的路径, 键值中不能包含 /
+func Path(s string) *pb.Path {
+	path := &pb.Path{}
+	for _, part := range strings.Split(strings.Trim(s, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		elem := &pb.PathElem{Name: part}
+		if i := strings.IndexByte(part, '['); i >= 0 {
+			elem.Name = part[:i]
+			elem.Key = make(map[string]string)
+			for _, kv := range strings.Split(strings.Trim(part[i:], "[]"), "][") {
+				if k, v, ok := strings.Cut(kv, "="); ok {
+					elem.Key[k] = v
+				}
+			}
+		}
+		path.Elem = append(path.Elem, elem)
+	}
+	return path
+}
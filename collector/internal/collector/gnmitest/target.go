@@ -0,0 +1,210 @@
+// Package gnmitest 提供进程内 gNMI 目标, 用于在没有真实设备时验证采集器的订阅、映射与重连
+package gnmitest
+
+import (
+	"net"
+	"strconv"
+	"sync"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Target 进程内 gNMI 目标
+// 新订阅先收到 New/SetInitial 指定的初始通知与 sync_response, 之后由 Send 推送
+type Target struct {
+	pb.UnimplementedGNMIServer
+
+	// Username / Password 非空时校验请求元数据中的凭据
+	Username string
+	Password string
+
+	server   *grpc.Server
+	listener net.Listener
+
+	mu       sync.Mutex
+	initial  []*pb.Notification
+	streams  map[*stream]bool
+	requests []*pb.SubscriptionList
+	changed  chan struct{}
+}
+
+type stream struct {
+	updates chan *pb.Notification
+	done    chan struct{}
+}
+
+// New 在 127.0.0.1 随机端口启动目标, initial 为每个新订阅的初始通知
+func New(initial ...*pb.Notification) (*Target, error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	t := &Target{
+		server:   grpc.NewServer(),
+		listener: lis,
+		initial:  initial,
+		streams:  make(map[*stream]bool),
+		changed:  make(chan struct{}, 1),
+	}
+	pb.RegisterGNMIServer(t.server, t)
+	go t.server.Serve(lis)
+	return t, nil
+}
+
+// Addr 监听地址
+func (t *Target) Addr() string {
+	return t.listener.Addr().String()
+}
+
+// Port 监听端口, 用于 Device.GNMI.Port
+func (t *Target) Port() int {
+	_, port, _ := net.SplitHostPort(t.Addr())
+	n, _ := strconv.Atoi(port)
+	return n
+}
+
+// SetInitial 替换新订阅的初始通知
+func (t *Target) SetInitial(initial ...*pb.Notification) {
+	t.mu.Lock()
+	t.initial = initial
+	t.mu.Unlock()
+}
+
+// Send 向当前所有订阅推送通知
+func (t *Target) Send(n *pb.Notification) {
+	t.mu.Lock()
+	streams := make([]*stream, 0, len(t.streams))
+	for s := range t.streams {
+		streams = append(streams, s)
+	}
+	t.mu.Unlock()
+
+	for _, s := range streams {
+		select {
+		case s.updates <- n:
+		case <-s.done:
+		}
+	}
+}
+
+// Disconnect 断开当前所有订阅 (模拟设备重启或网络中断)
+func (t *Target) Disconnect() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for s := range t.streams {
+		close(s.done)
+		delete(t.streams, s)
+	}
+}
+
+// Subscriptions 当前活动订阅数
+func (t *Target) Subscriptions() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.streams)
+}
+
+// Requests 已收到的订阅请求
+func (t *Target) Requests() []*pb.SubscriptionList {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*pb.SubscriptionList(nil), t.requests...)
+}
+
+// Changed 订阅建立或断开时收到通知
+func (t *Target) Changed() <-chan struct{} {
+	return t.changed
+}
+
+// Close 停止目标
+func (t *Target) Close() {
+	t.Disconnect()
+	t.server.Stop()
+}
+
+// Subscribe 实现 gNMI Subscribe, 仅支持 STREAM 模式
+func (t *Target) Subscribe(srv pb.GNMI_SubscribeServer) error {
+	if err := t.authenticate(srv); err != nil {
+		return err
+	}
+
+	req, err := srv.Recv()
+	if err != nil {
+		return err
+	}
+	list := req.GetSubscribe()
+	if list == nil {
+		return status.Error(codes.InvalidArgument, "first request must be a subscription list")
+	}
+	if list.Mode != pb.SubscriptionList_STREAM {
+		return status.Error(codes.Unimplemented, "only STREAM subscriptions are supported")
+	}
+
+	s := &stream{updates: make(chan *pb.Notification), done: make(chan struct{})}
+	t.mu.Lock()
+	t.requests = append(t.requests, list)
+	initial := t.initial
+	t.streams[s] = true
+	t.mu.Unlock()
+	t.notify()
+	defer t.notify()
+	defer func() {
+		t.mu.Lock()
+		if t.streams[s] {
+			close(s.done)
+			delete(t.streams, s)
+		}
+		t.mu.Unlock()
+	}()
+
+	for _, n := range initial {
+		if err := srv.Send(&pb.SubscribeResponse{Response: &pb.SubscribeResponse_Update{Update: n}}); err != nil {
+			return err
+		}
+	}
+	if err := srv.Send(&pb.SubscribeResponse{Response: &pb.SubscribeResponse_SyncResponse{SyncResponse: true}}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-srv.Context().Done():
+			return srv.Context().Err()
+		case <-s.done:
+			return status.Error(codes.Unavailable, "target disconnected")
+		case n := <-s.updates:
+			if err := srv.Send(&pb.SubscribeResponse{Response: &pb.SubscribeResponse_Update{Update: n}}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (t *Target) authenticate(srv pb.GNMI_SubscribeServer) error {
+	if t.Username == "" && t.Password == "" {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(srv.Context())
+	if first(md.Get("username")) != t.Username || first(md.Get("password")) != t.Password {
+		return status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+	return nil
+}
+
+func (t *Target) notify() {
+	select {
+	case t.changed <- struct{}{}:
+	default:
+	}
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
@@ -0,0 +1,101 @@
+package collector
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/netvis/collector/internal/config"
+)
+
+func init() {
+	RegisterProbe("http", newHTTPProbe)
+}
+
+// httpProbeOptions HTTP探测参数, url 中的 {ip} 替换为设备地址
+type httpProbeOptions struct {
+	URL                string        `yaml:"url"`
+	Method             string        `yaml:"method"`
+	ExpectStatus       []int         `yaml:"expectStatus"` // 为空时 <400 视为成功
+	Timeout            time.Duration `yaml:"timeout"`
+	InsecureSkipVerify bool          `yaml:"insecureSkipVerify"`
+}
+
+// httpProbe HTTP(S) 服务可用性探测
+type httpProbe struct {
+	name   string
+	types  deviceTypeFilter
+	opts   httpProbeOptions
+	client *http.Client
+}
+
+func newHTTPProbe(c *Collector, cfg config.ProbeConfig) (Probe, error) {
+	opts := httpProbeOptions{
+		URL:     "http://{ip}/",
+		Method:  http.MethodGet,
+		Timeout: 5 * time.Second,
+	}
+	if err := cfg.DecodeOptions(&opts); err != nil {
+		return nil, fmt.Errorf("decode http probe options: %w", err)
+	}
+
+	client := &http.Client{
+		Timeout: opts.Timeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify}, // 设备多为自签名证书, 由配置决定
+		},
+		// 不跟随重定向, 以首个响应状态码判定
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return &httpProbe{name: cfg.Name, types: newDeviceTypeFilter(cfg.DeviceTypes), opts: opts, client: client}, nil
+}
+
+func (p *httpProbe) Name() string { return p.name }
+
+func (p *httpProbe) Applicable(device Device) bool {
+	return p.types.match(device.Type)
+}
+
+func (p *httpProbe) Collect(ctx context.Context, device Device, sample *DeviceMetrics) error {
+	url := strings.ReplaceAll(p.opts.URL, "{ip}", device.IP)
+	result := CheckResult{Probe: p.name, Target: url}
+	defer func() { sample.Checks = append(sample.Checks, result) }()
+
+	req, err := http.NewRequestWithContext(ctx, p.opts.Method, url, nil)
+	if err != nil {
+		result.Detail = err.Error()
+		return err
+	}
+
+	start := time.Now()
+	resp, err := p.client.Do(req)
+	if err != nil {
+		result.Detail = err.Error()
+		return nil
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+
+	result.Latency = float64(time.Since(start).Microseconds()) / 1000.0
+	result.Detail = resp.Status
+	result.Success = p.statusOK(resp.StatusCode)
+	return nil
+}
+
+func (p *httpProbe) statusOK(code int) bool {
+	if len(p.opts.ExpectStatus) == 0 {
+		return code < 400
+	}
+	for _, s := range p.opts.ExpectStatus {
+		if s == code {
+			return true
+		}
+	}
+	return false
+}
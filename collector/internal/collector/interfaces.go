@@ -0,0 +1,166 @@
+package collector
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// 接口表 OID (IF-MIB)
+const (
+	// ifTable
+	oidIfDescr       = ".1.3.6.1.2.1.2.2.1.2"  // ifDescr
+	oidIfType        = ".1.3.6.1.2.1.2.2.1.3"  // ifType
+	oidIfSpeed       = ".1.3.6.1.2.1.2.2.1.5"  // ifSpeed (bps, 最大 4294967295)
+	oidIfAdminStatus = ".1.3.6.1.2.1.2.2.1.7"  // ifAdminStatus
+	oidIfOperStatus  = ".1.3.6.1.2.1.2.2.1.8"  // ifOperStatus
+	oidIfInOctets    = ".1.3.6.1.2.1.2.2.1.10" // ifInOctets
+	oidIfInUcastPkts = ".1.3.6.1.2.1.2.2.1.11" // ifInUcastPkts
+	oidIfInDiscards  = ".1.3.6.1.2.1.2.2.1.13" // ifInDiscards
+	oidIfInErrors    = ".1.3.6.1.2.1.2.2.1.14" // ifInErrors
+	oidIfOutOctets   = ".1.3.6.1.2.1.2.2.1.16" // ifOutOctets
+	oidIfOutUcastPkt = ".1.3.6.1.2.1.2.2.1.17" // ifOutUcastPkts
+	oidIfOutDiscards = ".1.3.6.1.2.1.2.2.1.19" // ifOutDiscards
+	oidIfOutErrors   = ".1.3.6.1.2.1.2.2.1.20" // ifOutErrors
+
+	// ifXTable
+	oidIfName              = ".1.3.6.1.2.1.31.1.1.1.1"  // ifName
+	oidIfInMulticastPkts   = ".1.3.6.1.2.1.31.1.1.1.2"  // ifInMulticastPkts
+	oidIfInBroadcastPkts   = ".1.3.6.1.2.1.31.1.1.1.3"  // ifInBroadcastPkts
+	oidIfOutMulticastPkts  = ".1.3.6.1.2.1.31.1.1.1.4"  // ifOutMulticastPkts
+	oidIfOutBroadcastPkts  = ".1.3.6.1.2.1.31.1.1.1.5"  // ifOutBroadcastPkts
+	oidIfHCInOctets        = ".1.3.6.1.2.1.31.1.1.1.6"  // ifHCInOctets
+	oidIfHCInUcastPkts     = ".1.3.6.1.2.1.31.1.1.1.7"  // ifHCInUcastPkts
+	oidIfHCInMulticastPkts = ".1.3.6.1.2.1.31.1.1.1.8"  // ifHCInMulticastPkts
+	oidIfHCInBroadcastPkts = ".1.3.6.1.2.1.31.1.1.1.9"  // ifHCInBroadcastPkts
+	oidIfHCOutOctets       = ".1.3.6.1.2.1.31.1.1.1.10" // ifHCOutOctets
+	oidIfHCOutUcastPkts    = ".1.3.6.1.2.1.31.1.1.1.11" // ifHCOutUcastPkts
+	oidIfHCOutMulticastPkt = ".1.3.6.1.2.1.31.1.1.1.12" // ifHCOutMulticastPkts
+	oidIfHCOutBroadcastPkt = ".1.3.6.1.2.1.31.1.1.1.13" // ifHCOutBroadcastPkts
+	oidIfHighSpeed         = ".1.3.6.1.2.1.31.1.1.1.15" // ifHighSpeed (Mbps)
+	oidIfAlias             = ".1.3.6.1.2.1.31.1.1.1.18" // ifAlias
+)
+
+// ifStatusNames ifAdminStatus / ifOperStatus 取值
+var ifStatusNames = map[int64]string{
+	1: "up",
+	2: "down",
+	3: "testing",
+	4: "unknown",
+	5: "dormant",
+	6: "notPresent",
+	7: "lowerLayerDown",
+}
+
+func ifStatusName(v int64, ok bool) string {
+	if !ok {
+		return ""
+	}
+	if name, found := ifStatusNames[v]; found {
+		return name
+	}
+	return "unknown"
+}
+
+// collectInterfaces 采集接口统计
+// 一次并行遍历 ifTable/ifXTable 所需列, 按真实 ifIndex 关联
+// 遍历失败 (包括中途超时) 时返回错误, 避免未取回的接口被当作已删除而丢失状态与速率基线
+func (c *Collector) collectInterfaces(snmp *gosnmp.GoSNMP) ([]IfStats, error) {
+	table, err := walkTable(snmp,
+		oidIfDescr, oidIfType, oidIfSpeed, oidIfAdminStatus, oidIfOperStatus,
+		oidIfInOctets, oidIfInUcastPkts, oidIfInDiscards, oidIfInErrors,
+		oidIfOutOctets, oidIfOutUcastPkt, oidIfOutDiscards, oidIfOutErrors,
+		oidIfName, oidIfInMulticastPkts, oidIfInBroadcastPkts,
+		oidIfOutMulticastPkts, oidIfOutBroadcastPkts,
+		oidIfHCInOctets, oidIfHCInUcastPkts, oidIfHCInMulticastPkts, oidIfHCInBroadcastPkts,
+		oidIfHCOutOctets, oidIfHCOutUcastPkts, oidIfHCOutMulticastPkt, oidIfHCOutBroadcastPkt,
+		oidIfHighSpeed, oidIfAlias,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	interfaces := make([]IfStats, 0, len(table))
+	for index := range table {
+		ifIndex, err := strconv.Atoi(index)
+		if err != nil {
+			continue
+		}
+		// 仅 ifXTable 有值而 ifTable 缺失的行视为无效
+		if _, ok := table[index][oidIfDescr]; !ok {
+			if _, ok := table[index][oidIfName]; !ok {
+				continue
+			}
+		}
+
+		stats := IfStats{
+			Index: ifIndex,
+			Descr: table.rowString(index, oidIfDescr),
+			Name:  table.rowString(index, oidIfName),
+			Alias: table.rowString(index, oidIfAlias),
+		}
+		if stats.Name == "" {
+			stats.Name = stats.Descr
+		}
+		if v, ok := table.rowInt(index, oidIfType); ok {
+			stats.Type = int(v)
+		}
+
+		// ifSpeed 超过 4.29Gbps 时饱和, 优先使用 ifHighSpeed
+		if high, ok := table.rowUint(index, oidIfHighSpeed); ok && high > 0 {
+			stats.Speed = high * 1000000
+		} else if speed, ok := table.rowUint(index, oidIfSpeed); ok {
+			stats.Speed = speed
+		}
+
+		stats.AdminStatus = ifStatusName(table.rowInt(index, oidIfAdminStatus))
+		stats.OperStatus = ifStatusName(table.rowInt(index, oidIfOperStatus))
+		stats.Status = stats.OperStatus
+
+		// 优先使用64位HC计数器, 10G链路上32位 ifInOctets 数秒即回绕
+		inHC, inOK := table.rowInt(index, oidIfHCInOctets)
+		outHC, outOK := table.rowInt(index, oidIfHCOutOctets)
+		stats.HighCapacity = inOK && outOK
+		if stats.HighCapacity {
+			stats.InBytes = inHC
+			stats.OutBytes = outHC
+		} else {
+			stats.InBytes = table.counter(index, oidIfInOctets)
+			stats.OutBytes = table.counter(index, oidIfOutOctets)
+		}
+
+		// 包计数器逐个回退到32位列, 记录各自位宽供速率计算处理回绕
+		pkts := []struct {
+			value     *int64
+			hc, col32 string
+		}{
+			{&stats.InUcastPkts, oidIfHCInUcastPkts, oidIfInUcastPkts},
+			{&stats.InMulticastPkts, oidIfHCInMulticastPkts, oidIfInMulticastPkts},
+			{&stats.InBroadcastPkts, oidIfHCInBroadcastPkts, oidIfInBroadcastPkts},
+			{&stats.OutUcastPkts, oidIfHCOutUcastPkts, oidIfOutUcastPkt},
+			{&stats.OutMulticastPkts, oidIfHCOutMulticastPkt, oidIfOutMulticastPkts},
+			{&stats.OutBroadcastPkts, oidIfHCOutBroadcastPkt, oidIfOutBroadcastPkts},
+		}
+		for i, p := range pkts {
+			if v, ok := table.rowInt(index, p.hc); ok {
+				*p.value = v
+			} else {
+				*p.value = table.counter(index, p.col32)
+				stats.pkts32 |= 1 << i
+			}
+		}
+		stats.InDiscards = table.counter(index, oidIfInDiscards)
+		stats.OutDiscards = table.counter(index, oidIfOutDiscards)
+		stats.InErrors = table.counter(index, oidIfInErrors)
+		stats.OutErrors = table.counter(index, oidIfOutErrors)
+
+		interfaces = append(interfaces, stats)
+	}
+
+	sort.Slice(interfaces, func(i, j int) bool {
+		return interfaces[i].Index < interfaces[j].Index
+	})
+
+	return interfaces, nil
+}
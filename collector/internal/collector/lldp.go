@@ -0,0 +1,268 @@
+package collector
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// LLDP-MIB OID
+const (
+	// lldpRemTable, 索引: timeMark.localPortNum.remIndex
+	oidLldpRemChassisIdSubtype = ".1.0.8802.1.1.2.1.4.1.1.4"
+	oidLldpRemChassisId        = ".1.0.8802.1.1.2.1.4.1.1.5"
+	oidLldpRemPortIdSubtype    = ".1.0.8802.1.1.2.1.4.1.1.6"
+	oidLldpRemPortId           = ".1.0.8802.1.1.2.1.4.1.1.7"
+	oidLldpRemPortDesc         = ".1.0.8802.1.1.2.1.4.1.1.8"
+	oidLldpRemSysName          = ".1.0.8802.1.1.2.1.4.1.1.9"
+	oidLldpRemSysDesc          = ".1.0.8802.1.1.2.1.4.1.1.10"
+
+	// lldpLocPortTable, 索引: lldpLocPortNum
+	oidLldpLocPortIdSubtype = ".1.0.8802.1.1.2.1.3.7.1.2"
+	oidLldpLocPortId        = ".1.0.8802.1.1.2.1.3.7.1.3"
+	oidLldpLocPortDesc      = ".1.0.8802.1.1.2.1.3.7.1.4"
+
+	// lldpRemManAddrTable, 索引: timeMark.localPortNum.remIndex.addrSubtype.addrLen.addr
+	oidLldpRemManAddrIfSubtype = ".1.0.8802.1.1.2.1.4.2.1.3"
+)
+
+// LLDP chassis ID 子类型 (LldpChassisIdSubtype)
+const (
+	lldpChassisComponent = 1
+	lldpChassisIfAlias   = 2
+	lldpChassisPortComp  = 3
+	lldpChassisMAC       = 4
+	lldpChassisNetAddr   = 5
+	lldpChassisIfName    = 6
+	lldpChassisLocal     = 7
+)
+
+// LLDP port ID 子类型 (LldpPortIdSubtype)
+const (
+	lldpPortIfAlias = 1
+	lldpPortMAC     = 3
+	lldpPortNetAddr = 4
+	lldpPortIfName  = 5
+	lldpPortLocal   = 7
+)
+
+var lldpChassisSubtypeNames = map[int64]string{
+	lldpChassisComponent: "chassisComponent",
+	lldpChassisIfAlias:   "interfaceAlias",
+	lldpChassisPortComp:  "portComponent",
+	lldpChassisMAC:       "macAddress",
+	lldpChassisNetAddr:   "networkAddress",
+	lldpChassisIfName:    "interfaceName",
+	lldpChassisLocal:     "local",
+}
+
+// collectLLDP 采集LLDP邻居
+// ifNames 为 ifIndex -> 接口名, 用于本地端口号无法从 lldpLocPortTable 解析时兜底
+func (c *Collector) collectLLDP(snmp *gosnmp.GoSNMP, ifNames map[int]string) ([]Neighbor, error) {
+	remotes, err := walkTable(snmp,
+		oidLldpRemChassisIdSubtype, oidLldpRemChassisId,
+		oidLldpRemPortIdSubtype, oidLldpRemPortId, oidLldpRemPortDesc,
+		oidLldpRemSysName, oidLldpRemSysDesc,
+	)
+	if err != nil {
+		return nil, err // LLDP not supported or enabled
+	}
+	if len(remotes) == 0 {
+		return []Neighbor{}, nil
+	}
+
+	locals, err := walkTable(snmp, oidLldpLocPortIdSubtype, oidLldpLocPortId, oidLldpLocPortDesc)
+	if err != nil {
+		locals = snmpTable{}
+	}
+
+	manAddrs := c.collectLLDPManAddrs(snmp)
+
+	neighbors := make([]Neighbor, 0, len(remotes))
+	for index := range remotes {
+		idx, err := parseIndex(index)
+		if err != nil || len(idx) != 3 {
+			continue
+		}
+		localPortNum := idx[1]
+		remKey := fmt.Sprintf("%d.%d.%d", idx[0], idx[1], idx[2])
+
+		chassisSubtype, _ := remotes.rowInt(index, oidLldpRemChassisIdSubtype)
+		chassisRaw := pduBytes(remotes[index][oidLldpRemChassisId])
+		portSubtype, _ := remotes.rowInt(index, oidLldpRemPortIdSubtype)
+		portRaw := pduBytes(remotes[index][oidLldpRemPortId])
+
+		n := Neighbor{
+			LocalPort:           resolveLocalPort(locals, localPortNum, ifNames),
+			LocalPortIndex:      localPortNum,
+			RemoteChassisID:     decodeChassisID(chassisSubtype, chassisRaw),
+			RemoteChassisIDType: lldpChassisSubtypeNames[chassisSubtype],
+			RemotePort:          decodePortID(portSubtype, portRaw, remotes.rowString(index, oidLldpRemPortDesc)),
+			RemotePortDescr:     remotes.rowString(index, oidLldpRemPortDesc),
+			RemoteSystemName:    remotes.rowString(index, oidLldpRemSysName),
+			RemoteSystemDescr:   remotes.rowString(index, oidLldpRemSysDesc),
+			RemoteIP:            manAddrs[remKey],
+			LinkType:            "ethernet",
+			Protocol:            "lldp",
+		}
+		if n.RemoteIP == "" && chassisSubtype == lldpChassisNetAddr {
+			n.RemoteIP = decodeNetworkAddress(chassisRaw)
+		}
+		if n.RemoteSystemName == "" {
+			n.RemoteSystemName = n.RemoteChassisID
+		}
+
+		neighbors = append(neighbors, n)
+	}
+
+	sort.Slice(neighbors, func(i, j int) bool {
+		if neighbors[i].LocalPortIndex != neighbors[j].LocalPortIndex {
+			return neighbors[i].LocalPortIndex < neighbors[j].LocalPortIndex
+		}
+		return neighbors[i].RemoteChassisID < neighbors[j].RemoteChassisID
+	})
+
+	return neighbors, nil
+}
+
+// collectLLDPManAddrs 采集远端管理地址, 返回 timeMark.localPortNum.remIndex -> IP (IPv4优先)
+func (c *Collector) collectLLDPManAddrs(snmp *gosnmp.GoSNMP) map[string]string {
+	addrs := make(map[string]string)
+
+	table, err := walkTable(snmp, oidLldpRemManAddrIfSubtype)
+	if err != nil {
+		return addrs
+	}
+
+	for index := range table {
+		idx, err := parseIndex(index)
+		// timeMark.localPortNum.remIndex.addrSubtype.addrLen.addr[addrLen]
+		if err != nil || len(idx) < 5 || len(idx) != 5+idx[4] {
+			continue
+		}
+		key := fmt.Sprintf("%d.%d.%d", idx[0], idx[1], idx[2])
+		addrBytes := make([]byte, idx[4])
+		for i := range addrBytes {
+			addrBytes[i] = byte(idx[5+i])
+		}
+
+		var ip string
+		switch idx[3] {
+		case 1: // ipV4
+			if len(addrBytes) == net.IPv4len {
+				ip = net.IP(addrBytes).String()
+			}
+		case 2: // ipV6
+			if len(addrBytes) == net.IPv6len {
+				ip = net.IP(addrBytes).String()
+			}
+		}
+		if ip == "" {
+			continue
+		}
+		if existing, ok := addrs[key]; ok && strings.Count(existing, ".") == 3 {
+			continue
+		}
+		addrs[key] = ip
+	}
+
+	return addrs
+}
+
+// resolveLocalPort 将 lldpLocPortNum 解析为本地端口名
+func resolveLocalPort(locals snmpTable, portNum int, ifNames map[int]string) string {
+	index := strconv.Itoa(portNum)
+	if _, ok := locals[index]; ok {
+		subtype, _ := locals.rowInt(index, oidLldpLocPortIdSubtype)
+		raw := pduBytes(locals[index][oidLldpLocPortId])
+		desc := locals.rowString(index, oidLldpLocPortDesc)
+		if name := decodePortID(subtype, raw, desc); name != "" {
+			return name
+		}
+	}
+	// 多数设备的 lldpLocPortNum 与 ifIndex 一致
+	if name, ok := ifNames[portNum]; ok {
+		return name
+	}
+	return index
+}
+
+// decodeChassisID 按子类型解码 chassis ID
+func decodeChassisID(subtype int64, raw []byte) string {
+	switch subtype {
+	case lldpChassisMAC:
+		if len(raw) == 6 {
+			return formatMAC(raw)
+		}
+	case lldpChassisNetAddr:
+		if ip := decodeNetworkAddress(raw); ip != "" {
+			return ip
+		}
+	}
+	return printableOrHex(raw)
+}
+
+// decodePortID 按子类型解码 port ID; MAC 形式的端口ID优先使用端口描述
+func decodePortID(subtype int64, raw []byte, desc string) string {
+	switch subtype {
+	case lldpPortIfName, lldpPortIfAlias, lldpPortLocal:
+		if s := printableOrHex(raw); s != "" {
+			return s
+		}
+	case lldpPortMAC:
+		if desc != "" {
+			return desc
+		}
+		if len(raw) == 6 {
+			return formatMAC(raw)
+		}
+	case lldpPortNetAddr:
+		if ip := decodeNetworkAddress(raw); ip != "" {
+			return ip
+		}
+	}
+	if s := printableOrHex(raw); s != "" {
+		return s
+	}
+	return desc
+}
+
+// decodeNetworkAddress 解码 IANA 地址族前缀的网络地址
+func decodeNetworkAddress(raw []byte) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	switch {
+	case raw[0] == 1 && len(raw) == 1+net.IPv4len:
+		return net.IP(raw[1:]).String()
+	case raw[0] == 2 && len(raw) == 1+net.IPv6len:
+		return net.IP(raw[1:]).String()
+	}
+	return ""
+}
+
+// formatMAC 格式化为 aa:bb:cc:dd:ee:ff
+func formatMAC(raw []byte) string {
+	return net.HardwareAddr(raw).String()
+}
+
+// printableOrHex 可打印字符串原样返回, 否则返回冒号分隔的十六进制
+func printableOrHex(raw []byte) string {
+	s := strings.TrimRight(string(raw), "\x00")
+	for _, r := range s {
+		if r == utf8.RuneError || !unicode.IsPrint(r) {
+			parts := make([]string, len(raw))
+			for i, b := range raw {
+				parts[i] = fmt.Sprintf("%02x", b)
+			}
+			return strings.Join(parts, ":")
+		}
+	}
+	return s
+}
@@ -0,0 +1,341 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/netvis/collector/internal/config"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+func init() {
+	RegisterProbe("netconf", newNETCONFProbe)
+}
+
+const (
+	netconfYangLibrary = "urn:ietf:params:netconf:capability:yang-library:"
+	ietfInterfacesNS   = "urn:ietf:params:xml:ns:yang:ietf-interfaces"
+)
+
+// netconfStateFilters 默认的运行状态子树过滤, 仅对 hello 中声明了对应模块的设备发送
+// (声明 yang-library 能力的设备不在 hello 中列出模块, 全部尝试)
+var netconfStateFilters = []struct {
+	namespace string
+	filter    string
+}{
+	{"http://openconfig.net/yang/interfaces", `<interfaces xmlns="http://openconfig.net/yang/interfaces"><interface><name/><state/></interface></interfaces>`},
+	{"http://openconfig.net/yang/system", `<system xmlns="http://openconfig.net/yang/system"><cpus/><memory/></system>`},
+	{ietfInterfacesNS, `<interfaces-state xmlns="` + ietfInterfacesNS + `"/><interfaces xmlns="` + ietfInterfacesNS + `"/>`},
+}
+
+// netconfVolatileAttrs 配置中随提交变化的属性 (如 Junos 的 junos:changed-seconds), 计算哈希时忽略
+var netconfVolatileAttrs = regexp.MustCompile(`\s+[\w-]+:(changed|commit)-(seconds|localtime|user)="[^"]*"`)
+
+// netconfProbeOptions NETCONF 采集参数
+type netconfProbeOptions struct {
+	sshLoginOptions `yaml:",inline"`
+	Sources         []string `yaml:"sources"` // 备份的数据存储, 默认 running; candidate 仅在设备声明 :candidate 能力时获取
+	Filters         []string `yaml:"filters"` // 覆盖默认的 get 子树过滤
+}
+
+// netconfProbe 通过 NETCONF 获取配置快照及运行状态 (接口计数器/状态、CPU、内存)
+type netconfProbe struct {
+	c     *Collector
+	name  string
+	types deviceTypeFilter
+	opts  netconfProbeOptions
+	login *sshLogin
+}
+
+func newNETCONFProbe(c *Collector, cfg config.ProbeConfig) (Probe, error) {
+	opts := netconfProbeOptions{
+		sshLoginOptions: sshLoginOptions{Port: 830, Timeout: 30 * time.Second},
+		Sources:         []string{"running"},
+	}
+	if err := cfg.DecodeOptions(&opts); err != nil {
+		return nil, fmt.Errorf("decode netconf probe options: %w", err)
+	}
+	for _, source := range opts.Sources {
+		if source != "running" && source != "candidate" && source != "startup" {
+			return nil, fmt.Errorf("unsupported netconf source %q", source)
+		}
+	}
+	login, err := newSSHLogin(opts.sshLoginOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &netconfProbe{
+		c:     c,
+		name:  cfg.Name,
+		types: newDeviceTypeFilter(cfg.DeviceTypes),
+		opts:  opts,
+		login: login,
+	}, nil
+}
+
+func (p *netconfProbe) Name() string { return p.name }
+
+func (p *netconfProbe) Applicable(device Device) bool {
+	return p.types.match(device.Type)
+}
+
+// Collect 凭据与 ssh 探针共用, 端口固定取探针配置 (默认 830), 凭据模板中的端口仅用于 CLI
+func (p *netconfProbe) Collect(ctx context.Context, device Device, sample *DeviceMetrics) error {
+	cred, err := p.login.credential(p.c, device)
+	if err != nil {
+		return err
+	}
+	target := net.JoinHostPort(device.IP, strconv.Itoa(p.opts.Port))
+
+	start := time.Now()
+	result := CheckResult{Probe: p.name, Target: target}
+	defer func() { sample.Checks = append(sample.Checks, result) }()
+
+	client, err := p.login.dial(ctx, target, cred)
+	if err != nil {
+		result.Detail = err.Error()
+		return err
+	}
+	defer client.Close()
+
+	session, err := newNETCONFSession(ctx, client, p.opts.Timeout)
+	if err != nil {
+		result.Detail = err.Error()
+		return err
+	}
+	defer session.Close()
+
+	backed, err := p.backup(ctx, session, device)
+	if err != nil {
+		result.Detail = err.Error()
+		return err
+	}
+	if err := p.collectState(ctx, session, device, sample); err != nil {
+		result.Detail = err.Error()
+		return err
+	}
+
+	result.Success = true
+	result.Latency = float64(time.Since(start).Microseconds()) / 1000.0
+	result.Detail = fmt.Sprintf("session %s, %d config(s) collected", session.sessionID, backed)
+	return nil
+}
+
+// backup 获取各数据存储的配置并发送快照 (由 reporter 按哈希去重), 返回快照数量
+func (p *netconfProbe) backup(ctx context.Context, session *netconfSession, device Device) (int, error) {
+	vendor := device.Vendor
+	if vendor == "" {
+		vendor = netconfVendor(session.capabilities)
+	}
+
+	backed := 0
+	for _, source := range p.opts.Sources {
+		if source == "candidate" && !session.hasCapability(netconfCandidate) {
+			continue
+		}
+		data, err := session.getConfig(ctx, source)
+		if err != nil {
+			return backed, fmt.Errorf("get-config %s: %w", source, err)
+		}
+		if len(data) == 0 {
+			continue
+		}
+
+		content := string(data)
+		snapshot := ConfigSnapshot{
+			CollectorID: p.c.config.Collector.ID,
+			DeviceID:    device.ID,
+			IP:          device.IP,
+			Vendor:      vendor,
+			Type:        source,
+			Content:     content,
+			Size:        len(content),
+			Hash:        configHash(netconfVolatileAttrs.ReplaceAllString(content, "")),
+			CollectedAt: time.Now(),
+		}
+		p.c.reportConfig(snapshot)
+		backed++
+	}
+	return backed, nil
+}
+
+// collectState 执行过滤 get 并将结果合并到设备样本
+// 默认过滤失败 (设备不支持该模型) 时忽略, 自定义过滤失败时返回错误
+func (p *netconfProbe) collectState(ctx context.Context, session *netconfSession, device Device, sample *DeviceMetrics) error {
+	filters := p.opts.Filters
+	custom := filters != nil
+	if !custom {
+		all := session.hasCapability(netconfYangLibrary+"1.0") || session.hasCapability(netconfYangLibrary+"1.1")
+		for _, f := range netconfStateFilters {
+			if all || session.hasCapability(f.namespace) {
+				filters = append(filters, f.filter)
+			}
+		}
+	}
+
+	state := newGNMIState()
+	for _, filter := range filters {
+		data, err := session.get(ctx, filter)
+		if err != nil {
+			if custom {
+				return fmt.Errorf("get: %w", err)
+			}
+			p.c.logger.WithError(err).WithField("ip", device.IP).Debug("NETCONF state filter not supported")
+			continue
+		}
+		if err := state.xml(data); err != nil {
+			return fmt.Errorf("parse get reply: %w", err)
+		}
+	}
+
+	if state.dirty {
+		updated, _ := state.take()
+		p.c.mergeOpenConfig(device.ID, time.Now(), sample, state, updated, nil)
+	}
+	return nil
+}
+
+// netconfVendor 按 hello 中厂商私有能力推断厂商
+func netconfVendor(capabilities []string) string {
+	for _, c := range capabilities {
+		switch {
+		case strings.Contains(c, "juniper.net"):
+			return "juniper"
+		case strings.Contains(c, "huawei.com"):
+			return "huawei"
+		case strings.Contains(c, "h3c.com"):
+			return "h3c"
+		case strings.Contains(c, "cisco.com"):
+			return "cisco"
+		}
+	}
+	return ""
+}
+
+// xmlNode 解析后的 XML 元素
+type xmlNode struct {
+	name     xml.Name
+	text     string
+	children []*xmlNode
+}
+
+// xml 展开 NETCONF <data> 内容为叶子节点, 按 OpenConfig / ietf-interfaces 映射
+func (s *gnmiState) xml(data []byte) error {
+	s.at = time.Now()
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var stack []*xmlNode
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			stack = append(stack, &xmlNode{name: t.Name})
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		case xml.EndElement:
+			if len(stack) == 0 {
+				continue
+			}
+			node := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+				continue
+			}
+			s.xmlWalk(node.name.Space, nil, node)
+		}
+	}
+}
+
+// xmlWalk 含 name / index 子叶子的元素视为列表项, 生成与 gNMI 路径相同的键
+func (s *gnmiState) xmlWalk(namespace string, elems []*pb.PathElem, node *xmlNode) {
+	elem := &pb.PathElem{Name: node.name.Local}
+	if len(node.children) == 0 {
+		s.xmlLeaf(namespace, appendElem(elems, elem), strings.TrimSpace(node.text))
+		return
+	}
+	for _, field := range []string{"name", "index"} {
+		if child := node.child(field); child != nil && len(child.children) == 0 {
+			elem.Key = map[string]string{field: strings.TrimSpace(child.text)}
+			break
+		}
+	}
+	elems = appendElem(elems, elem)
+	for _, child := range node.children {
+		s.xmlWalk(namespace, elems, child)
+	}
+}
+
+func (n *xmlNode) child(local string) *xmlNode {
+	for _, c := range n.children {
+		if c.name.Local == local {
+			return c
+		}
+	}
+	return nil
+}
+
+func (s *gnmiState) xmlLeaf(namespace string, elems []*pb.PathElem, v string) {
+	if namespace == ietfInterfacesNS {
+		s.ietfLeaf(elems, v)
+		return
+	}
+	s.leaf(elems, v)
+}
+
+// ietfLeaf 映射 ietf-interfaces (RFC 8343 及旧版 interfaces-state) 的接口统计与状态
+func (s *gnmiState) ietfLeaf(elems []*pb.PathElem, v string) {
+	names := elemNames(elems)
+	if !hasPrefix(names, "interfaces", "interface") && !hasPrefix(names, "interfaces-state", "interface") {
+		return
+	}
+	name := elems[1].GetKey()["name"]
+	if name == "" || len(names) < 3 {
+		return
+	}
+
+	leaf := names[2:]
+	switch {
+	case len(leaf) == 2 && leaf[0] == "statistics":
+		// 计数器名称与 OpenConfig 相同
+		s.ifaceLeaf(name, []string{"counters", leaf[1]}, v)
+	case len(leaf) == 1 && leaf[0] == "if-index":
+		s.ifaceLeaf(name, []string{"ifindex"}, v)
+	case len(leaf) == 1 && leaf[0] == "description":
+		s.ifaceLeaf(name, leaf, v)
+	case len(leaf) == 1 && (leaf[0] == "oper-status" || leaf[0] == "admin-status"):
+		// 枚举值即 IF-MIB 名称 (up / down / lowerLayerDown ...)
+		iface := s.iface(name)
+		if leaf[0] == "oper-status" {
+			iface.stats.OperStatus, iface.stats.Status = v, v
+		} else {
+			iface.stats.AdminStatus = v
+		}
+		iface.updated = true
+		s.dirty = true
+	case len(leaf) == 1 && leaf[0] == "speed":
+		if n, err := strconv.ParseUint(v, 10, 64); err == nil {
+			iface := s.iface(name)
+			iface.stats.Speed = n
+			iface.updated = true
+			s.dirty = true
+		}
+	}
+}
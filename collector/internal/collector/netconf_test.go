@@ -0,0 +1,203 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/netvis/collector/internal/collector/netconftest"
+	"github.com/netvis/collector/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ocInterfacesNS = "http://openconfig.net/yang/interfaces"
+	ocSystemNS     = "http://openconfig.net/yang/system"
+)
+
+// newNETCONFTestProbe 创建连接 server 的 netconf 探针, options 为附加的探针参数 (yaml 流式映射内容)
+func newNETCONFTestProbe(t *testing.T, c *Collector, server *netconftest.Server, options string) (Probe, error) {
+	t.Helper()
+	var cfg config.ProbeConfig
+	doc := fmt.Sprintf("name: netconf\ntype: netconf\noptions: {port: %d, timeout: 5s, username: admin, password: secret, %s}",
+		server.Port(), options)
+	if err := yaml.Unmarshal([]byte(doc), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	return newNETCONFProbe(c, cfg)
+}
+
+func newNETCONFTestServer(t *testing.T, capabilities ...string) *netconftest.Server {
+	t.Helper()
+	server, err := netconftest.New(capabilities...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+	server.Username, server.Password = "admin", "secret"
+	return server
+}
+
+// drainConfigs 取出已发送的配置快照
+func drainConfigs(c *Collector) []ConfigSnapshot {
+	var snapshots []ConfigSnapshot
+	for {
+		select {
+		case s := <-c.Configs():
+			snapshots = append(snapshots, s)
+		default:
+			return snapshots
+		}
+	}
+}
+
+func TestNETCONFGetConfigAndState(t *testing.T) {
+	server := newNETCONFTestServer(t, ocInterfacesNS, ocSystemNS, "http://xml.juniper.net/netconf/junos/1.0")
+	server.SetRunning(`<configuration xmlns:junos="http://xml.juniper.net/junos/*/junos" junos:changed-seconds="100"><system><host-name>r1</host-name></system></configuration>`)
+	server.SetCandidate(`<configuration><system><host-name>r1-new</host-name></system></configuration>`)
+	if err := server.SetState(`<interfaces xmlns="` + ocInterfacesNS + `"><interface><name>ge-0/0/0</name><state>` +
+		`<ifindex>1</ifindex><oper-status>UP</oper-status><admin-status>UP</admin-status><description>uplink</description>` +
+		`<counters><in-octets>1000</in-octets><out-octets>2000</out-octets><in-unicast-pkts>10</in-unicast-pkts></counters>` +
+		`</state></interface></interfaces>` +
+		`<system xmlns="` + ocSystemNS + `"><cpus><cpu><index>ALL</index><state><total><instant>35</instant></total></state></cpu></cpus>` +
+		`<memory><state><physical>2000</physical><used>500</used></state></memory></system>` +
+		`<interfaces-state xmlns="` + ietfInterfacesNS + `"><interface><name>ignored</name></interface></interfaces-state>`); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newTestCollector(t, testConfig)
+	probe, err := newNETCONFTestProbe(t, c, server, "insecureIgnoreHostKey: true, sources: [running, candidate]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	device := Device{ID: "dev-1", IP: "127.0.0.1", Type: "router"}
+
+	var sample DeviceMetrics
+	if err := probe.Collect(context.Background(), device, &sample); err != nil {
+		t.Fatal(err)
+	}
+	if len(sample.Checks) != 1 || !sample.Checks[0].Success {
+		t.Fatalf("checks = %+v", sample.Checks)
+	}
+
+	snapshots := drainConfigs(c)
+	if len(snapshots) != 2 || snapshots[0].Type != "running" || snapshots[1].Type != "candidate" {
+		t.Fatalf("snapshots = %+v", snapshots)
+	}
+	running := snapshots[0]
+	if running.Vendor != "juniper" || running.DeviceID != device.ID || !strings.Contains(running.Content, "<host-name>r1</host-name>") {
+		t.Errorf("running snapshot = %+v", running)
+	}
+
+	// 状态映射: 仅请求 hello 中声明的模块
+	if sample.CPUUsage != 35 || sample.MemoryUsage != 25 {
+		t.Errorf("cpu = %v, memory = %v", sample.CPUUsage, sample.MemoryUsage)
+	}
+	if len(sample.Interfaces) != 1 {
+		t.Fatalf("interfaces = %+v", sample.Interfaces)
+	}
+	ifs := sample.Interfaces[0]
+	if ifs.Name != "ge-0/0/0" || ifs.Index != 1 || ifs.Status != "up" || ifs.AdminStatus != "up" || ifs.Alias != "uplink" ||
+		ifs.InBytes != 1000 || ifs.OutBytes != 2000 || ifs.InUcastPkts != 10 {
+		t.Errorf("interface = %+v", ifs)
+	}
+	for _, rpc := range server.RPCs() {
+		if strings.Contains(rpc, ietfInterfacesNS) {
+			t.Errorf("ietf-interfaces requested without capability: %s", rpc)
+		}
+	}
+
+	// 提交属性变化不影响哈希
+	server.SetRunning(`<configuration xmlns:junos="http://xml.juniper.net/junos/*/junos" junos:changed-seconds="200"><system><host-name>r1</host-name></system></configuration>`)
+	sample = DeviceMetrics{}
+	if err := probe.Collect(context.Background(), device, &sample); err != nil {
+		t.Fatal(err)
+	}
+	snapshots = drainConfigs(c)
+	if len(snapshots) != 2 || snapshots[0].Hash != running.Hash {
+		t.Errorf("volatile attributes changed the hash: %+v", snapshots)
+	}
+	if server.Sessions() != 2 {
+		t.Errorf("sessions = %d, want 2", server.Sessions())
+	}
+}
+
+func TestNETCONFBase10IETFInterfaces(t *testing.T) {
+	server := newNETCONFTestServer(t, ietfInterfacesNS)
+	server.Base10Only = true
+	server.SetRunning(`<interfaces xmlns="` + ietfInterfacesNS + `"><interface><name>eth0</name></interface></interfaces>`)
+	if err := server.SetState(`<interfaces-state xmlns="` + ietfInterfacesNS + `"><interface><name>eth0</name>` +
+		`<if-index>5</if-index><oper-status>down</oper-status><admin-status>up</admin-status><speed>1000000000</speed>` +
+		`<statistics><in-octets>123</in-octets><out-octets>456</out-octets><in-errors>7</in-errors></statistics>` +
+		`</interface></interfaces-state>`); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newTestCollector(t, testConfig)
+	probe, err := newNETCONFTestProbe(t, c, server, "insecureIgnoreHostKey: true, sources: [running, candidate]")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sample DeviceMetrics
+	if err := probe.Collect(context.Background(), Device{ID: "dev-2", IP: "127.0.0.1"}, &sample); err != nil {
+		t.Fatal(err)
+	}
+
+	// 未声明 :candidate 时只备份 running
+	snapshots := drainConfigs(c)
+	if len(snapshots) != 1 || snapshots[0].Type != "running" {
+		t.Fatalf("snapshots = %+v", snapshots)
+	}
+	if len(sample.Interfaces) != 1 {
+		t.Fatalf("interfaces = %+v", sample.Interfaces)
+	}
+	ifs := sample.Interfaces[0]
+	if ifs.Name != "eth0" || ifs.Index != 5 || ifs.Status != "down" || ifs.AdminStatus != "up" || ifs.Speed != 1e9 ||
+		ifs.InBytes != 123 || ifs.OutBytes != 456 || ifs.InErrors != 7 {
+		t.Errorf("interface = %+v", ifs)
+	}
+}
+
+func TestNETCONFCustomFilterError(t *testing.T) {
+	server := newNETCONFTestServer(t)
+	server.SetRunning(`<configuration/>`)
+
+	c := newTestCollector(t, testConfig)
+	probe, err := newNETCONFTestProbe(t, c, server, `insecureIgnoreHostKey: true, filters: ["<bogus xmlns=\"urn:x\">"]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sample DeviceMetrics
+	if err := probe.Collect(context.Background(), Device{ID: "dev-3", IP: "127.0.0.1"}, &sample); err == nil {
+		t.Fatal("malformed custom filter did not fail the probe")
+	}
+	if len(sample.Checks) != 1 || sample.Checks[0].Success {
+		t.Errorf("checks = %+v", sample.Checks)
+	}
+}
+
+func TestNETCONFLoginOptions(t *testing.T) {
+	server := newNETCONFTestServer(t)
+	c := newTestCollector(t, testConfig)
+
+	// 未配置 knownHosts 时必须显式跳过主机密钥校验
+	if _, err := newNETCONFTestProbe(t, c, server, "sources: [running]"); err == nil {
+		t.Fatal("probe created without knownHosts or insecureIgnoreHostKey")
+	}
+
+	probe, err := newNETCONFTestProbe(t, c, server, "insecureIgnoreHostKey: true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.Password = "other"
+	var sample DeviceMetrics
+	if err := probe.Collect(context.Background(), Device{ID: "dev-4", IP: "127.0.0.1"}, &sample); err == nil {
+		t.Fatal("login with wrong password succeeded")
+	}
+	if server.Sessions() != 0 {
+		t.Errorf("sessions = %d, want 0", server.Sessions())
+	}
+}
@@ -0,0 +1,289 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	netconfNamespace = "urn:ietf:params:xml:ns:netconf:base:1.0"
+	netconfBase10    = "urn:ietf:params:netconf:base:1.0"
+	netconfBase11    = "urn:ietf:params:netconf:base:1.1"
+	netconfCandidate = "urn:ietf:params:netconf:capability:candidate:1.0"
+	netconfEOM       = "]]>]]>"
+
+	// netconfMaxMessage 单条响应上限, 防止异常设备耗尽内存
+	netconfMaxMessage = 256 << 20
+)
+
+// netconfSession NETCONF over SSH 会话 (RFC 6241 / RFC 6242)
+// hello 使用 ]]>]]> 分帧, 双方均支持 base:1.1 时之后改用分块分帧
+type netconfSession struct {
+	session      *ssh.Session
+	w            io.WriteCloser
+	r            *bufio.Reader
+	timeout      time.Duration
+	chunked      bool
+	sessionID    string
+	capabilities []string
+	msgID        int
+}
+
+type netconfHello struct {
+	Capabilities []string `xml:"capabilities>capability"`
+	SessionID    string   `xml:"session-id"`
+}
+
+type netconfReply struct {
+	MessageID string         `xml:"message-id,attr"`
+	Errors    []netconfError `xml:"rpc-error"`
+	Data      struct {
+		Inner []byte `xml:",innerxml"`
+	} `xml:"data"`
+}
+
+type netconfError struct {
+	Type     string `xml:"error-type"`
+	Tag      string `xml:"error-tag"`
+	Severity string `xml:"error-severity"`
+	Path     string `xml:"error-path"`
+	Message  string `xml:"error-message"`
+}
+
+func (e netconfError) Error() string {
+	msg := strings.TrimSpace(e.Message)
+	if msg == "" {
+		msg = e.Tag
+	}
+	if e.Path != "" {
+		return fmt.Sprintf("netconf %s error at %s: %s", e.Type, strings.TrimSpace(e.Path), msg)
+	}
+	return fmt.Sprintf("netconf %s error: %s", e.Type, msg)
+}
+
+// newNETCONFSession 打开 netconf 子系统并交换 hello
+func newNETCONFSession(ctx context.Context, client *ssh.Client, timeout time.Duration) (*netconfSession, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("open session: %w", err)
+	}
+	w, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	r, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	if err := session.RequestSubsystem("netconf"); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("request netconf subsystem: %w", err)
+	}
+
+	s := &netconfSession{session: session, w: w, r: bufio.NewReader(r), timeout: timeout}
+	if err := s.hello(ctx); err != nil {
+		session.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *netconfSession) hello(ctx context.Context) error {
+	hello := xml.Header + `<hello xmlns="` + netconfNamespace + `"><capabilities>` +
+		`<capability>` + netconfBase10 + `</capability>` +
+		`<capability>` + netconfBase11 + `</capability>` +
+		`</capabilities></hello>`
+	reply, err := s.exchange(ctx, []byte(hello))
+	if err != nil {
+		return fmt.Errorf("netconf hello: %w", err)
+	}
+
+	var h netconfHello
+	if err := xml.Unmarshal(reply, &h); err != nil {
+		return fmt.Errorf("parse netconf hello: %w", err)
+	}
+	for i, c := range h.Capabilities {
+		h.Capabilities[i] = strings.TrimSpace(c)
+	}
+	s.capabilities = h.Capabilities
+	s.sessionID = strings.TrimSpace(h.SessionID)
+	s.chunked = s.hasCapability(netconfBase11)
+	return nil
+}
+
+// hasCapability 按 URI 匹配能力, 忽略 ?module=...&revision=... 参数
+func (s *netconfSession) hasCapability(uri string) bool {
+	for _, c := range s.capabilities {
+		if c == uri || strings.HasPrefix(c, uri+"?") {
+			return true
+		}
+	}
+	return false
+}
+
+// getConfig 获取 running / candidate 等数据存储的配置
+func (s *netconfSession) getConfig(ctx context.Context, source string) ([]byte, error) {
+	return s.rpc(ctx, "<get-config><source><"+source+"/></source></get-config>")
+}
+
+// get 按子树过滤获取运行状态数据
+func (s *netconfSession) get(ctx context.Context, filter string) ([]byte, error) {
+	if filter == "" {
+		return s.rpc(ctx, "<get/>")
+	}
+	return s.rpc(ctx, `<get><filter type="subtree">`+filter+`</filter></get>`)
+}
+
+// rpc 执行操作并返回 <data> 的内容, error 级别的 rpc-error 作为错误返回 (warning 忽略)
+func (s *netconfSession) rpc(ctx context.Context, operation string) ([]byte, error) {
+	s.msgID++
+	id := strconv.Itoa(s.msgID)
+	msg := xml.Header + `<rpc message-id="` + id + `" xmlns="` + netconfNamespace + `">` + operation + `</rpc>`
+
+	raw, err := s.exchange(ctx, []byte(msg))
+	if err != nil {
+		return nil, err
+	}
+	var reply netconfReply
+	if err := xml.Unmarshal(raw, &reply); err != nil {
+		return nil, fmt.Errorf("parse rpc-reply: %w", err)
+	}
+	if reply.MessageID != "" && reply.MessageID != id {
+		return nil, fmt.Errorf("rpc-reply message-id %s does not match request %s", reply.MessageID, id)
+	}
+	for _, e := range reply.Errors {
+		if e.Severity != "warning" {
+			return nil, e
+		}
+	}
+	return bytes.TrimSpace(reply.Data.Inner), nil
+}
+
+// Close 尽量通知设备关闭会话后断开
+func (s *netconfSession) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	s.rpc(ctx, "<close-session/>")
+	s.w.Close()
+	return s.session.Close()
+}
+
+// exchange 发送一条消息并读取一条响应, 超时或 ctx 取消时关闭会话以中断读取
+func (s *netconfSession) exchange(ctx context.Context, msg []byte) ([]byte, error) {
+	type result struct {
+		data []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		if err := s.writeMessage(msg); err != nil {
+			done <- result{err: err}
+			return
+		}
+		data, err := s.readMessage()
+		done <- result{data, err}
+	}()
+
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.data, r.err
+	case <-ctx.Done():
+		s.session.Close()
+		return nil, ctx.Err()
+	case <-timer.C:
+		s.session.Close()
+		return nil, errors.New("timed out waiting for netconf reply")
+	}
+}
+
+func (s *netconfSession) writeMessage(msg []byte) error {
+	var err error
+	if s.chunked {
+		_, err = fmt.Fprintf(s.w, "\n#%d\n%s\n##\n", len(msg), msg)
+	} else {
+		_, err = s.w.Write(append(msg, netconfEOM...))
+	}
+	return err
+}
+
+func (s *netconfSession) readMessage() ([]byte, error) {
+	if s.chunked {
+		return readChunkedMessage(s.r)
+	}
+	return readEOMMessage(s.r)
+}
+
+// readEOMMessage base:1.0 分帧, 以 ]]>]]> 结束
+func readEOMMessage(r *bufio.Reader) ([]byte, error) {
+	var buf []byte
+	for {
+		part, err := r.ReadSlice('>')
+		buf = append(buf, part...)
+		if bytes.HasSuffix(buf, []byte(netconfEOM)) {
+			return buf[:len(buf)-len(netconfEOM)], nil
+		}
+		if len(buf) > netconfMaxMessage {
+			return nil, errors.New("netconf message too large")
+		}
+		if err != nil && !errors.Is(err, bufio.ErrBufferFull) {
+			return nil, err
+		}
+	}
+}
+
+// readChunkedMessage base:1.1 分块分帧: \n#<size>\n<data> ... \n##\n
+func readChunkedMessage(r *bufio.Reader) ([]byte, error) {
+	var buf []byte
+	for {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		if header == "\n" && len(buf) == 0 {
+			// 块头前的换行
+			if header, err = r.ReadString('\n'); err != nil {
+				return nil, err
+			}
+		}
+		header = strings.TrimSpace(header)
+		if header == "##" {
+			return buf, nil
+		}
+		if !strings.HasPrefix(header, "#") {
+			return nil, fmt.Errorf("invalid netconf chunk header %q", header)
+		}
+		size, err := strconv.ParseUint(header[1:], 10, 32)
+		if err != nil || size == 0 {
+			return nil, fmt.Errorf("invalid netconf chunk size %q", header)
+		}
+		if len(buf)+int(size) > netconfMaxMessage {
+			return nil, errors.New("netconf message too large")
+		}
+		chunk := make([]byte, size)
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return nil, err
+		}
+		buf = append(buf, chunk...)
+
+		// 块数据后紧跟下一个块头的换行
+		if b, err := r.ReadByte(); err != nil {
+			return nil, err
+		} else if b != '\n' {
+			return nil, fmt.Errorf("invalid netconf chunk delimiter %q", b)
+		}
+	}
+}
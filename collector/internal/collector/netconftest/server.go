@@ -0,0 +1,400 @@
+// Package netconftest 提供进程内 NETCONF over SSH 服务端, 用于在没有真实设备时验证采集器的能力交换、配置获取与状态映射
+package netconftest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	namespace = "urn:ietf:params:xml:ns:netconf:base:1.0"
+	base10    = "urn:ietf:params:netconf:base:1.0"
+	base11    = "urn:ietf:params:netconf:base:1.1"
+	candidate = "urn:ietf:params:netconf:capability:candidate:1.0"
+	eom       = "]]>]]>"
+)
+
+// Server 进程内 NETCONF 服务端
+// get-config 返回 SetRunning / SetCandidate 指定的配置, get 按过滤的顶层元素从 SetState 的数据中选取
+type Server struct {
+	// Username / Password 非空时校验登录凭据
+	Username string
+	Password string
+	// Base10Only 为 true 时不声明 base:1.1, 始终使用 ]]>]]> 分帧
+	Base10Only bool
+
+	listener net.Listener
+	config   *ssh.ServerConfig
+
+	mu           sync.Mutex
+	capabilities []string
+	running      string
+	candidate    string
+	state        []element
+	rpcs         []string
+	sessions     int
+}
+
+// element 状态数据的顶层元素
+type element struct {
+	name xml.Name
+	raw  string
+}
+
+// New 在 127.0.0.1 随机端口启动服务端, capabilities 为附加声明的能力 (如模块命名空间)
+func New(capabilities ...string) (*Server, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return nil, err
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{listener: lis, capabilities: capabilities}
+	s.config = &ssh.ServerConfig{PasswordCallback: s.authenticate}
+	s.config.AddHostKey(signer)
+	go s.serve()
+	return s, nil
+}
+
+// Addr 监听地址
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Port 监听端口, 用于 netconf 探针的 port 参数
+func (s *Server) Port() int {
+	_, port, _ := net.SplitHostPort(s.Addr())
+	n, _ := strconv.Atoi(port)
+	return n
+}
+
+// SetRunning 设置 running 数据存储的配置 (<data> 的内容)
+func (s *Server) SetRunning(config string) {
+	s.mu.Lock()
+	s.running = config
+	s.mu.Unlock()
+}
+
+// SetCandidate 设置 candidate 配置, 非空时声明 :candidate 能力
+func (s *Server) SetCandidate(config string) {
+	s.mu.Lock()
+	s.candidate = config
+	s.mu.Unlock()
+}
+
+// SetState 设置 get 返回的运行状态数据, 可包含多个带命名空间的顶层元素
+func (s *Server) SetState(data string) error {
+	elements, err := splitElements(data)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.state = elements
+	s.mu.Unlock()
+	return nil
+}
+
+// RPCs 已收到的操作 (rpc 的内容)
+func (s *Server) RPCs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.rpcs...)
+}
+
+// Sessions 已建立的 NETCONF 会话数
+func (s *Server) Sessions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessions
+}
+
+// Close 停止服务端
+func (s *Server) Close() error {
+	return s.listener.Close()
+}
+
+func (s *Server) authenticate(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+	if (s.Username != "" && conn.User() != s.Username) || (s.Password != "" && string(password) != s.Password) {
+		return nil, errors.New("invalid credentials")
+	}
+	return nil, nil
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer conn.Close()
+	sshConn, chans, reqs, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		return
+	}
+	defer sshConn.Close()
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "only session channels are supported")
+			continue
+		}
+		ch, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go s.handleChannel(ch, requests)
+	}
+}
+
+// handleChannel 仅接受 netconf 子系统请求
+func (s *Server) handleChannel(ch ssh.Channel, requests <-chan *ssh.Request) {
+	defer ch.Close()
+	for req := range requests {
+		if req.Type == "subsystem" && len(req.Payload) > 4 && string(req.Payload[4:]) == "netconf" {
+			req.Reply(true, nil)
+			go ssh.DiscardRequests(requests)
+			s.session(ch)
+			return
+		}
+		req.Reply(false, nil)
+	}
+}
+
+type rpc struct {
+	MessageID string `xml:"message-id,attr"`
+	Inner     []byte `xml:",innerxml"`
+}
+
+type operation struct {
+	XMLName xml.Name
+	Source  struct {
+		Running   *struct{} `xml:"running"`
+		Candidate *struct{} `xml:"candidate"`
+	} `xml:"source"`
+	Filter *struct {
+		Inner []byte `xml:",innerxml"`
+	} `xml:"filter"`
+}
+
+func (s *Server) session(ch ssh.Channel) {
+	s.mu.Lock()
+	s.sessions++
+	id := s.sessions
+	caps := []string{base10}
+	if !s.Base10Only {
+		caps = append(caps, base11)
+	}
+	if s.candidate != "" {
+		caps = append(caps, candidate)
+	}
+	caps = append(caps, s.capabilities...)
+	s.mu.Unlock()
+
+	var hello strings.Builder
+	hello.WriteString(xml.Header + `<hello xmlns="` + namespace + `"><capabilities>`)
+	for _, c := range caps {
+		hello.WriteString("<capability>")
+		xml.EscapeText(&hello, []byte(c))
+		hello.WriteString("</capability>")
+	}
+	fmt.Fprintf(&hello, "</capabilities><session-id>%d</session-id></hello>", id)
+	if _, err := io.WriteString(ch, hello.String()+eom); err != nil {
+		return
+	}
+
+	r := bufio.NewReader(ch)
+	clientHello, err := readEOM(r)
+	if err != nil {
+		return
+	}
+	chunked := !s.Base10Only && bytes.Contains(clientHello, []byte(base11))
+
+	for {
+		var msg []byte
+		if chunked {
+			msg, err = readChunked(r)
+		} else {
+			msg, err = readEOM(r)
+		}
+		if err != nil {
+			return
+		}
+
+		reply, closeSession := s.handle(msg)
+		if chunked {
+			_, err = fmt.Fprintf(ch, "\n#%d\n%s\n##\n", len(reply), reply)
+		} else {
+			_, err = io.WriteString(ch, reply+eom)
+		}
+		if err != nil || closeSession {
+			return
+		}
+	}
+}
+
+// handle 处理一条 rpc, 返回 rpc-reply 及是否关闭会话
+func (s *Server) handle(msg []byte) (string, bool) {
+	var req rpc
+	if err := xml.Unmarshal(msg, &req); err != nil {
+		return reply("", rpcError("rpc", "malformed-message", err.Error())), true
+	}
+	var op operation
+	if err := xml.Unmarshal(req.Inner, &op); err != nil {
+		return reply(req.MessageID, rpcError("rpc", "malformed-message", err.Error())), false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rpcs = append(s.rpcs, strings.TrimSpace(string(req.Inner)))
+
+	switch op.XMLName.Local {
+	case "get-config":
+		switch {
+		case op.Source.Running != nil:
+			return reply(req.MessageID, "<data>"+s.running+"</data>"), false
+		case op.Source.Candidate != nil && s.candidate != "":
+			return reply(req.MessageID, "<data>"+s.candidate+"</data>"), false
+		}
+		return reply(req.MessageID, rpcError("protocol", "invalid-value", "unsupported source")), false
+	case "get":
+		var filter []element
+		if op.Filter != nil {
+			var err error
+			if filter, err = splitElements(string(op.Filter.Inner)); err != nil {
+				return reply(req.MessageID, rpcError("protocol", "malformed-message", err.Error())), false
+			}
+		}
+		var data strings.Builder
+		for _, e := range s.state {
+			if selected(e, filter) {
+				data.WriteString(e.raw)
+			}
+		}
+		return reply(req.MessageID, "<data>"+data.String()+"</data>"), false
+	case "close-session":
+		return reply(req.MessageID, "<ok/>"), true
+	}
+	return reply(req.MessageID, rpcError("protocol", "operation-not-supported", op.XMLName.Local+" is not supported")), false
+}
+
+// selected 子树过滤仅按顶层元素名称及命名空间选取
+func selected(e element, filter []element) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, f := range filter {
+		if f.name.Local == e.name.Local && (f.name.Space == "" || f.name.Space == e.name.Space) {
+			return true
+		}
+	}
+	return false
+}
+
+func reply(messageID, body string) string {
+	return xml.Header + `<rpc-reply xmlns="` + namespace + `" message-id="` + messageID + `">` + body + `</rpc-reply>`
+}
+
+func rpcError(errType, tag, message string) string {
+	var msg bytes.Buffer
+	xml.EscapeText(&msg, []byte(message))
+	return "<rpc-error><error-type>" + errType + "</error-type><error-tag>" + tag +
+		"</error-tag><error-severity>error</error-severity><error-message>" + msg.String() + "</error-message></rpc-error>"
+}
+
+// splitElements 切分顶层元素, 保留原始文本
+func splitElements(data string) ([]element, error) {
+	dec := xml.NewDecoder(strings.NewReader(data))
+	var elements []element
+	depth := 0
+	var start int64
+	var name xml.Name
+	for {
+		offset := dec.InputOffset()
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return elements, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				start, name = offset, t.Name
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+			if depth == 0 {
+				elements = append(elements, element{name: name, raw: data[start:dec.InputOffset()]})
+			}
+		}
+	}
+}
+
+func readEOM(r *bufio.Reader) ([]byte, error) {
+	var buf []byte
+	for {
+		part, err := r.ReadSlice('>')
+		buf = append(buf, part...)
+		if bytes.HasSuffix(buf, []byte(eom)) {
+			return buf[:len(buf)-len(eom)], nil
+		}
+		if err != nil && !errors.Is(err, bufio.ErrBufferFull) {
+			return nil, err
+		}
+	}
+}
+
+func readChunked(r *bufio.Reader) ([]byte, error) {
+	var buf []byte
+	for {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		header = strings.TrimSpace(header)
+		switch {
+		case header == "":
+			continue
+		case header == "##":
+			return buf, nil
+		case !strings.HasPrefix(header, "#"):
+			return nil, fmt.Errorf("invalid chunk header %q", header)
+		}
+		size, err := strconv.Atoi(header[1:])
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("invalid chunk size %q", header)
+		}
+		chunk := make([]byte, size)
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return nil, err
+		}
+		buf = append(buf, chunk...)
+	}
+}
@@ -0,0 +1,430 @@
+package collector
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// NetFlow v9 / IPFIX 信息元素 (IANA IPFIX Information Elements, 与 v9 字段类型编号一致)
+const (
+	ieOctetDeltaCount        = 1
+	iePacketDeltaCount       = 2
+	ieProtocolIdentifier     = 4
+	ieSourceTransportPort    = 7
+	ieSourceIPv4Address      = 8
+	ieIngressInterface       = 10
+	ieDestTransportPort      = 11
+	ieDestIPv4Address        = 12
+	ieEgressInterface        = 14
+	iePostOctetDeltaCount    = 23
+	iePostPacketDeltaCount   = 24
+	ieSourceIPv6Address      = 27
+	ieDestIPv6Address        = 28
+	ieSamplingInterval       = 34
+	ieSamplerRandomInterval  = 50
+	ieOctetTotalCount        = 85
+	iePacketTotalCount       = 86
+	ieSamplingPacketInterval = 305
+)
+
+// FlowSet / Set ID
+const (
+	v9TemplateSetID        = 0
+	v9OptionsTemplateSetID = 1
+	ipfixTemplateSetID     = 2
+	ipfixOptionsTemplateID = 3
+	minDataSetID           = 256
+)
+
+// errTemplateLimit 导出方模板数达到上限, 新模板被忽略 (同一报文中的其余集合照常处理)
+var errTemplateLimit = errors.New("template limit reached")
+
+// templateField 模板字段
+type templateField struct {
+	typ        uint16
+	length     uint16 // 65535 表示 IPFIX 变长字段
+	enterprise uint32
+}
+
+// flowTemplate 数据记录模板
+type flowTemplate struct {
+	fields  []templateField
+	options bool
+	updated time.Time
+}
+
+// templateKey 模板按 导出方+版本+观测域(source ID)+模板ID 缓存
+type templateKey struct {
+	exporter string
+	version  uint16
+	domain   uint32
+	id       uint16
+}
+
+// samplerKey 选项数据上报的采样率按 导出方+版本+观测域 记录
+type samplerKey struct {
+	exporter string
+	version  uint16
+	domain   uint32
+}
+
+// netflowDecoder NetFlow v5/v9 与 IPFIX 解码器
+type netflowDecoder struct {
+	mu           sync.Mutex
+	timeout      time.Duration
+	maxTemplates int // 每个导出方缓存的模板上限
+	templates    map[templateKey]*flowTemplate
+	perExporter  map[string]int
+	sampling     map[samplerKey]uint64
+}
+
+func newNetFlowDecoder(timeout time.Duration, maxTemplates int) *netflowDecoder {
+	return &netflowDecoder{
+		timeout:      timeout,
+		maxTemplates: maxTemplates,
+		templates:    make(map[templateKey]*flowTemplate),
+		perExporter:  make(map[string]int),
+		sampling:     make(map[samplerKey]uint64),
+	}
+}
+
+// deleteTemplate 删除模板并更新导出方计数, 调用方持有 d.mu
+func (d *netflowDecoder) deleteTemplate(key templateKey) {
+	if _, ok := d.templates[key]; !ok {
+		return
+	}
+	delete(d.templates, key)
+	if d.perExporter[key.exporter]--; d.perExporter[key.exporter] <= 0 {
+		delete(d.perExporter, key.exporter)
+	}
+}
+
+// expire 清理过期模板
+func (d *netflowDecoder) expire(now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for key, t := range d.templates {
+		if now.Sub(t.updated) > d.timeout {
+			d.deleteTemplate(key)
+		}
+	}
+}
+
+// decode 按版本号解码报文
+func (d *netflowDecoder) decode(exporter string, data []byte, now time.Time) ([]flowRecord, error) {
+	if len(data) < 4 {
+		return nil, errors.New("packet too short")
+	}
+	switch version := binary.BigEndian.Uint16(data); version {
+	case 5:
+		return decodeNetFlowV5(data)
+	case 9:
+		return d.decodeV9(exporter, data, now)
+	case 10:
+		return d.decodeIPFIX(exporter, data, now)
+	default:
+		return nil, fmt.Errorf("unsupported flow version %d", version)
+	}
+}
+
+// decodeNetFlowV5 固定格式: 24字节头 + 48字节记录
+func decodeNetFlowV5(data []byte) ([]flowRecord, error) {
+	const headerLen, recordLen = 24, 48
+	if len(data) < headerLen {
+		return nil, errors.New("netflow v5: short header")
+	}
+	count := int(binary.BigEndian.Uint16(data[2:]))
+	if len(data) < headerLen+count*recordLen {
+		return nil, errors.New("netflow v5: truncated records")
+	}
+
+	// 高2位为采样模式, 低14位为采样间隔
+	rate := uint64(binary.BigEndian.Uint16(data[22:]) & 0x3fff)
+	if rate == 0 {
+		rate = 1
+	}
+
+	records := make([]flowRecord, 0, count)
+	for i := 0; i < count; i++ {
+		r := data[headerLen+i*recordLen:]
+		records = append(records, flowRecord{
+			SrcAddr:  net.IP(append([]byte(nil), r[0:4]...)),
+			DstAddr:  net.IP(append([]byte(nil), r[4:8]...)),
+			InIf:     uint32(binary.BigEndian.Uint16(r[12:])),
+			OutIf:    uint32(binary.BigEndian.Uint16(r[14:])),
+			Packets:  uint64(binary.BigEndian.Uint32(r[16:])) * rate,
+			Bytes:    uint64(binary.BigEndian.Uint32(r[20:])) * rate,
+			SrcPort:  binary.BigEndian.Uint16(r[32:]),
+			DstPort:  binary.BigEndian.Uint16(r[34:]),
+			Protocol: r[38],
+		})
+	}
+	return records, nil
+}
+
+// decodeV9 20字节头 (含 source ID) + FlowSet 列表
+func (d *netflowDecoder) decodeV9(exporter string, data []byte, now time.Time) ([]flowRecord, error) {
+	const headerLen = 20
+	if len(data) < headerLen {
+		return nil, errors.New("netflow v9: short header")
+	}
+	domain := binary.BigEndian.Uint32(data[16:])
+	return d.decodeSets(exporter, 9, domain, data[headerLen:], now)
+}
+
+// decodeIPFIX 16字节头 (含 observation domain) + Set 列表
+func (d *netflowDecoder) decodeIPFIX(exporter string, data []byte, now time.Time) ([]flowRecord, error) {
+	const headerLen = 16
+	if len(data) < headerLen {
+		return nil, errors.New("ipfix: short header")
+	}
+	length := int(binary.BigEndian.Uint16(data[2:]))
+	if length < headerLen || length > len(data) {
+		return nil, errors.New("ipfix: invalid message length")
+	}
+	domain := binary.BigEndian.Uint32(data[12:])
+	return d.decodeSets(exporter, 10, domain, data[headerLen:length], now)
+}
+
+// decodeSets 依次处理模板与数据集; 模板未知的数据集跳过 (等待模板到达)
+func (d *netflowDecoder) decodeSets(exporter string, version uint16, domain uint32, data []byte, now time.Time) ([]flowRecord, error) {
+	var records []flowRecord
+	var limitErr error
+	for len(data) >= 4 {
+		setID := binary.BigEndian.Uint16(data)
+		length := int(binary.BigEndian.Uint16(data[2:]))
+		if length < 4 || length > len(data) {
+			return records, fmt.Errorf("invalid set length %d", length)
+		}
+		body := data[4:length]
+		data = data[length:]
+
+		var err error
+		switch {
+		case version == 9 && setID == v9TemplateSetID, version == 10 && setID == ipfixTemplateSetID:
+			err = d.parseTemplates(exporter, version, domain, body, false, now)
+		case version == 9 && setID == v9OptionsTemplateSetID, version == 10 && setID == ipfixOptionsTemplateID:
+			err = d.parseTemplates(exporter, version, domain, body, true, now)
+		case setID >= minDataSetID:
+			records = append(records, d.parseData(exporter, version, domain, setID, body)...)
+		}
+		if errors.Is(err, errTemplateLimit) {
+			limitErr = err
+		} else if err != nil {
+			return records, err
+		}
+	}
+	return records, limitErr
+}
+
+// parseTemplates 解析 (选项)模板集
+func (d *netflowDecoder) parseTemplates(exporter string, version uint16, domain uint32, body []byte, options bool, now time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var dropped int
+	for len(body) >= 4 {
+		id := binary.BigEndian.Uint16(body)
+		var count int
+		var headerLen int
+
+		switch {
+		case options && version == 9:
+			// template ID, option scope length(字节), option length(字节)
+			if len(body) < 6 {
+				return errors.New("netflow v9: short options template")
+			}
+			count = (int(binary.BigEndian.Uint16(body[2:])) + int(binary.BigEndian.Uint16(body[4:]))) / 4
+			headerLen = 6
+		case options:
+			// template ID, field count, scope field count
+			if len(body) < 6 {
+				return errors.New("ipfix: short options template")
+			}
+			count = int(binary.BigEndian.Uint16(body[2:]))
+			headerLen = 6
+		default:
+			count = int(binary.BigEndian.Uint16(body[2:]))
+			headerLen = 4
+		}
+		body = body[headerLen:]
+
+		key := templateKey{exporter, version, domain, id}
+		if version == 10 && count == 0 {
+			// IPFIX 模板撤销; 模板ID为集合ID时撤销该观测域的全部模板
+			if id == ipfixTemplateSetID || id == ipfixOptionsTemplateID {
+				for k := range d.templates {
+					if k.exporter == exporter && k.version == version && k.domain == domain {
+						d.deleteTemplate(k)
+					}
+				}
+			}
+			d.deleteTemplate(key)
+			continue
+		}
+		if id < minDataSetID {
+			// v9/IPFIX 集合末尾的填充
+			break
+		}
+
+		fields := make([]templateField, 0, count)
+		for i := 0; i < count; i++ {
+			if len(body) < 4 {
+				return errors.New("truncated template")
+			}
+			f := templateField{typ: binary.BigEndian.Uint16(body), length: binary.BigEndian.Uint16(body[2:])}
+			body = body[4:]
+			if version == 10 && f.typ&0x8000 != 0 {
+				if len(body) < 4 {
+					return errors.New("truncated enterprise field")
+				}
+				f.typ &^= 0x8000
+				f.enterprise = binary.BigEndian.Uint32(body)
+				body = body[4:]
+			}
+			fields = append(fields, f)
+		}
+		if _, ok := d.templates[key]; !ok {
+			if d.maxTemplates > 0 && d.perExporter[exporter] >= d.maxTemplates {
+				dropped++
+				continue
+			}
+			d.perExporter[exporter]++
+		}
+		d.templates[key] = &flowTemplate{fields: fields, options: options, updated: now}
+	}
+	if dropped > 0 {
+		return fmt.Errorf("%w: exporter %s has %d templates, dropped %d", errTemplateLimit, exporter, d.maxTemplates, dropped)
+	}
+	return nil
+}
+
+// parseData 按模板解码数据集
+func (d *netflowDecoder) parseData(exporter string, version uint16, domain uint32, setID uint16, body []byte) []flowRecord {
+	d.mu.Lock()
+	tmpl := d.templates[templateKey{exporter, version, domain, setID}]
+	sk := samplerKey{exporter, version, domain}
+	rate := d.sampling[sk]
+	d.mu.Unlock()
+
+	if tmpl == nil || len(tmpl.fields) == 0 {
+		return nil
+	}
+
+	var records []flowRecord
+	for len(body) > 0 {
+		values, n, ok := readDataRecord(tmpl.fields, body)
+		if !ok || n == 0 {
+			break // 剩余为填充
+		}
+		body = body[n:]
+
+		if tmpl.options {
+			if v := samplingRate(values); v > 0 {
+				d.mu.Lock()
+				d.sampling[sk] = v
+				d.mu.Unlock()
+				rate = v
+			}
+			continue
+		}
+		records = append(records, buildFlowRecord(values, rate))
+	}
+	return records
+}
+
+// readDataRecord 读取一条数据记录, 返回 字段类型->原始值 及占用字节数
+func readDataRecord(fields []templateField, body []byte) (map[uint16][]byte, int, bool) {
+	values := make(map[uint16][]byte, len(fields))
+	offset := 0
+	for _, f := range fields {
+		length := int(f.length)
+		if f.length == 65535 {
+			// IPFIX 变长编码: 1字节长度, 255 表示后跟2字节长度
+			if offset >= len(body) {
+				return nil, 0, false
+			}
+			length = int(body[offset])
+			offset++
+			if length == 255 {
+				if offset+2 > len(body) {
+					return nil, 0, false
+				}
+				length = int(binary.BigEndian.Uint16(body[offset:]))
+				offset += 2
+			}
+		}
+		if offset+length > len(body) {
+			return nil, 0, false
+		}
+		if f.enterprise == 0 {
+			if _, exists := values[f.typ]; !exists {
+				values[f.typ] = body[offset : offset+length]
+			}
+		}
+		offset += length
+	}
+	return values, offset, true
+}
+
+// buildFlowRecord 将字段映射为流记录; 记录自带采样间隔时优先使用
+func buildFlowRecord(values map[uint16][]byte, rate uint64) flowRecord {
+	if v := samplingRate(values); v > 0 {
+		rate = v
+	}
+	if rate == 0 {
+		rate = 1
+	}
+
+	rec := flowRecord{
+		SrcPort:  uint16(beUint(values[ieSourceTransportPort])),
+		DstPort:  uint16(beUint(values[ieDestTransportPort])),
+		Protocol: uint8(beUint(values[ieProtocolIdentifier])),
+		InIf:     uint32(beUint(values[ieIngressInterface])),
+		OutIf:    uint32(beUint(values[ieEgressInterface])),
+	}
+	if v, ok := values[ieSourceIPv4Address]; ok && len(v) == net.IPv4len {
+		rec.SrcAddr = net.IP(append([]byte(nil), v...))
+	} else if v, ok := values[ieSourceIPv6Address]; ok && len(v) == net.IPv6len {
+		rec.SrcAddr = net.IP(append([]byte(nil), v...))
+	}
+	if v, ok := values[ieDestIPv4Address]; ok && len(v) == net.IPv4len {
+		rec.DstAddr = net.IP(append([]byte(nil), v...))
+	} else if v, ok := values[ieDestIPv6Address]; ok && len(v) == net.IPv6len {
+		rec.DstAddr = net.IP(append([]byte(nil), v...))
+	}
+
+	rec.Bytes = firstUint(values, ieOctetDeltaCount, iePostOctetDeltaCount, ieOctetTotalCount) * rate
+	rec.Packets = firstUint(values, iePacketDeltaCount, iePostPacketDeltaCount, iePacketTotalCount) * rate
+	return rec
+}
+
+// samplingRate 读取采样间隔字段
+func samplingRate(values map[uint16][]byte) uint64 {
+	return firstUint(values, ieSamplingInterval, ieSamplerRandomInterval, ieSamplingPacketInterval)
+}
+
+func firstUint(values map[uint16][]byte, types ...uint16) uint64 {
+	for _, t := range types {
+		if v, ok := values[t]; ok {
+			return beUint(v)
+		}
+	}
+	return 0
+}
+
+// beUint 读取不超过8字节的大端无符号整数
+func beUint(b []byte) uint64 {
+	if len(b) > 8 {
+		b = b[len(b)-8:]
+	}
+	var v uint64
+	for _, x := range b {
+		v = v<<8 | uint64(x)
+	}
+	return v
+}
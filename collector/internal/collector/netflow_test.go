@@ -0,0 +1,262 @@
+package collector
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func be16(v uint16) []byte { return binary.BigEndian.AppendUint16(nil, v) }
+func be32(v uint32) []byte { return binary.BigEndian.AppendUint32(nil, v) }
+func be64(v uint64) []byte { return binary.BigEndian.AppendUint64(nil, v) }
+
+func cat(parts ...[]byte) []byte {
+	var b []byte
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
+
+// flowSet FlowSet / Set: ID + 长度 + 内容
+func flowSet(id uint16, body ...[]byte) []byte {
+	b := cat(body...)
+	return cat(be16(id), be16(uint16(4+len(b))), b)
+}
+
+// tmplField 模板字段, enterprise 非零时写入企业号 (仅 IPFIX)
+type tmplField struct {
+	typ, length uint16
+	enterprise  uint32
+}
+
+func flowTemplateRecord(id uint16, fields ...tmplField) []byte {
+	b := cat(be16(id), be16(uint16(len(fields))))
+	for _, f := range fields {
+		if f.enterprise != 0 {
+			b = cat(b, be16(f.typ|0x8000), be16(f.length), be32(f.enterprise))
+		} else {
+			b = cat(b, be16(f.typ), be16(f.length))
+		}
+	}
+	return b
+}
+
+func netflowV9Packet(sourceID uint32, sets ...[]byte) []byte {
+	return cat(be16(9), be16(uint16(len(sets))), be32(1000), be32(1700000000), be32(1), be32(sourceID), cat(sets...))
+}
+
+func ipfixPacket(domain uint32, sets ...[]byte) []byte {
+	body := cat(sets...)
+	return cat(be16(10), be16(uint16(16+len(body))), be32(1700000000), be32(1), be32(domain), body)
+}
+
+// v4Flow 模板 256: 源/目的地址、端口、协议、入/出接口、字节/包数
+var v4FlowFields = []tmplField{
+	{typ: ieSourceIPv4Address, length: 4},
+	{typ: ieDestIPv4Address, length: 4},
+	{typ: ieSourceTransportPort, length: 2},
+	{typ: ieDestTransportPort, length: 2},
+	{typ: ieProtocolIdentifier, length: 1},
+	{typ: ieIngressInterface, length: 4},
+	{typ: ieEgressInterface, length: 4},
+	{typ: ieOctetDeltaCount, length: 8},
+	{typ: iePacketDeltaCount, length: 4},
+}
+
+func v4FlowData(src, dst string, sport, dport uint16, proto uint8, in, out uint32, bytes uint64, pkts uint32) []byte {
+	return cat(net.ParseIP(src).To4(), net.ParseIP(dst).To4(), be16(sport), be16(dport), []byte{proto},
+		be32(in), be32(out), be64(bytes), be32(pkts))
+}
+
+func TestNetFlowV5(t *testing.T) {
+	record := func(src, dst string, in, out uint16, pkts, bytes uint32, sport, dport uint16, proto uint8) []byte {
+		r := make([]byte, 48)
+		copy(r[0:], net.ParseIP(src).To4())
+		copy(r[4:], net.ParseIP(dst).To4())
+		binary.BigEndian.PutUint16(r[12:], in)
+		binary.BigEndian.PutUint16(r[14:], out)
+		binary.BigEndian.PutUint32(r[16:], pkts)
+		binary.BigEndian.PutUint32(r[20:], bytes)
+		binary.BigEndian.PutUint16(r[32:], sport)
+		binary.BigEndian.PutUint16(r[34:], dport)
+		r[38] = proto
+		return r
+	}
+	// 采样模式 01, 间隔 100
+	header := cat(be16(5), be16(2), be32(1000), be32(1700000000), be32(0), be32(1), []byte{0, 0}, be16(0x4000|100))
+	packet := cat(header,
+		record("10.0.0.1", "10.0.0.2", 1, 2, 3, 1500, 51000, 443, 6),
+		record("10.0.0.3", "10.0.0.4", 5, 6, 1, 80, 53, 40000, 17))
+
+	d := newNetFlowDecoder(time.Minute, 0)
+	records, err := d.decode("192.0.2.1", packet, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("records = %+v", records)
+	}
+	r := records[0]
+	if r.SrcAddr.String() != "10.0.0.1" || r.DstAddr.String() != "10.0.0.2" || r.InIf != 1 || r.OutIf != 2 ||
+		r.SrcPort != 51000 || r.DstPort != 443 || r.Protocol != 6 || r.Packets != 300 || r.Bytes != 150000 {
+		t.Errorf("record = %+v", r)
+	}
+	if records[1].Protocol != 17 || records[1].Bytes != 8000 {
+		t.Errorf("record = %+v", records[1])
+	}
+
+	if _, err := d.decode("192.0.2.1", packet[:len(packet)-1], time.Now()); err == nil {
+		t.Error("truncated v5 packet decoded")
+	}
+}
+
+func TestNetFlowV9Templates(t *testing.T) {
+	const exporter = "192.0.2.1"
+	now := time.Now()
+	d := newNetFlowDecoder(time.Minute, 0)
+	data := flowSet(256, v4FlowData("10.0.0.1", "10.0.0.2", 1234, 80, 6, 3, 4, 1000, 10))
+
+	// 数据先于模板到达: 跳过
+	records, err := d.decode(exporter, netflowV9Packet(1, data), now)
+	if err != nil || len(records) != 0 {
+		t.Fatalf("data before template: records = %+v, err = %v", records, err)
+	}
+
+	// 模板与数据在同一报文中
+	records, err = d.decode(exporter, netflowV9Packet(1, flowSet(v9TemplateSetID, flowTemplateRecord(256, v4FlowFields...)), data), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("records = %+v", records)
+	}
+	r := records[0]
+	if r.SrcAddr.String() != "10.0.0.1" || r.DstAddr.String() != "10.0.0.2" || r.SrcPort != 1234 || r.DstPort != 80 ||
+		r.Protocol != 6 || r.InIf != 3 || r.OutIf != 4 || r.Bytes != 1000 || r.Packets != 10 {
+		t.Errorf("record = %+v", r)
+	}
+
+	// 模板按 source ID 区分
+	if records, _ := d.decode(exporter, netflowV9Packet(2, data), now); len(records) != 0 {
+		t.Errorf("template leaked to another source ID: %+v", records)
+	}
+
+	// 选项模板: scope 为 system (4字节), 选项为 samplingInterval
+	options := flowSet(v9OptionsTemplateSetID, be16(257), be16(4), be16(4), be16(1), be16(4), be16(ieSamplingInterval), be16(4))
+	optionsData := flowSet(257, be32(0), be32(50))
+	if _, err := d.decode(exporter, netflowV9Packet(1, options, optionsData), now); err != nil {
+		t.Fatal(err)
+	}
+	records, _ = d.decode(exporter, netflowV9Packet(1, data), now)
+	if len(records) != 1 || records[0].Bytes != 50000 || records[0].Packets != 500 {
+		t.Errorf("sampled records = %+v", records)
+	}
+
+	// 超时未刷新的模板过期
+	d.expire(now.Add(2 * time.Minute))
+	if records, _ := d.decode(exporter, netflowV9Packet(1, data), now); len(records) != 0 {
+		t.Errorf("expired template still used: %+v", records)
+	}
+}
+
+func TestNetFlowV9OptionsLengthOverflow(t *testing.T) {
+	// scope 长度 0xfffc + 选项长度 8: 按 uint16 相加会回绕为 4 字节 (1 个字段)
+	options := flowSet(v9OptionsTemplateSetID, be16(300), be16(0xfffc), be16(8), be16(ieSamplingInterval), be16(4))
+	d := newNetFlowDecoder(time.Minute, 0)
+	if _, err := d.decode("192.0.2.1", netflowV9Packet(1, options), time.Now()); err == nil {
+		t.Error("oversized options template accepted")
+	}
+	if len(d.templates) != 0 {
+		t.Errorf("templates = %+v", d.templates)
+	}
+}
+
+func TestIPFIXTemplates(t *testing.T) {
+	const exporter = "192.0.2.1"
+	now := time.Now()
+	d := newNetFlowDecoder(time.Minute, 0)
+
+	// 企业字段 (含与标准字段同号的字段及变长字段) 跳过
+	tmpl := flowSet(ipfixTemplateSetID, flowTemplateRecord(400,
+		tmplField{typ: ieSourceIPv4Address, length: 4},
+		tmplField{typ: ieDestIPv4Address, length: 4, enterprise: 9},
+		tmplField{typ: ieDestIPv4Address, length: 4},
+		tmplField{typ: 100, length: 65535, enterprise: 29305},
+		tmplField{typ: ieOctetDeltaCount, length: 8},
+		tmplField{typ: iePacketDeltaCount, length: 4},
+	))
+	data := flowSet(400,
+		net.ParseIP("10.0.0.1").To4(), net.ParseIP("172.16.0.1").To4(), net.ParseIP("10.0.0.2").To4(),
+		[]byte{3, 'a', 'b', 'c'}, be64(2000), be32(4),
+		// 数据集末尾的填充
+		[]byte{0, 0, 0})
+	records, err := d.decode(exporter, ipfixPacket(7, tmpl, data), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("records = %+v", records)
+	}
+	if r := records[0]; r.SrcAddr.String() != "10.0.0.1" || r.DstAddr.String() != "10.0.0.2" || r.Bytes != 2000 || r.Packets != 4 {
+		t.Errorf("record = %+v", r)
+	}
+
+	// 选项模板 (字段数 2, scope 字段数 1) 上报 samplingPacketInterval
+	options := flowSet(ipfixOptionsTemplateID, be16(401), be16(2), be16(1), be16(149), be16(4), be16(ieSamplingPacketInterval), be16(4))
+	optionsData := flowSet(401, be32(7), be32(10))
+	records, err = d.decode(exporter, ipfixPacket(7, options, optionsData, data), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Bytes != 20000 || records[0].Packets != 40 {
+		t.Errorf("sampled records = %+v", records)
+	}
+
+	// 模板撤销 (字段数 0)
+	withdraw := flowSet(ipfixTemplateSetID, be16(400), be16(0))
+	if records, _ := d.decode(exporter, ipfixPacket(7, withdraw, data), now); len(records) != 0 {
+		t.Errorf("withdrawn template still used: %+v", records)
+	}
+
+	// 报文长度与实际不符
+	packet := ipfixPacket(7, data)
+	if _, err := d.decode(exporter, packet[:len(packet)-4], now); err == nil {
+		t.Error("ipfix message with invalid length decoded")
+	}
+}
+
+func TestNetFlowTemplateLimit(t *testing.T) {
+	now := time.Now()
+	d := newNetFlowDecoder(time.Minute, 2)
+	templates := flowSet(v9TemplateSetID,
+		flowTemplateRecord(256, v4FlowFields...),
+		flowTemplateRecord(257, v4FlowFields...),
+		flowTemplateRecord(258, v4FlowFields...))
+	data := v4FlowData("10.0.0.1", "10.0.0.2", 1234, 80, 6, 3, 4, 1000, 10)
+
+	// 超出上限的模板被忽略, 同一报文中其余集合照常解码
+	records, err := d.decode("192.0.2.1", netflowV9Packet(1, templates, flowSet(256, data), flowSet(258, data)), now)
+	if !errors.Is(err, errTemplateLimit) {
+		t.Errorf("err = %v, want %v", err, errTemplateLimit)
+	}
+	if len(records) != 1 || len(d.templates) != 2 {
+		t.Errorf("records = %+v, templates = %d", records, len(d.templates))
+	}
+
+	// 刷新已缓存的模板不受上限影响, 其他导出方单独计数
+	if _, err := d.decode("192.0.2.1", netflowV9Packet(1, flowSet(v9TemplateSetID, flowTemplateRecord(256, v4FlowFields...))), now); err != nil {
+		t.Errorf("template refresh rejected: %v", err)
+	}
+	if _, err := d.decode("192.0.2.2", netflowV9Packet(1, templates), now); !errors.Is(err, errTemplateLimit) || len(d.templates) != 4 {
+		t.Errorf("second exporter: err = %v, templates = %d", err, len(d.templates))
+	}
+
+	// 过期后释放名额
+	d.expire(now.Add(2 * time.Minute))
+	if _, err := d.decode("192.0.2.1", netflowV9Packet(1, templates), now); !errors.Is(err, errTemplateLimit) || len(d.templates) != 2 {
+		t.Errorf("after expiry: err = %v, templates = %d", err, len(d.templates))
+	}
+}
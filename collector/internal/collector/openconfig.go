@@ -0,0 +1,485 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// gnmiIface 订阅得到的接口状态, 按接口名称跟踪
+// 只覆盖订阅实际推送过的字段, 其余字段保留 SNMP 采集结果
+type gnmiIface struct {
+	stats       IfStats
+	hasCounters bool
+	counters    bool // 本周期计数器有更新
+	updated     bool
+}
+
+// gnmiState 单个订阅会话累积的 OpenConfig 数据, 按 flushInterval 合并到设备样本
+type gnmiState struct {
+	ifaces      map[string]*gnmiIface
+	deleted     map[string]bool
+	cpus        map[string]float64 // cpu index / 组件名 -> 利用率
+	memPhysical float64
+	memUsed     float64
+	memFree     float64
+	dirty       bool
+	at          time.Time // 最近一次通知的时间戳
+}
+
+func newGNMIState() *gnmiState {
+	return &gnmiState{
+		ifaces:  make(map[string]*gnmiIface),
+		deleted: make(map[string]bool),
+		cpus:    make(map[string]float64),
+	}
+}
+
+// notification 处理一次通知, JSON 编码的子树展开为叶子节点
+func (s *gnmiState) notification(n *pb.Notification) {
+	if n.Timestamp > 0 {
+		s.at = time.Unix(0, n.Timestamp)
+	} else {
+		s.at = time.Now()
+	}
+	prefix := n.GetPrefix().GetElem()
+
+	for _, p := range n.Delete {
+		// 仅处理整个接口被删除
+		elems := joinElems(prefix, p.GetElem())
+		if names := elemNames(elems); len(names) == 2 && hasPrefix(names, "interfaces", "interface") {
+			if name := elems[1].GetKey()["name"]; name != "" {
+				delete(s.ifaces, name)
+				s.deleted[name] = true
+				s.dirty = true
+			}
+		}
+	}
+
+	for _, u := range n.Update {
+		elems := joinElems(prefix, u.GetPath().GetElem())
+		switch v := u.GetVal().GetValue().(type) {
+		case *pb.TypedValue_JsonIetfVal:
+			s.json(elems, v.JsonIetfVal)
+		case *pb.TypedValue_JsonVal:
+			s.json(elems, v.JsonVal)
+		default:
+			s.leaf(elems, typedValue(u.GetVal()))
+		}
+	}
+}
+
+func (s *gnmiState) json(elems []*pb.PathElem, data []byte) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return
+	}
+	s.walk(elems, v)
+}
+
+// walk 展开 JSON 子树, 列表元素按 name / index 生成键
+func (s *gnmiState) walk(elems []*pb.PathElem, v interface{}) {
+	switch node := v.(type) {
+	case map[string]interface{}:
+		for k, child := range node {
+			name := stripModule(k)
+			if list, ok := child.([]interface{}); ok {
+				for _, item := range list {
+					entry, ok := item.(map[string]interface{})
+					if !ok {
+						continue
+					}
+					key := make(map[string]string)
+					for _, field := range []string{"name", "index"} {
+						if kv, ok := entry[field]; ok {
+							key[field] = stripModule(jsonString(kv))
+							break
+						}
+					}
+					s.walk(appendElem(elems, &pb.PathElem{Name: name, Key: key}), entry)
+				}
+				continue
+			}
+			s.walk(appendElem(elems, &pb.PathElem{Name: name}), child)
+		}
+	default:
+		s.leaf(elems, node)
+	}
+}
+
+// leaf 将 OpenConfig 叶子映射到接口统计 / CPU / 内存
+func (s *gnmiState) leaf(elems []*pb.PathElem, v interface{}) {
+	names := elemNames(elems)
+	switch {
+	case hasPrefix(names, "interfaces", "interface", "state"):
+		name := elems[1].GetKey()["name"]
+		if name == "" || len(names) < 4 {
+			return
+		}
+		s.ifaceLeaf(name, names[3:], v)
+	case hasPrefix(names, "system", "cpus", "cpu", "state", "total", "instant"):
+		if f, ok := toFloat(v); ok {
+			s.cpus[elems[2].GetKey()["index"]] = f
+			s.dirty = true
+		}
+	case hasPrefix(names, "components", "component", "cpu", "utilization", "state", "instant"):
+		if f, ok := toFloat(v); ok {
+			s.cpus[elems[1].GetKey()["name"]] = f
+			s.dirty = true
+		}
+	case hasPrefix(names, "system", "memory", "state") && len(names) == 4:
+		f, ok := toFloat(v)
+		if !ok {
+			return
+		}
+		switch names[3] {
+		case "physical":
+			s.memPhysical = f
+		case "used":
+			s.memUsed = f
+		case "free":
+			s.memFree = f
+		default:
+			return
+		}
+		s.dirty = true
+	}
+}
+
+// iface 按名称获取或创建接口状态
+func (s *gnmiState) iface(name string) *gnmiIface {
+	iface, ok := s.ifaces[name]
+	if !ok {
+		iface = &gnmiIface{stats: IfStats{Name: name, HighCapacity: true}}
+		s.ifaces[name] = iface
+		delete(s.deleted, name)
+	}
+	return iface
+}
+
+func (s *gnmiState) ifaceLeaf(name string, leaf []string, v interface{}) {
+	iface := s.iface(name)
+	st := &iface.stats
+	if len(leaf) == 2 && leaf[0] == "counters" {
+		n, ok := toUint(v)
+		if !ok {
+			return
+		}
+		counter := map[string]*int64{
+			"in-octets":          &st.InBytes,
+			"out-octets":         &st.OutBytes,
+			"in-unicast-pkts":    &st.InUcastPkts,
+			"out-unicast-pkts":   &st.OutUcastPkts,
+			"in-multicast-pkts":  &st.InMulticastPkts,
+			"out-multicast-pkts": &st.OutMulticastPkts,
+			"in-broadcast-pkts":  &st.InBroadcastPkts,
+			"out-broadcast-pkts": &st.OutBroadcastPkts,
+			"in-discards":        &st.InDiscards,
+			"out-discards":       &st.OutDiscards,
+			"in-errors":          &st.InErrors,
+			"out-errors":         &st.OutErrors,
+		}[leaf[1]]
+		if counter == nil {
+			return
+		}
+		*counter = int64(n)
+		iface.hasCounters, iface.counters = true, true
+	} else if len(leaf) == 1 {
+		switch leaf[0] {
+		case "ifindex":
+			n, ok := toUint(v)
+			if !ok {
+				return
+			}
+			st.Index = int(n)
+		case "oper-status":
+			st.OperStatus = openconfigStatus(v)
+			st.Status = st.OperStatus
+		case "admin-status":
+			st.AdminStatus = openconfigStatus(v)
+		case "description":
+			st.Alias = jsonString(v)
+		default:
+			return
+		}
+	} else {
+		return
+	}
+	iface.updated = true
+	s.dirty = true
+}
+
+// cpuUsage 优先使用 index=ALL 的汇总值, 否则取各CPU平均
+func (s *gnmiState) cpuUsage() (float64, bool) {
+	if v, ok := s.cpus["ALL"]; ok {
+		return v, true
+	}
+	if len(s.cpus) == 0 {
+		return 0, false
+	}
+	var sum float64
+	for _, v := range s.cpus {
+		sum += v
+	}
+	return round2(sum / float64(len(s.cpus))), true
+}
+
+func (s *gnmiState) memoryUsage() (float64, bool) {
+	if s.memPhysical <= 0 {
+		return 0, false
+	}
+	used := s.memUsed
+	if used == 0 && s.memFree > 0 {
+		used = s.memPhysical - s.memFree
+	}
+	if used <= 0 {
+		return 0, false
+	}
+	return round2(used / s.memPhysical * 100), true
+}
+
+// applyGNMI 将累积的订阅数据合并到设备样本并发送
+func (c *Collector) applyGNMI(deviceID string, s *gnmiState) {
+	if !s.dirty {
+		return
+	}
+	s.dirty = false
+	now := s.at
+	updated, deleted := s.take()
+
+	c.pushSample(deviceID, now, func(sample *DeviceMetrics) bool {
+		return c.mergeOpenConfig(deviceID, now, sample, s, updated, deleted)
+	})
+}
+
+// take 取出自上次合并以来有更新的接口及被删除的接口
+func (s *gnmiState) take() ([]gnmiIface, map[string]bool) {
+	var updated []gnmiIface
+	for _, iface := range s.ifaces {
+		if iface.updated {
+			updated = append(updated, *iface)
+			iface.updated, iface.counters = false, false
+		}
+	}
+	deleted := s.deleted
+	s.deleted = make(map[string]bool)
+	return updated, deleted
+}
+
+// mergeOpenConfig 将 CPU / 内存及接口数据合并到 sample, 接口有变化时返回 true
+// 接口按名称与 SNMP 采集结果合并, 仅有 ifindex 的接口计算速率与跟踪状态
+func (c *Collector) mergeOpenConfig(deviceID string, now time.Time, sample *DeviceMetrics, s *gnmiState, updated []gnmiIface, deleted map[string]bool) bool {
+	if v, ok := s.cpuUsage(); ok {
+		sample.CPUUsage = v
+	}
+	if v, ok := s.memoryUsage(); ok {
+		sample.MemoryUsage = v
+	}
+	if len(updated) == 0 && len(deleted) == 0 {
+		return false
+	}
+
+	interfaces := make([]IfStats, 0, len(sample.Interfaces)+len(updated))
+	byName := make(map[string]int)
+	for _, ifs := range sample.Interfaces {
+		if deleted[ifs.Name] {
+			continue
+		}
+		byName[ifs.Name] = len(interfaces)
+		interfaces = append(interfaces, ifs)
+	}
+
+	for _, g := range updated {
+		i, ok := byName[g.stats.Name]
+		ifs := IfStats{Name: g.stats.Name}
+		if ok {
+			ifs = interfaces[i]
+		}
+		ifs = g.overlay(ifs)
+		if g.counters && ifs.Index > 0 {
+			tmp := []IfStats{ifs}
+			c.rates.apply(deviceID, 0, now, tmp)
+			ifs = tmp[0]
+		}
+		if ok {
+			interfaces[i] = ifs
+		} else {
+			byName[ifs.Name] = len(interfaces)
+			interfaces = append(interfaces, ifs)
+		}
+	}
+
+	sort.SliceStable(interfaces, func(i, j int) bool {
+		return interfaces[i].Index < interfaces[j].Index
+	})
+	sample.Interfaces = interfaces
+	return true
+}
+
+// overlay 将订阅推送过的字段覆盖到 prev
+func (g *gnmiIface) overlay(prev IfStats) IfStats {
+	st := g.stats
+	if st.Index > 0 {
+		prev.Index = st.Index
+	}
+	if st.OperStatus != "" {
+		prev.OperStatus, prev.Status = st.OperStatus, st.OperStatus
+	}
+	if st.AdminStatus != "" {
+		prev.AdminStatus = st.AdminStatus
+	}
+	if st.Alias != "" {
+		prev.Alias = st.Alias
+	}
+	if st.Speed > 0 {
+		prev.Speed = st.Speed
+	}
+	if g.hasCounters {
+		prev.HighCapacity, prev.pkts32 = true, 0
+		prev.InBytes, prev.OutBytes = st.InBytes, st.OutBytes
+		prev.InUcastPkts, prev.OutUcastPkts = st.InUcastPkts, st.OutUcastPkts
+		prev.InMulticastPkts, prev.OutMulticastPkts = st.InMulticastPkts, st.OutMulticastPkts
+		prev.InBroadcastPkts, prev.OutBroadcastPkts = st.InBroadcastPkts, st.OutBroadcastPkts
+		prev.InDiscards, prev.OutDiscards = st.InDiscards, st.OutDiscards
+		prev.InErrors, prev.OutErrors = st.InErrors, st.OutErrors
+	}
+	return prev
+}
+
+// openconfigStatus OpenConfig 枚举 (UP / LOWER_LAYER_DOWN ...) 转为 IF-MIB 名称
+func openconfigStatus(v interface{}) string {
+	s := strings.ToUpper(stripModule(jsonString(v)))
+	parts := strings.Split(strings.ToLower(s), "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// typedValue 取出 TypedValue 中的标量值
+func typedValue(v *pb.TypedValue) interface{} {
+	switch x := v.GetValue().(type) {
+	case *pb.TypedValue_UintVal:
+		return x.UintVal
+	case *pb.TypedValue_IntVal:
+		return x.IntVal
+	case *pb.TypedValue_DoubleVal:
+		return x.DoubleVal
+	case *pb.TypedValue_FloatVal:
+		return float64(x.FloatVal)
+	case *pb.TypedValue_StringVal:
+		return x.StringVal
+	case *pb.TypedValue_BoolVal:
+		return x.BoolVal
+	case *pb.TypedValue_DecimalVal:
+		return float64(x.DecimalVal.Digits) / math.Pow10(int(x.DecimalVal.Precision))
+	}
+	return nil
+}
+
+// toUint JSON_IETF 中 64 位整数编码为字符串
+func toUint(v interface{}) (uint64, bool) {
+	switch x := v.(type) {
+	case uint64:
+		return x, true
+	case int64:
+		return uint64(x), x >= 0
+	case float64:
+		return uint64(x), x >= 0
+	case json.Number:
+		return toUint(string(x))
+	case string:
+		n, err := strconv.ParseUint(x, 10, 64)
+		if err != nil {
+			f, ferr := strconv.ParseFloat(x, 64)
+			return uint64(f), ferr == nil && f >= 0
+		}
+		return n, true
+	}
+	return 0, false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case uint64:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case float64:
+		return x, true
+	case json.Number:
+		f, err := x.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(x, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func jsonString(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case json.Number:
+		return x.String()
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+// stripModule 去掉 JSON_IETF 的模块前缀, 如 openconfig-interfaces:interfaces
+func stripModule(s string) string {
+	if i := strings.LastIndexByte(s, ':'); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
+
+// joinElems 拼接前缀与路径, 去掉元素名中的模块前缀
+func joinElems(prefix, elems []*pb.PathElem) []*pb.PathElem {
+	out := make([]*pb.PathElem, 0, len(prefix)+len(elems))
+	for _, list := range [][]*pb.PathElem{prefix, elems} {
+		for _, e := range list {
+			out = append(out, &pb.PathElem{Name: stripModule(e.GetName()), Key: e.GetKey()})
+		}
+	}
+	return out
+}
+
+func appendElem(elems []*pb.PathElem, e *pb.PathElem) []*pb.PathElem {
+	return append(elems[:len(elems):len(elems)], e)
+}
+
+func elemNames(elems []*pb.PathElem) []string {
+	names := make([]string, len(elems))
+	for i, e := range elems {
+		names[i] = e.GetName()
+	}
+	return names
+}
+
+func hasPrefix(names []string, prefix ...string) bool {
+	if len(names) < len(prefix) {
+		return false
+	}
+	for i, p := range prefix {
+		if names[i] != p {
+			return false
+		}
+	}
+	return true
+}
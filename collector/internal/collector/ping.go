@@ -0,0 +1,135 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-ping/ping"
+	"github.com/netvis/collector/internal/config"
+)
+
+func init() {
+	RegisterProbe("ping", newPingProbe)
+}
+
+// pingProbe ICMP探测, 全部丢包时判定设备不可达
+// options 可覆盖全局 ping 配置 (count / timeout / interval / size / ttl / privileged)
+type pingProbe struct {
+	c     *Collector
+	name  string
+	types deviceTypeFilter
+	opts  config.PingConfig
+}
+
+func newPingProbe(c *Collector, cfg config.ProbeConfig) (Probe, error) {
+	opts := c.config.Ping
+	if err := cfg.DecodeOptions(&opts); err != nil {
+		return nil, fmt.Errorf("decode ping probe options: %w", err)
+	}
+	return &pingProbe{c: c, name: cfg.Name, types: newDeviceTypeFilter(cfg.DeviceTypes), opts: opts}, nil
+}
+
+func (p *pingProbe) Name() string { return p.name }
+
+func (p *pingProbe) Applicable(device Device) bool {
+	return p.types.match(device.Type)
+}
+
+func (p *pingProbe) Collect(ctx context.Context, device Device, sample *DeviceMetrics) error {
+	result, err := pingDevice(ctx, device.IP, p.opts)
+	if err != nil {
+		sample.PacketLoss = 100
+		return fmt.Errorf("%w: ping: %v", ErrUnreachable, err)
+	}
+
+	sample.Latency = result.Avg
+	sample.MinLatency = result.Min
+	sample.MaxLatency = result.Max
+	sample.StdDevRTT = result.StdDev
+	sample.Jitter = result.Jitter
+	sample.PacketLoss = result.PacketLoss
+
+	if result.Received == 0 {
+		return fmt.Errorf("%w: ping: no reply from %s", ErrUnreachable, device.IP)
+	}
+	return nil
+}
+
+// pingResult Ping统计 (时间单位: ms)
+type pingResult struct {
+	Sent       int
+	Received   int
+	PacketLoss float64 // %
+	Min        float64
+	Max        float64
+	Avg        float64
+	StdDev     float64
+	Jitter     float64
+}
+
+// pingDevice 执行Ping检测
+func pingDevice(ctx context.Context, ip string, cfg config.PingConfig) (pingResult, error) {
+	pinger, err := ping.NewPinger(ip)
+	if err != nil {
+		return pingResult{PacketLoss: 100}, err
+	}
+
+	pinger.Count = cfg.Count
+	pinger.Timeout = cfg.Timeout
+	if cfg.Interval > 0 {
+		pinger.Interval = cfg.Interval
+	}
+	if cfg.Size > 0 {
+		pinger.Size = cfg.Size
+	}
+	if cfg.TTL > 0 {
+		pinger.TTL = cfg.TTL
+	}
+	// 非特权模式使用UDP ICMP, 特权模式使用原始套接字
+	pinger.SetPrivileged(cfg.Privileged)
+
+	// 调度取消时提前结束
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			pinger.Stop()
+		case <-done:
+		}
+	}()
+
+	if err := pinger.Run(); err != nil {
+		return pingResult{PacketLoss: 100}, err
+	}
+
+	stats := pinger.Statistics()
+	return pingResult{
+		Sent:       stats.PacketsSent,
+		Received:   stats.PacketsRecv,
+		PacketLoss: stats.PacketLoss,
+		Min:        durationMs(stats.MinRtt),
+		Max:        durationMs(stats.MaxRtt),
+		Avg:        durationMs(stats.AvgRtt),
+		StdDev:     durationMs(stats.StdDevRtt),
+		Jitter:     rttJitter(stats.Rtts),
+	}, nil
+}
+
+// rttJitter 相邻RTT差值绝对值的平均 (ms), 少于两个样本时为0
+func rttJitter(rtts []time.Duration) float64 {
+	if len(rtts) < 2 {
+		return 0
+	}
+	var sum float64
+	for i := 1; i < len(rtts); i++ {
+		sum += math.Abs(durationMs(rtts[i] - rtts[i-1]))
+	}
+	return round2(sum / float64(len(rtts)-1))
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000.0
+}
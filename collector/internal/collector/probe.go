@@ -0,0 +1,131 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/netvis/collector/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrUnreachable 探针判定设备不可达时返回
+// 是否影响设备状态由可达性策略决定, 不参与判定的探针返回该错误时被忽略
+var ErrUnreachable = errors.New("device unreachable")
+
+// Probe 采集探针
+// 每个探针负责一类采集 (ping / snmp / tcp / http / 厂商私有...), 结果写入同一份 DeviceMetrics
+type Probe interface {
+	// Name 探针名称 (对应 config.yaml 中 probes[].name)
+	Name() string
+	// Applicable 判断探针是否适用于该设备
+	Applicable(device Device) bool
+	// Collect 执行采集并写入 sample
+	Collect(ctx context.Context, device Device, sample *DeviceMetrics) error
+}
+
+// ProbeFactory 探针构造函数, cfg 为 config.yaml 中该探针的配置
+type ProbeFactory func(c *Collector, cfg config.ProbeConfig) (Probe, error)
+
+// CheckResult 单项检测结果 (TCP端口、HTTP等)
+type CheckResult struct {
+	Probe   string  `json:"probe"`
+	Target  string  `json:"target"`
+	Success bool    `json:"success"`
+	Latency float64 `json:"latency"` // ms
+	Detail  string  `json:"detail,omitempty"`
+}
+
+var (
+	probeFactoriesMu sync.RWMutex
+	probeFactories   = make(map[string]ProbeFactory)
+)
+
+// RegisterProbe 注册探针类型, 通常在 init() 中调用
+// 自研探针只需在独立包中注册并在 main 中引入, 无需修改采集器代码
+func RegisterProbe(kind string, factory ProbeFactory) {
+	probeFactoriesMu.Lock()
+	defer probeFactoriesMu.Unlock()
+	if _, exists := probeFactories[kind]; exists {
+		panic(fmt.Sprintf("collector: probe %q registered twice", kind))
+	}
+	probeFactories[kind] = factory
+}
+
+// RegisteredProbes 返回已注册的探针类型
+func RegisteredProbes() []string {
+	probeFactoriesMu.RLock()
+	defer probeFactoriesMu.RUnlock()
+	kinds := make([]string, 0, len(probeFactories))
+	for kind := range probeFactories {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// defaultProbes 未配置 probes 时的默认流程: 先 ping 后 snmp
+var defaultProbes = []config.ProbeConfig{
+	{Name: "ping"},
+	{Name: "snmp"},
+}
+
+// buildProbes 按配置顺序实例化探针
+func (c *Collector) buildProbes() []probeSpec {
+	cfgs := c.config.Probes
+	if len(cfgs) == 0 {
+		cfgs = defaultProbes
+	}
+
+	probes := make([]probeSpec, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		kind := cfg.Kind()
+
+		probeFactoriesMu.RLock()
+		factory, ok := probeFactories[kind]
+		probeFactoriesMu.RUnlock()
+		if !ok {
+			c.logger.WithField("probe", kind).Error("Unknown probe type, skipped")
+			continue
+		}
+
+		probe, err := factory(c, cfg)
+		if err != nil {
+			c.logger.WithError(err).WithField("probe", cfg.Name).Error("Failed to create probe, skipped")
+			continue
+		}
+		probes = append(probes, probeSpec{probe: probe, kind: kind, interval: cfg.Interval})
+	}
+
+	return probes
+}
+
+// Config 返回采集器配置 (供自研探针使用)
+func (c *Collector) Config() *config.Config {
+	return c.config
+}
+
+// Logger 返回采集器日志 (供自研探针使用)
+func (c *Collector) Logger() *logrus.Logger {
+	return c.logger
+}
+
+// deviceTypeFilter 按 Device.Type 过滤的通用实现, 为空表示适用于所有类型
+type deviceTypeFilter map[string]bool
+
+func newDeviceTypeFilter(types []string) deviceTypeFilter {
+	if len(types) == 0 {
+		return nil
+	}
+	f := make(deviceTypeFilter, len(types))
+	for _, t := range types {
+		f[t] = true
+	}
+	return f
+}
+
+func (f deviceTypeFilter) match(deviceType string) bool {
+	return f == nil || f[deviceType]
+}
@@ -0,0 +1,122 @@
+package collector
+
+import (
+	"time"
+
+	"github.com/netvis/collector/internal/config"
+)
+
+// CredentialProfile 服务端下发的凭据模板 (SNMP 及可选的 SSH 登录凭据)
+type CredentialProfile struct {
+	ID             string               `json:"id"`
+	Name           string               `json:"name,omitempty"`
+	Version        string               `json:"version"` // 1 / 2c / 3
+	Community      string               `json:"community,omitempty"`
+	Port           int                  `json:"port,omitempty"`
+	Timeout        int                  `json:"timeout,omitempty"` // 秒
+	Retries        *int                 `json:"retries,omitempty"`
+	MaxRepetitions int                  `json:"maxRepetitions,omitempty"`
+	Interval       int                  `json:"interval,omitempty"` // 引用该模板的设备的采集间隔(秒)
+	V3             *config.SNMPv3Config `json:"v3,omitempty"`
+	SSH            *SSHCredential       `json:"ssh,omitempty"`
+}
+
+// SSHCredential SSH 登录凭据, 密码与私钥至少提供一个
+type SSHCredential struct {
+	Username       string `json:"username"`
+	Password       string `json:"password,omitempty"`
+	PrivateKey     string `json:"privateKey,omitempty"` // PEM
+	Passphrase     string `json:"passphrase,omitempty"`
+	EnablePassword string `json:"enablePassword,omitempty"` // 用户模式登录时的 enable 密码
+	Port           int    `json:"port,omitempty"`
+}
+
+// snmpSettings 合并后的设备SNMP参数 (凭据模板 > 设备字段 > 全局配置)
+type snmpSettings struct {
+	Version        string
+	Community      string
+	Port           int
+	Timeout        time.Duration
+	Retries        int
+	MaxRepetitions int
+	V3             config.SNMPv3Config
+}
+
+// SetCredentialProfiles 更新凭据模板缓存
+func (c *Collector) SetCredentialProfiles(profiles []CredentialProfile) {
+	cache := make(map[string]CredentialProfile, len(profiles))
+	for _, p := range profiles {
+		cache[p.ID] = p
+	}
+
+	c.profilesMu.Lock()
+	c.profiles = cache
+	c.profilesMu.Unlock()
+}
+
+// credentialProfile 按ID查找凭据模板
+func (c *Collector) credentialProfile(id string) (CredentialProfile, bool) {
+	c.profilesMu.RLock()
+	defer c.profilesMu.RUnlock()
+	p, ok := c.profiles[id]
+	return p, ok
+}
+
+// resolveSNMPSettings 解析设备实际使用的SNMP参数
+func (c *Collector) resolveSNMPSettings(device Device) snmpSettings {
+	global := c.config.SNMP
+	s := snmpSettings{
+		Version:        global.Version,
+		Community:      global.Community,
+		Port:           global.Port,
+		Timeout:        global.Timeout,
+		Retries:        global.Retries,
+		MaxRepetitions: global.MaxRepetitions,
+		V3:             global.V3,
+	}
+
+	if device.SNMPVersion != "" {
+		s.Version = device.SNMPVersion
+	}
+	if device.Community != "" {
+		s.Community = device.Community
+	}
+	if device.SNMPv3 != nil && device.SNMPv3.Username != "" {
+		s.V3 = *device.SNMPv3
+	}
+
+	if device.CredentialProfileID == "" {
+		return s
+	}
+	p, ok := c.credentialProfile(device.CredentialProfileID)
+	if !ok {
+		c.logger.WithField("profile", device.CredentialProfileID).
+			WithField("ip", device.IP).
+			Warn("Credential profile not found, using device defaults")
+		return s
+	}
+
+	if p.Version != "" {
+		s.Version = p.Version
+	}
+	if p.Community != "" {
+		s.Community = p.Community
+	}
+	if p.Port > 0 {
+		s.Port = p.Port
+	}
+	if p.Timeout > 0 {
+		s.Timeout = time.Duration(p.Timeout) * time.Second
+	}
+	if p.Retries != nil {
+		s.Retries = *p.Retries
+	}
+	if p.MaxRepetitions > 0 {
+		s.MaxRepetitions = p.MaxRepetitions
+	}
+	if p.V3 != nil && p.V3.Username != "" {
+		s.V3 = *p.V3
+	}
+
+	return s
+}
@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"sync"
+	"time"
+)
+
+// sourceLimiter 按来源地址的令牌桶限速, 用于抵御 trap/syslog 风暴
+type sourceLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // 每秒补充的令牌数
+	burst   float64
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens  float64
+	last    time.Time
+	dropped int // 自上次报告以来丢弃的消息数
+}
+
+func newSourceLimiter(rate float64, burst int) *sourceLimiter {
+	return &sourceLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow 判断来源的消息是否放行
+func (l *sourceLimiter) allow(source string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[source]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[source] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		b.dropped++
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// drain 返回各来源被丢弃的消息数并清零, 同时清理长期空闲的来源
+func (l *sourceLimiter) drain(now time.Time, idle time.Duration) map[string]int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	dropped := make(map[string]int)
+	for source, b := range l.buckets {
+		if b.dropped > 0 {
+			dropped[source] = b.dropped
+			b.dropped = 0
+		}
+		if now.Sub(b.last) > idle {
+			delete(l.buckets, source)
+		}
+	}
+	return dropped
+}
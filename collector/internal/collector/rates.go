@@ -0,0 +1,141 @@
+package collector
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateSampleTTL 超过该时长未更新的接口样本将被清理
+const rateSampleTTL = time.Hour
+
+// ifSample 上一次采集的接口计数器快照
+type ifSample struct {
+	inOctets  uint64
+	outOctets uint64
+	inPkts    [3]uint64 // 单播/组播/广播分别保存, 32位计数器需逐个处理回绕
+	outPkts   [3]uint64
+	hc        bool   // 字节计数器为64位
+	pkts32    uint8  // 同 IfStats.pkts32
+	uptime    uint32 // sysUpTime (timeticks)
+	at        time.Time
+}
+
+// rateTracker 按 设备+ifIndex 保存上次样本并计算速率
+type rateTracker struct {
+	mu        sync.Mutex
+	samples   map[string]ifSample
+	lastPrune time.Time
+}
+
+func newRateTracker() *rateTracker {
+	return &rateTracker{
+		samples:   make(map[string]ifSample),
+		lastPrune: time.Now(),
+	}
+}
+
+// apply 计算并填充接口的 bps/pps/利用率
+// uptime 为本次采集的 sysUpTime, 小于上次值说明设备重启, 此时仅重建基线
+func (t *rateTracker) apply(deviceID string, uptime uint32, now time.Time, interfaces []IfStats) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i := range interfaces {
+		ifs := &interfaces[i]
+		key := deviceID + "/" + strconv.Itoa(ifs.Index)
+
+		cur := ifSample{
+			inOctets:  uint64(ifs.InBytes),
+			outOctets: uint64(ifs.OutBytes),
+			inPkts:    [3]uint64{uint64(ifs.InUcastPkts), uint64(ifs.InMulticastPkts), uint64(ifs.InBroadcastPkts)},
+			outPkts:   [3]uint64{uint64(ifs.OutUcastPkts), uint64(ifs.OutMulticastPkts), uint64(ifs.OutBroadcastPkts)},
+			hc:        ifs.HighCapacity,
+			pkts32:    ifs.pkts32,
+			uptime:    uptime,
+			at:        now,
+		}
+		prev, ok := t.samples[key]
+		t.samples[key] = cur
+
+		if !ok || prev.hc != cur.hc || prev.pkts32 != cur.pkts32 {
+			continue
+		}
+		if uptime > 0 && prev.uptime > 0 && uptime < prev.uptime {
+			// 设备重启, 计数器已清零
+			continue
+		}
+		elapsed := now.Sub(prev.at).Seconds()
+		if elapsed <= 0 {
+			continue
+		}
+
+		inOctets, ok1 := counterDelta(prev.inOctets, cur.inOctets, cur.hc)
+		outOctets, ok2 := counterDelta(prev.outOctets, cur.outOctets, cur.hc)
+		if !ok1 || !ok2 {
+			continue
+		}
+		inBps := float64(inOctets) * 8 / elapsed
+		outBps := float64(outOctets) * 8 / elapsed
+
+		// 32位计数器在一个周期内多次回绕时无法识别, 超过接口速率的结果视为无效
+		if ifs.Speed > 0 {
+			limit := float64(ifs.Speed) * 1.1
+			if inBps > limit || outBps > limit {
+				continue
+			}
+			ifs.InUtilization = round2(inBps / float64(ifs.Speed) * 100)
+			ifs.OutUtilization = round2(outBps / float64(ifs.Speed) * 100)
+		}
+		ifs.InBps = round2(inBps)
+		ifs.OutBps = round2(outBps)
+
+		if inPkts, ok := countersDelta(prev.inPkts, cur.inPkts, cur.pkts32); ok {
+			ifs.InPps = round2(float64(inPkts) / elapsed)
+		}
+		if outPkts, ok := countersDelta(prev.outPkts, cur.outPkts, cur.pkts32>>3); ok {
+			ifs.OutPps = round2(float64(outPkts) / elapsed)
+		}
+		ifs.RateInterval = round2(elapsed)
+	}
+
+	if now.Sub(t.lastPrune) > rateSampleTTL/6 {
+		for key, s := range t.samples {
+			if now.Sub(s.at) > rateSampleTTL {
+				delete(t.samples, key)
+			}
+		}
+		t.lastPrune = now
+	}
+}
+
+// counterDelta 计算计数器增量, 处理32位回绕
+// 64位计数器实际不会回绕, 变小说明计数器被清零, 本次样本丢弃
+func counterDelta(prev, cur uint64, hc bool) (uint64, bool) {
+	if cur >= prev {
+		return cur - prev, true
+	}
+	if !hc && prev <= math.MaxUint32 {
+		return cur + (math.MaxUint32 - prev) + 1, true
+	}
+	return 0, false
+}
+
+// countersDelta 逐个计算计数器增量后求和, counter32 的低3位标记各计数器为32位
+// 任一计数器被清零时本次样本丢弃
+func countersDelta(prev, cur [3]uint64, counter32 uint8) (uint64, bool) {
+	var sum uint64
+	for i := range cur {
+		delta, ok := counterDelta(prev[i], cur[i], counter32&(1<<i) == 0)
+		if !ok {
+			return 0, false
+		}
+		sum += delta
+	}
+	return sum, true
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,48 @@
+package collector
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRatesMixedCounterWidths(t *testing.T) {
+	tracker := newRateTracker()
+	start := time.Now()
+
+	// 字节计数器为64位, 入方向单播包仅有32位列且即将回绕
+	ifs := IfStats{
+		Index: 1, Speed: 1e9, HighCapacity: true, pkts32: 1,
+		InBytes: 1 << 40, OutBytes: 1 << 40,
+		InUcastPkts: math.MaxUint32 - 99, InMulticastPkts: 1 << 33,
+	}
+	tracker.apply("dev-1", 100, start, []IfStats{ifs})
+
+	ifs.InBytes += 1000
+	ifs.InUcastPkts = 900 // 回绕: 增量 1000
+	ifs.InMulticastPkts += 500
+	cur := []IfStats{ifs}
+	tracker.apply("dev-1", 1100, start.Add(10*time.Second), cur)
+	if cur[0].RateInterval != 10 || cur[0].InBps != 800 {
+		t.Fatalf("rates = %+v", cur[0])
+	}
+	if cur[0].InPps != 150 {
+		t.Errorf("in pps = %v, want 150 (32-bit ucast wrap)", cur[0].InPps)
+	}
+
+	// 64位组播计数器变小视为清零, 不按32位回绕计算
+	ifs.InMulticastPkts = 10
+	cur = []IfStats{ifs}
+	tracker.apply("dev-1", 2100, start.Add(20*time.Second), cur)
+	if cur[0].InPps != 0 {
+		t.Errorf("in pps after 64-bit counter reset = %v, want 0", cur[0].InPps)
+	}
+
+	// 计数器位宽变化时重建基线
+	ifs.pkts32 = 0
+	cur = []IfStats{ifs}
+	tracker.apply("dev-1", 3100, start.Add(30*time.Second), cur)
+	if cur[0].RateInterval != 0 {
+		t.Errorf("rates computed across counter width change: %+v", cur[0])
+	}
+}
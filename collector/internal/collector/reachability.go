@@ -0,0 +1,251 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/netvis/collector/internal/config"
+)
+
+// 设备状态
+const (
+	StatusOnline      = "online"      // 可达且所有探针正常
+	StatusDegraded    = "degraded"    // 可达, 但存在部分丢包或非关键探针失败
+	StatusUnreachable = "unreachable" // 按可达性策略判定不可达
+	StatusSNMPFailed  = "snmp-failed" // 可达, 但SNMP采集失败 (凭据错误/ACL等)
+	StatusUnknown     = "unknown"     // 尚无判定所需的探针结果
+)
+
+// flapState 抖动抑制: 与当前状态相反的观测连续达到阈值才切换
+type flapState struct {
+	known  bool
+	ok     bool
+	streak int
+}
+
+// observe 记录一次观测, 返回状态是否切换 (首次观测直接采用, 不视为切换)
+func (f *flapState) observe(ok bool, d config.DampeningConfig) bool {
+	if !f.known {
+		f.known, f.ok = true, ok
+		return false
+	}
+	if ok == f.ok {
+		f.streak = 0
+		return false
+	}
+	f.streak++
+	need := d.Failures
+	if ok {
+		need = d.Successes
+	}
+	if f.streak < need {
+		return false
+	}
+	f.ok, f.streak = ok, 0
+	return true
+}
+
+// probeOutcome 探针经抖动抑制后的结果
+type probeOutcome struct {
+	kind        string
+	state       flapState
+	targets     map[string]*flapState // 检测目标 -> 状态
+	unreachable bool                  // 最近一次失败时返回 ErrUnreachable
+	reason      string                // 最近一次失败原因
+	checks      []CheckResult
+}
+
+func (o *probeOutcome) ran() bool { return o.state.known }
+func (o *probeOutcome) ok() bool  { return o.state.ok }
+
+// record 根据探针返回值与检测结果更新
+func (o *probeOutcome) record(err error, checks []CheckResult, d config.DampeningConfig) {
+	rawOK := err == nil
+	reason := ""
+	if err != nil {
+		reason = err.Error()
+	}
+
+	targets := make(map[string]*flapState, len(checks))
+	for _, chk := range checks {
+		f, ok := o.targets[chk.Target]
+		if !ok {
+			f = &flapState{}
+		}
+		f.observe(chk.Success, d)
+		targets[chk.Target] = f
+
+		if !chk.Success && rawOK {
+			rawOK = false
+			reason = fmt.Sprintf("%s: %s", chk.Target, chk.Detail)
+		}
+	}
+	o.targets = targets
+	o.checks = checks
+
+	if !rawOK {
+		o.reason = reason
+		o.unreachable = errors.Is(err, ErrUnreachable)
+	}
+	o.state.observe(rawOK, d)
+}
+
+// verdict 参与可达性判定的单项结果
+type verdict struct {
+	name   string
+	ok     bool
+	reason string
+}
+
+// reachabilityPolicy 返回设备类型对应的可达性策略
+func (c *Collector) reachabilityPolicy(deviceType string) config.ReachabilityPolicy {
+	if p, ok := c.config.Reachability.DeviceTypes[deviceType]; ok && p.Mode != "" {
+		return p
+	}
+	return c.config.Reachability.Default
+}
+
+// policyProbes 策略引用的探针名称或类型
+func policyProbes(policy config.ReachabilityPolicy) []string {
+	switch policy.Mode {
+	case config.ReachPingOnly:
+		return []string{"ping"}
+	case config.ReachSNMPOnly:
+		return []string{"snmp"}
+	case config.ReachAnyOf, config.ReachAllOf:
+		if len(policy.Probes) > 0 {
+			return policy.Probes
+		}
+		return []string{"ping", "snmp"}
+	}
+	return nil
+}
+
+// decides 判断探针是否参与可达性判定
+func decides(policy config.ReachabilityPolicy, name string, o *probeOutcome) bool {
+	if policy.Mode == config.ReachTCP {
+		_, found := tcpVerdict(o.checks, policy.Port)
+		return found
+	}
+	for _, p := range policyProbes(policy) {
+		if p == name || p == o.kind {
+			return true
+		}
+	}
+	return false
+}
+
+// tcpVerdict 在检测结果中查找目标端口, 端口为0时匹配任意TCP检测
+func tcpVerdict(checks []CheckResult, port int) (CheckResult, bool) {
+	for _, chk := range checks {
+		_, p, err := net.SplitHostPort(chk.Target)
+		if err != nil {
+			continue
+		}
+		if port == 0 || p == strconv.Itoa(port) {
+			return chk, true
+		}
+	}
+	return CheckResult{}, false
+}
+
+// evaluateStatus 按可达性策略计算设备状态及原因
+func evaluateStatus(policy config.ReachabilityPolicy, outcomes map[string]*probeOutcome, packetLoss float64) (string, string) {
+	names := make([]string, 0, len(outcomes))
+	for name := range outcomes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var verdicts []verdict
+	deciding := make(map[string]bool)
+	for _, name := range names {
+		o := outcomes[name]
+		if !decides(policy, name, o) {
+			continue
+		}
+		deciding[name] = true
+		if !o.ran() {
+			continue
+		}
+		v := verdict{name: name, ok: o.ok(), reason: o.reason}
+		if policy.Mode == config.ReachTCP {
+			chk, _ := tcpVerdict(o.checks, policy.Port)
+			if f, ok := o.targets[chk.Target]; ok {
+				v.ok = f.ok
+			}
+			v.reason = fmt.Sprintf("%s: %s", chk.Target, chk.Detail)
+		}
+		verdicts = append(verdicts, v)
+	}
+
+	// 策略引用的探针均不适用于该设备时, 退化为任一探针成功即可达
+	if len(deciding) == 0 {
+		policy = config.ReachabilityPolicy{Mode: config.ReachAnyOf}
+		for _, name := range names {
+			if o := outcomes[name]; o.ran() {
+				deciding[name] = true
+				verdicts = append(verdicts, verdict{name: name, ok: o.ok(), reason: o.reason})
+			}
+		}
+	}
+	if len(verdicts) == 0 {
+		return StatusUnknown, ""
+	}
+
+	var okCount int
+	var failures []string
+	for _, v := range verdicts {
+		if v.ok {
+			okCount++
+		} else {
+			failures = append(failures, v.name+": "+v.reason)
+		}
+	}
+
+	reachable := okCount > 0
+	if policy.Mode == config.ReachAllOf {
+		reachable = okCount == len(verdicts)
+	}
+	if !reachable {
+		return StatusUnreachable, strings.Join(failures, "; ")
+	}
+
+	// 可达: SNMP失败单独标记, 其余失败或部分丢包视为降级
+	for _, name := range names {
+		if o := outcomes[name]; o.kind == "snmp" && o.ran() && !o.ok() {
+			return StatusSNMPFailed, name + ": " + o.reason
+		}
+	}
+	for _, name := range names {
+		o := outcomes[name]
+		// 不参与判定的探针报告不可达 (如屏蔽ICMP时的ping) 不影响状态
+		if !o.ran() || o.ok() || (!deciding[name] && o.unreachable) {
+			continue
+		}
+		failures = append(failures, name+": "+o.reason)
+	}
+	if len(failures) > 0 {
+		return StatusDegraded, strings.Join(dedupStrings(failures), "; ")
+	}
+	if packetLoss > 0 && packetLoss < 100 {
+		return StatusDegraded, fmt.Sprintf("packet loss %.1f%%", packetLoss)
+	}
+	return StatusOnline, ""
+}
+
+func dedupStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	out := values[:0]
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
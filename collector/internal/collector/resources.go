@@ -0,0 +1,398 @@
+package collector
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// 系统资源 OID (厂商私有 MIB)
+const (
+	oidSysObjectID = ".1.3.6.1.2.1.1.2.0" // sysObjectID
+
+	// CISCO-PROCESS-MIB cpmCPUTotalTable
+	oidCpmCPUTotal1min    = ".1.3.6.1.4.1.9.9.109.1.1.1.1.4" // cpmCPUTotal1min (旧版 IOS)
+	oidCpmCPUTotal1minRev = ".1.3.6.1.4.1.9.9.109.1.1.1.1.7" // cpmCPUTotal1minRev
+	// CISCO-ENHANCED-MEMPOOL-MIB cempMemPoolTable
+	oidCempMemPoolType   = ".1.3.6.1.4.1.9.9.221.1.1.1.1.3"  // cempMemPoolType (2 = processorMemory)
+	oidCempMemPoolUsed   = ".1.3.6.1.4.1.9.9.221.1.1.1.1.7"  // cempMemPoolUsed
+	oidCempMemPoolFree   = ".1.3.6.1.4.1.9.9.221.1.1.1.1.8"  // cempMemPoolFree
+	oidCempMemPoolHCUsed = ".1.3.6.1.4.1.9.9.221.1.1.1.1.18" // cempMemPoolHCUsed
+	oidCempMemPoolHCFree = ".1.3.6.1.4.1.9.9.221.1.1.1.1.20" // cempMemPoolHCFree
+	// CISCO-MEMORY-POOL-MIB ciscoMemoryPoolTable (索引 1 = processor)
+	oidCiscoMemoryPoolUsed = ".1.3.6.1.4.1.9.9.48.1.1.1.5" // ciscoMemoryPoolUsed
+	oidCiscoMemoryPoolFree = ".1.3.6.1.4.1.9.9.48.1.1.1.6" // ciscoMemoryPoolFree
+	// CISCO-ENVMON-MIB
+	oidCiscoEnvMonTemperature = ".1.3.6.1.4.1.9.9.13.1.3.1.3" // ciscoEnvMonTemperatureStatusValue (°C)
+
+	// JUNIPER-MIB jnxOperatingTable
+	oidJnxOperatingDescr  = ".1.3.6.1.4.1.2636.3.1.13.1.5"  // jnxOperatingDescr
+	oidJnxOperatingTemp   = ".1.3.6.1.4.1.2636.3.1.13.1.7"  // jnxOperatingTemp (°C)
+	oidJnxOperatingCPU    = ".1.3.6.1.4.1.2636.3.1.13.1.8"  // jnxOperatingCPU (%)
+	oidJnxOperatingBuffer = ".1.3.6.1.4.1.2636.3.1.13.1.11" // jnxOperatingBuffer (内存 %)
+
+	// RUIJIE-PROCESS-MIB / RUIJIE-MEMORY-MIB / RUIJIE-SYSTEM-MIB
+	oidRuijieCPUUtilization1Min    = ".1.3.6.1.4.1.4881.1.1.10.2.36.1.1.2"   // ruijieCPUUtilization1Min
+	oidRuijieMemoryPoolUtilization = ".1.3.6.1.4.1.4881.1.1.10.2.35.1.1.1.3" // ruijieMemoryPoolCurrentUtilization
+	oidRuijieSystemTemperature     = ".1.3.6.1.4.1.4881.1.1.10.2.1.1.16"     // ruijieSystemTemperatureCurrent
+
+	// HOST-RESOURCES-MIB
+	oidHrProcessorLoad          = ".1.3.6.1.2.1.25.3.3.1.2" // hrProcessorLoad
+	oidHrStorageType            = ".1.3.6.1.2.1.25.2.3.1.2" // hrStorageType
+	oidHrStorageDescr           = ".1.3.6.1.2.1.25.2.3.1.3" // hrStorageDescr
+	oidHrStorageAllocationUnits = ".1.3.6.1.2.1.25.2.3.1.4" // hrStorageAllocationUnits (字节)
+	oidHrStorageSize            = ".1.3.6.1.2.1.25.2.3.1.5" // hrStorageSize (单位数)
+	oidHrStorageUsed            = ".1.3.6.1.2.1.25.2.3.1.6" // hrStorageUsed (单位数)
+)
+
+// hrStorageTypes hrStorageType 取值 (hrStorageTypes 下的 OID) 对应的类型名
+var hrStorageTypes = map[string]string{
+	".1.3.6.1.2.1.25.2.1.1":  "other",
+	".1.3.6.1.2.1.25.2.1.2":  "ram",
+	".1.3.6.1.2.1.25.2.1.3":  "virtualMemory",
+	".1.3.6.1.2.1.25.2.1.4":  "fixedDisk",
+	".1.3.6.1.2.1.25.2.1.5":  "removableDisk",
+	".1.3.6.1.2.1.25.2.1.6":  "floppyDisk",
+	".1.3.6.1.2.1.25.2.1.7":  "compactDisc",
+	".1.3.6.1.2.1.25.2.1.8":  "ramDisk",
+	".1.3.6.1.2.1.25.2.1.9":  "flashMemory",
+	".1.3.6.1.2.1.25.2.1.10": "networkDisk",
+}
+
+// reportedStorageTypes 上报的存储类型, 可移动介质、ramDisk (tmpfs) 及 other 不上报
+var reportedStorageTypes = map[string]bool{
+	"ram":           true,
+	"virtualMemory": true,
+	"fixedDisk":     true,
+	"flashMemory":   true,
+	"networkDisk":   true,
+}
+
+// StorageUsage 存储使用情况 (hrStorageTable), 每个文件系统 / 内存类型一条
+type StorageUsage struct {
+	Index int     `json:"index"`
+	Type  string  `json:"type"`  // ram / virtualMemory / fixedDisk / flashMemory / networkDisk
+	Descr string  `json:"descr"` // 挂载点或名称
+	Size  uint64  `json:"size"`  // 字节
+	Used  uint64  `json:"used"`  // 字节
+	Usage float64 `json:"usage"` // %
+}
+
+// resourceUsage 系统资源采集结果, has* 为 false 表示该项未取到
+type resourceUsage struct {
+	cpu         float64
+	memory      float64
+	temperature float64
+	storage     []StorageUsage
+	hasCPU      bool
+	hasMemory   bool
+}
+
+// resourceProfile 按 sysObjectID 前缀选择的厂商资源采集方式
+type resourceProfile struct {
+	name    string
+	prefix  string
+	collect func(snmp *gosnmp.GoSNMP) resourceUsage
+}
+
+// entityExtColumns 华为/H3C 实体扩展状态表的列 (按 entPhysicalIndex 索引的单板)
+type entityExtColumns struct {
+	cpu, memory, memorySize, temperature string
+}
+
+var (
+	// HUAWEI-ENTITY-EXTENT-MIB hwEntityStateTable
+	huaweiEntityState = entityExtColumns{
+		cpu:         ".1.3.6.1.4.1.2011.5.25.31.1.1.1.1.5",  // hwEntityCpuUsage
+		memory:      ".1.3.6.1.4.1.2011.5.25.31.1.1.1.1.7",  // hwEntityMemUsage
+		memorySize:  ".1.3.6.1.4.1.2011.5.25.31.1.1.1.1.9",  // hwEntityMemSize
+		temperature: ".1.3.6.1.4.1.2011.5.25.31.1.1.1.1.11", // hwEntityTemperature
+	}
+	// HH3C-ENTITY-EXT-MIB hh3cEntityExtStateTable
+	h3cEntityExtState = entityExtColumns{
+		cpu:         ".1.3.6.1.4.1.25506.2.6.1.1.1.1.6",  // hh3cEntityExtCpuUsage
+		memory:      ".1.3.6.1.4.1.25506.2.6.1.1.1.1.8",  // hh3cEntityExtMemUsage
+		memorySize:  ".1.3.6.1.4.1.25506.2.6.1.1.1.1.10", // hh3cEntityExtMemSize
+		temperature: ".1.3.6.1.4.1.25506.2.6.1.1.1.1.12", // hh3cEntityExtTemperature
+	}
+	// H3C-ENTITY-EXT-MIB (华为3Com 时期的设备, 位于华为企业号下)
+	h3cLegacyEntityExtState = entityExtColumns{
+		cpu:         ".1.3.6.1.4.1.2011.10.2.6.1.1.1.1.6",
+		memory:      ".1.3.6.1.4.1.2011.10.2.6.1.1.1.1.8",
+		memorySize:  ".1.3.6.1.4.1.2011.10.2.6.1.1.1.1.10",
+		temperature: ".1.3.6.1.4.1.2011.10.2.6.1.1.1.1.12",
+	}
+)
+
+// resourceProfiles 厂商资源采集配置, 按最长前缀匹配
+var resourceProfiles = []resourceProfile{
+	{name: "cisco", prefix: ".1.3.6.1.4.1.9.", collect: collectCiscoResources},
+	{name: "huawei", prefix: ".1.3.6.1.4.1.2011.", collect: entityExtResources(huaweiEntityState)},
+	{name: "h3c", prefix: ".1.3.6.1.4.1.2011.10.", collect: entityExtResources(h3cLegacyEntityExtState)},
+	{name: "h3c", prefix: ".1.3.6.1.4.1.25506.", collect: entityExtResources(h3cEntityExtState)},
+	{name: "juniper", prefix: ".1.3.6.1.4.1.2636.", collect: collectJuniperResources},
+	{name: "ruijie", prefix: ".1.3.6.1.4.1.4881.", collect: collectRuijieResources},
+}
+
+// lookupResourceProfile 按 sysObjectID 查找厂商资源采集配置, 未匹配返回 nil
+func lookupResourceProfile(sysObjectID string) *resourceProfile {
+	if sysObjectID == "" {
+		return nil
+	}
+	oid := "." + strings.Trim(sysObjectID, ".") + "."
+	var best *resourceProfile
+	for i := range resourceProfiles {
+		p := &resourceProfiles[i]
+		if strings.HasPrefix(oid, p.prefix) && (best == nil || len(p.prefix) > len(best.prefix)) {
+			best = p
+		}
+	}
+	return best
+}
+
+// collectResources 采集 CPU / 内存 / 温度
+// 优先使用厂商私有 MIB, 未取到的 CPU / 内存由 HOST-RESOURCES-MIB 补充
+func (c *Collector) collectResources(snmp *gosnmp.GoSNMP, sysObjectID string) resourceUsage {
+	var usage resourceUsage
+	if profile := lookupResourceProfile(sysObjectID); profile != nil {
+		usage = profile.collect(snmp)
+		if !usage.hasCPU && !usage.hasMemory {
+			c.logger.WithField("ip", snmp.Target).WithField("profile", profile.name).
+				Debug("Vendor resource MIBs returned no data, falling back to HOST-RESOURCES")
+		}
+	}
+	if usage.hasCPU && usage.hasMemory {
+		return usage
+	}
+
+	host := collectHostResources(snmp)
+	usage.storage = host.storage
+	if !usage.hasCPU {
+		usage.cpu, usage.hasCPU = host.cpu, host.hasCPU
+	}
+	if !usage.hasMemory {
+		usage.memory, usage.hasMemory = host.memory, host.hasMemory
+	}
+	return usage
+}
+
+// collectHostResources HOST-RESOURCES-MIB: CPU 取所有处理器平均, 内存取 hrStorageRam 条目, 并上报各文件系统用量
+func collectHostResources(snmp *gosnmp.GoSNMP) resourceUsage {
+	var usage resourceUsage
+	if table, err := walkTable(snmp, oidHrProcessorLoad); err == nil {
+		usage.cpu, usage.hasCPU = columnAverage(table, nil, oidHrProcessorLoad)
+	}
+
+	table, err := walkTable(snmp, oidHrStorageType, oidHrStorageDescr, oidHrStorageAllocationUnits, oidHrStorageSize, oidHrStorageUsed)
+	if err != nil {
+		return usage
+	}
+
+	var ramSize, ramUsed, cached uint64
+	for index := range table {
+		entry, ok := hrStorageEntry(table, index)
+		if !ok {
+			continue
+		}
+		switch {
+		case entry.Type == "ram":
+			ramSize += entry.Size
+			ramUsed += entry.Used
+		case entry.Type == "other" && (entry.Descr == "Memory buffers" || entry.Descr == "Cached memory"):
+			// net-snmp 的 Physical memory 已用量包含缓冲与缓存
+			cached += entry.Used
+		}
+		if reportedStorageTypes[entry.Type] {
+			usage.storage = append(usage.storage, entry)
+		}
+	}
+	sort.Slice(usage.storage, func(i, j int) bool {
+		return usage.storage[i].Index < usage.storage[j].Index
+	})
+
+	if ramSize > 0 {
+		if cached < ramUsed {
+			ramUsed -= cached
+		}
+		usage.memory, usage.hasMemory = round2(float64(ramUsed)/float64(ramSize)*100), true
+	}
+	return usage
+}
+
+// hrStorageEntry 解析 hrStorageTable 的一行, 按分配单元换算为字节
+func hrStorageEntry(table snmpTable, index string) (StorageUsage, bool) {
+	pdu, ok := table[index][oidHrStorageType]
+	if !ok {
+		return StorageUsage{}, false
+	}
+	typeOID, _ := pdu.Value.(string)
+	typeName, ok := hrStorageTypes["."+strings.TrimPrefix(typeOID, ".")]
+	if !ok {
+		typeName = "other"
+	}
+	idx, err := strconv.Atoi(index)
+	if err != nil {
+		return StorageUsage{}, false
+	}
+
+	units, _ := table.rowInt(index, oidHrStorageAllocationUnits)
+	size, ok := table.rowInt(index, oidHrStorageSize)
+	used, _ := table.rowInt(index, oidHrStorageUsed)
+	if !ok || units <= 0 {
+		return StorageUsage{}, false
+	}
+
+	entry := StorageUsage{
+		Index: idx,
+		Type:  typeName,
+		Descr: table.rowString(index, oidHrStorageDescr),
+		// Integer32 列, 大容量磁盘在部分代理上会回绕为负数
+		Size: uint64(uint32(size)) * uint64(units),
+		Used: uint64(uint32(used)) * uint64(units),
+	}
+	if entry.Size > 0 {
+		entry.Usage = round2(float64(entry.Used) / float64(entry.Size) * 100)
+	}
+	return entry, true
+}
+
+// collectCiscoResources CPU 取各 CPU 1 分钟平均, 内存取处理器内存池, 温度取最高的传感器
+func collectCiscoResources(snmp *gosnmp.GoSNMP) resourceUsage {
+	var usage resourceUsage
+	table, err := walkTable(snmp,
+		oidCpmCPUTotal1minRev, oidCpmCPUTotal1min,
+		oidCempMemPoolType, oidCempMemPoolUsed, oidCempMemPoolFree, oidCempMemPoolHCUsed, oidCempMemPoolHCFree,
+		oidCiscoEnvMonTemperature,
+	)
+	if err != nil {
+		return usage
+	}
+
+	usage.cpu, usage.hasCPU = columnAverage(table, nil, oidCpmCPUTotal1minRev, oidCpmCPUTotal1min)
+
+	var used, free int64
+	for index := range table {
+		if t, ok := table.rowInt(index, oidCempMemPoolType); !ok || t != 2 {
+			continue
+		}
+		used += table.counter(index, oidCempMemPoolHCUsed, oidCempMemPoolUsed)
+		free += table.counter(index, oidCempMemPoolHCFree, oidCempMemPoolFree)
+	}
+	if used+free == 0 {
+		// 不支持 CISCO-ENHANCED-MEMPOOL-MIB 的旧设备
+		if pools, err := walkTable(snmp, oidCiscoMemoryPoolUsed, oidCiscoMemoryPoolFree); err == nil {
+			used = pools.counter("1", oidCiscoMemoryPoolUsed)
+			free = pools.counter("1", oidCiscoMemoryPoolFree)
+		}
+	}
+	if used+free > 0 {
+		usage.memory, usage.hasMemory = round2(float64(used)/float64(used+free)*100), true
+	}
+
+	usage.temperature, _ = columnMax(table, validTemperature, oidCiscoEnvMonTemperature)
+	return usage
+}
+
+// entityExtResources 华为/H3C: CPU 与内存取有内存的单板 (主控/业务板) 平均, 温度取最高值
+func entityExtResources(cols entityExtColumns) func(snmp *gosnmp.GoSNMP) resourceUsage {
+	return func(snmp *gosnmp.GoSNMP) resourceUsage {
+		var usage resourceUsage
+		table, err := walkTable(snmp, cols.cpu, cols.memory, cols.memorySize, cols.temperature)
+		if err != nil {
+			return usage
+		}
+
+		boards := func(index string) bool {
+			size, ok := table.rowInt(index, cols.memorySize)
+			return ok && size > 0
+		}
+		if _, ok := columnAverage(table, boards, cols.cpu); !ok {
+			// 部分型号不提供内存大小, 以 CPU 利用率非零识别单板
+			boards = func(index string) bool {
+				v, ok := table.rowInt(index, cols.cpu)
+				return ok && v > 0
+			}
+		}
+
+		usage.cpu, usage.hasCPU = columnAverage(table, boards, cols.cpu)
+		usage.memory, usage.hasMemory = columnAverage(table, boards, cols.memory)
+		usage.temperature, _ = columnMax(table, validTemperature, cols.temperature)
+		return usage
+	}
+}
+
+// collectJuniperResources CPU 与内存取路由引擎平均, 温度取最高值
+func collectJuniperResources(snmp *gosnmp.GoSNMP) resourceUsage {
+	var usage resourceUsage
+	table, err := walkTable(snmp, oidJnxOperatingDescr, oidJnxOperatingTemp, oidJnxOperatingCPU, oidJnxOperatingBuffer)
+	if err != nil {
+		return usage
+	}
+
+	routingEngine := func(index string) bool {
+		return strings.Contains(strings.ToLower(table.rowString(index, oidJnxOperatingDescr)), "routing engine")
+	}
+	usage.cpu, usage.hasCPU = columnAverage(table, routingEngine, oidJnxOperatingCPU)
+	usage.memory, usage.hasMemory = columnAverage(table, routingEngine, oidJnxOperatingBuffer)
+	usage.temperature, _ = columnMax(table, validTemperature, oidJnxOperatingTemp)
+	return usage
+}
+
+// collectRuijieResources CPU 取 1 分钟利用率, 内存取各内存池平均, 温度取最高值
+func collectRuijieResources(snmp *gosnmp.GoSNMP) resourceUsage {
+	var usage resourceUsage
+	table, err := walkTable(snmp, oidRuijieCPUUtilization1Min, oidRuijieMemoryPoolUtilization, oidRuijieSystemTemperature)
+	if err != nil {
+		return usage
+	}
+
+	usage.cpu, usage.hasCPU = columnAverage(table, nil, oidRuijieCPUUtilization1Min)
+	usage.memory, usage.hasMemory = columnAverage(table, nil, oidRuijieMemoryPoolUtilization)
+	usage.temperature, _ = columnMax(table, validTemperature, oidRuijieSystemTemperature)
+	return usage
+}
+
+// columnAverage 对满足 filter 的行取第一个存在的列求平均
+func columnAverage(table snmpTable, filter func(index string) bool, columns ...string) (float64, bool) {
+	var sum float64
+	n := 0
+	for index := range table {
+		if filter != nil && !filter(index) {
+			continue
+		}
+		for _, col := range columns {
+			if v, ok := table.rowInt(index, col); ok {
+				sum += float64(v)
+				n++
+				break
+			}
+		}
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return round2(sum / float64(n)), true
+}
+
+// columnMax 取列中 valid 的最大值
+func columnMax(table snmpTable, valid func(v int64) bool, column string) (float64, bool) {
+	var best float64
+	found := false
+	for index := range table {
+		v, ok := table.rowInt(index, column)
+		if !ok || !valid(v) {
+			continue
+		}
+		if !found || float64(v) > best {
+			best, found = float64(v), true
+		}
+	}
+	return best, found
+}
+
+// validTemperature 排除未安装传感器的 0 及 H3C 等设备表示不支持的 65535 等无效值
+func validTemperature(v int64) bool {
+	return v > 0 && v < 200
+}
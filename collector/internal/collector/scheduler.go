@@ -0,0 +1,451 @@
+package collector
+
+import (
+	"container/heap"
+	"context"
+	"math/rand"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// scheduleJitter 每次调度附加的随机延迟比例, 避免任务重新同步成整点突发
+const scheduleJitter = 0.05
+
+// probeSpec 已实例化的探针及其类型、默认采集间隔
+type probeSpec struct {
+	probe    Probe
+	kind     string
+	interval time.Duration
+}
+
+// task 设备+探针 调度单元; flush 为设备的样本发送任务 (每个采集周期发送一次合并样本)
+type task struct {
+	deviceID string
+	spec     probeSpec
+	flush    bool
+	interval time.Duration
+	next     time.Time
+	running  bool
+	index    int // 堆内位置
+}
+
+// taskHeap 按下次到期时间排序的最小堆
+type taskHeap []*task
+
+func (h taskHeap) Len() int           { return len(h) }
+func (h taskHeap) Less(i, j int) bool { return h[i].next.Before(h[j].next) }
+func (h taskHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *taskHeap) Push(x interface{}) {
+	t := x.(*task)
+	t.index = len(*h)
+	*h = append(*h, t)
+}
+func (h *taskHeap) Pop() interface{} {
+	old := *h
+	t := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	t.index = -1
+	return t
+}
+
+// deviceState 设备的合并采集结果, 各探针独立运行后写入同一份样本
+// 样本由 flush 任务每个周期发送一次, dirty 表示上次发送后有探针或被动数据源更新
+type deviceState struct {
+	mu        sync.Mutex
+	device    Device
+	sample    DeviceMetrics
+	dirty     bool
+	outcomes  map[string]*probeOutcome // 探针名称 -> 最近一次结果
+	ifaces    map[int]*ifaceState      // ifIndex -> 接口状态
+	changedAt time.Time                // 最近一次设备状态变化时间
+}
+
+// scheduler 按 设备+探针 独立调度, 慢设备不会阻塞其他设备
+type scheduler struct {
+	c      *Collector
+	mu     sync.Mutex
+	tasks  map[string]*task // deviceID/probe -> task
+	queue  taskHeap
+	states map[string]*deviceState
+	wake   chan struct{}
+	sem    chan struct{}
+}
+
+func newScheduler(c *Collector) *scheduler {
+	return &scheduler{
+		c:      c,
+		tasks:  make(map[string]*task),
+		states: make(map[string]*deviceState),
+		wake:   make(chan struct{}, 1),
+		sem:    make(chan struct{}, c.config.Collector.Concurrency),
+	}
+}
+
+// setDevices 同步设备列表: 新增任务随机分散首次执行时间, 已有任务保留原有节奏
+func (s *scheduler) setDevices(devices []Device) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	seen := make(map[string]bool)
+
+	for _, device := range devices {
+		state, ok := s.states[device.ID]
+		if !ok {
+			state = &deviceState{
+				sample:   DeviceMetrics{DeviceID: device.ID, IP: device.IP, Status: StatusUnknown},
+				outcomes: make(map[string]*probeOutcome),
+				ifaces:   make(map[int]*ifaceState),
+			}
+			s.states[device.ID] = state
+		}
+
+		state.mu.Lock()
+		state.device = device
+		state.sample.IP = device.IP
+		applicable := make(map[string]bool)
+		for _, spec := range s.c.probes {
+			if !spec.probe.Applicable(device) {
+				continue
+			}
+			applicable[spec.probe.Name()] = true
+			if _, ok := state.outcomes[spec.probe.Name()]; !ok {
+				state.outcomes[spec.probe.Name()] = &probeOutcome{kind: spec.kind}
+			}
+		}
+		for name := range state.outcomes {
+			if !applicable[name] {
+				delete(state.outcomes, name)
+			}
+		}
+		state.mu.Unlock()
+
+		// 设备采集周期取最短的探针间隔
+		var cycle time.Duration
+		for _, spec := range s.c.probes {
+			if !applicable[spec.probe.Name()] {
+				continue
+			}
+			key := device.ID + "/" + spec.probe.Name()
+			seen[key] = true
+			interval := s.c.probeInterval(device, spec)
+			if cycle == 0 || interval < cycle {
+				cycle = interval
+			}
+
+			if t, ok := s.tasks[key]; ok {
+				s.reschedule(t, interval, now)
+				continue
+			}
+
+			t := &task{
+				deviceID: device.ID,
+				spec:     spec,
+				interval: interval,
+				next:     now.Add(time.Duration(rand.Int63n(int64(interval) + 1))),
+			}
+			s.tasks[key] = t
+			heap.Push(&s.queue, t)
+		}
+
+		// 首次发送在一个周期后, 此时各周期内的探针均已执行过一次
+		if cycle == 0 {
+			cycle = s.c.config.Collector.Interval
+		}
+		key := device.ID + "#flush"
+		seen[key] = true
+		if t, ok := s.tasks[key]; ok {
+			s.reschedule(t, cycle, now)
+		} else {
+			t := &task{deviceID: device.ID, flush: true, interval: cycle, next: now.Add(cycle)}
+			s.tasks[key] = t
+			heap.Push(&s.queue, t)
+		}
+	}
+
+	for key, t := range s.tasks {
+		if seen[key] {
+			continue
+		}
+		if t.index >= 0 {
+			heap.Remove(&s.queue, t.index)
+		}
+		delete(s.tasks, key)
+	}
+	for id := range s.states {
+		if !containsDevice(devices, id) {
+			delete(s.states, id)
+		}
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// reschedule 更新任务间隔, 缩短时提前下次执行 (调用方持有 s.mu)
+func (s *scheduler) reschedule(t *task, interval time.Duration, now time.Time) {
+	if t.interval == interval {
+		return
+	}
+	t.interval = interval
+	if t.index >= 0 && t.next.Sub(now) > interval {
+		t.next = now.Add(interval)
+		heap.Fix(&s.queue, t.index)
+	}
+}
+
+func containsDevice(devices []Device, id string) bool {
+	for _, d := range devices {
+		if d.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// run 调度主循环, 等待最早到期的任务并派发
+func (s *scheduler) run(ctx context.Context, stop <-chan struct{}) {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		s.mu.Lock()
+		wait := time.Hour
+		now := time.Now()
+		for s.queue.Len() > 0 && !s.queue[0].next.After(now) {
+			t := heap.Pop(&s.queue).(*task)
+			s.dispatch(ctx, t, now)
+		}
+		if s.queue.Len() > 0 {
+			wait = s.queue[0].next.Sub(now)
+		}
+		s.mu.Unlock()
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// dispatch 派发到期任务并安排下次执行 (调用方持有 s.mu)
+func (s *scheduler) dispatch(ctx context.Context, t *task, now time.Time) {
+	// 固定频率调度, 落后时从当前时间重新计算
+	t.next = t.next.Add(t.interval + time.Duration(rand.Float64()*scheduleJitter*float64(t.interval)))
+	if t.next.Before(now) {
+		t.next = now.Add(t.interval)
+	}
+	heap.Push(&s.queue, t)
+
+	if t.flush {
+		if state, ok := s.states[t.deviceID]; ok {
+			s.c.flushSample(state)
+		}
+		return
+	}
+
+	if t.running {
+		s.c.logger.WithFields(logrus.Fields{
+			"device": t.deviceID,
+			"probe":  t.spec.probe.Name(),
+		}).Debug("Previous run still in progress, skipping")
+		return
+	}
+	state, ok := s.states[t.deviceID]
+	if !ok {
+		return
+	}
+	t.running = true
+
+	s.c.wg.Add(1)
+	go func() {
+		defer s.c.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			t.running = false
+			s.mu.Unlock()
+		}()
+
+		select {
+		case s.sem <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		defer func() { <-s.sem }()
+
+		// 单次执行不超过一个采集间隔
+		runCtx, cancel := context.WithTimeout(ctx, t.interval)
+		defer cancel()
+		s.c.runProbe(runCtx, state, t.spec.probe)
+	}()
+}
+
+// runProbe 执行单个探针并将结果合并到设备样本, 样本由 flush 任务按周期发送
+func (c *Collector) runProbe(ctx context.Context, state *deviceState, probe Probe) {
+	start := time.Now()
+	name := probe.Name()
+
+	state.mu.Lock()
+	device := state.device
+	policy := c.reachabilityPolicy(device.Type)
+	outcome, ok := state.outcomes[name]
+	// 设备已判定不可达时仅执行参与判定的探针, 由其负责恢复状态
+	skip := !ok || (state.sample.Status == StatusUnreachable && !decides(policy, name, outcome))
+	base := state.sample
+	state.mu.Unlock()
+
+	if skip {
+		return
+	}
+
+	scratch := base
+	scratch.Interfaces = append([]IfStats(nil), base.Interfaces...)
+	scratch.Checks = nil
+	err := probe.Collect(ctx, device, &scratch)
+
+	state.mu.Lock()
+	changed := mergeSample(&state.sample, &base, &scratch)
+
+	// 各探针的检测结果互不覆盖
+	checks := state.sample.Checks[:0:0]
+	for _, chk := range state.sample.Checks {
+		if chk.Probe != name {
+			checks = append(checks, chk)
+		}
+	}
+	state.sample.Checks = append(checks, scratch.Checks...)
+
+	now := time.Now()
+	outcome.record(err, scratch.Checks, c.config.Dampening)
+	status, reason := evaluateStatus(policy, state.outcomes, state.sample.PacketLoss)
+	events := c.applyDeviceStatus(state, status, reason, now)
+	if err == nil && changed["Interfaces"] {
+		events = append(events, c.applyInterfaceStatus(state, scratch.Interfaces, now)...)
+	}
+	state.sample.CollectedAt = now
+	state.dirty = true
+	sample := state.sample
+	state.mu.Unlock()
+
+	c.reportEvents(events)
+
+	if err != nil {
+		c.logger.WithError(err).WithFields(logrus.Fields{
+			"ip":     device.IP,
+			"probe":  name,
+			"status": sample.Status,
+		}).Warn("Probe failed")
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"ip":       device.IP,
+		"probe":    name,
+		"status":   sample.Status,
+		"duration": time.Since(start),
+	}).Debug("Probe collected")
+}
+
+// flushSample 发送设备自上次发送后更新过的合并样本, 每台设备每个周期至多一条
+func (c *Collector) flushSample(state *deviceState) {
+	state.mu.Lock()
+	if !state.dirty {
+		state.mu.Unlock()
+		return
+	}
+	state.dirty = false
+	sample := state.sample
+	state.mu.Unlock()
+
+	select {
+	case c.metrics <- sample:
+	default:
+		c.logger.Warn("Metrics channel full, dropping metrics")
+	}
+}
+
+// pushSample 被动数据源 (sFlow 计数器 / gNMI 订阅) 直接更新设备样本, 随下次 flush 发送
+// update 在持有 state.mu 时调用, 需整体替换而非原地修改 Interfaces (探针可能正在读取旧切片);
+// 返回 true 表示接口有更新, 此时同步跟踪接口状态
+func (c *Collector) pushSample(deviceID string, now time.Time, update func(sample *DeviceMetrics) bool) {
+	c.scheduler.mu.Lock()
+	state := c.scheduler.states[deviceID]
+	c.scheduler.mu.Unlock()
+	if state == nil {
+		return
+	}
+
+	state.mu.Lock()
+	var events []StateEvent
+	if update(&state.sample) {
+		events = c.applyInterfaceStatus(state, state.sample.Interfaces, now)
+	}
+	state.sample.CollectedAt = now
+	state.dirty = true
+	state.mu.Unlock()
+
+	c.reportEvents(events)
+}
+
+// mergeSample 三方合并: 仅将探针实际修改过的字段写回, 并发探针互不覆盖
+// 返回被修改的字段名
+func mergeSample(dst, base, scratch *DeviceMetrics) map[string]bool {
+	changed := make(map[string]bool)
+	dv := reflect.ValueOf(dst).Elem()
+	bv := reflect.ValueOf(base).Elem()
+	sv := reflect.ValueOf(scratch).Elem()
+	typ := dv.Type()
+
+	for i := 0; i < typ.NumField(); i++ {
+		switch typ.Field(i).Name {
+		case "DeviceID", "IP", "Status", "Reason", "Checks", "CollectedAt":
+			continue
+		}
+		if !reflect.DeepEqual(bv.Field(i).Interface(), sv.Field(i).Interface()) {
+			dv.Field(i).Set(sv.Field(i))
+			changed[typ.Field(i).Name] = true
+		}
+	}
+	return changed
+}
+
+// probeInterval 解析 设备+探针 的采集间隔
+// 优先级: 设备按探针配置 > 设备 > 凭据模板 > 探针配置 > collector.interval
+func (c *Collector) probeInterval(device Device, spec probeSpec) time.Duration {
+	if v, ok := device.Intervals[spec.probe.Name()]; ok && v > 0 {
+		return time.Duration(v) * time.Second
+	}
+	if device.Interval > 0 {
+		return time.Duration(device.Interval) * time.Second
+	}
+	if device.CredentialProfileID != "" {
+		if p, ok := c.credentialProfile(device.CredentialProfileID); ok && p.Interval > 0 {
+			return time.Duration(p.Interval) * time.Second
+		}
+	}
+	if spec.interval > 0 {
+		return spec.interval
+	}
+	return c.config.Collector.Interval
+}
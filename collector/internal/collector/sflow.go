@@ -0,0 +1,379 @@
+package collector
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"time"
+)
+
+// sFlow v5 样本类型 (enterprise 0)
+const (
+	sflowFlowSample            = 1
+	sflowCounterSample         = 2
+	sflowExpandedFlowSample    = 3
+	sflowExpandedCounterSample = 4
+)
+
+// sFlow v5 记录类型 (enterprise 0)
+const (
+	sflowRawPacketHeader   = 1
+	sflowIPv4Data          = 3
+	sflowIPv6Data          = 4
+	sflowGenericIfCounters = 1
+)
+
+// sflowHeaderProtocolEthernet 原始报文头协议: ethernet-ISO88023
+const sflowHeaderProtocolEthernet = 1
+
+// sflowDatagram 解码后的 sFlow 数据报
+type sflowDatagram struct {
+	Agent    string
+	Flows    []flowRecord
+	Counters []IfStats
+}
+
+// sflowReader 按 XDR 规则读取 (大端, 不透明数据按4字节对齐)
+type sflowReader struct {
+	data []byte
+	err  error
+}
+
+var errSFlowShort = errors.New("sflow: truncated datagram")
+
+func (r *sflowReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	padded := (n + 3) &^ 3
+	if n < 0 || padded > len(r.data) {
+		r.err = errSFlowShort
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[padded:]
+	return b
+}
+
+func (r *sflowReader) uint32() uint32 {
+	b := r.bytes(4)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+func (r *sflowReader) uint64() uint64 {
+	b := r.bytes(8)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+// sub 读取带长度前缀的子结构
+func (r *sflowReader) sub(n int) *sflowReader {
+	b := r.bytes(n)
+	return &sflowReader{data: b, err: r.err}
+}
+
+// decodeSFlow 解码 sFlow v5 数据报
+// 流样本解析原始报文头得到 L2-L4 字段, 字节/包数按采样率放大; 计数器样本转换为接口统计
+func decodeSFlow(data []byte) (*sflowDatagram, error) {
+	r := &sflowReader{data: data}
+	if version := r.uint32(); version != 5 {
+		if r.err != nil {
+			return nil, r.err
+		}
+		return nil, fmt.Errorf("sflow: unsupported version %d", version)
+	}
+
+	dg := &sflowDatagram{}
+	switch addrType := r.uint32(); addrType {
+	case 1:
+		dg.Agent = net.IP(r.bytes(net.IPv4len)).String()
+	case 2:
+		dg.Agent = net.IP(r.bytes(net.IPv6len)).String()
+	default:
+		if r.err == nil {
+			return nil, fmt.Errorf("sflow: unknown agent address type %d", addrType)
+		}
+	}
+	r.uint32() // sub agent id
+	r.uint32() // sequence number
+	r.uint32() // uptime
+	count := r.uint32()
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	for i := uint32(0); i < count && len(r.data) > 0; i++ {
+		format := r.uint32()
+		s := r.sub(int(r.uint32()))
+		if r.err != nil {
+			return dg, r.err
+		}
+		if format>>12 != 0 {
+			continue // 厂商私有样本
+		}
+		switch format & 0xfff {
+		case sflowFlowSample, sflowExpandedFlowSample:
+			dg.Flows = append(dg.Flows, decodeSFlowFlowSample(s, format&0xfff == sflowExpandedFlowSample)...)
+		case sflowCounterSample, sflowExpandedCounterSample:
+			dg.Counters = append(dg.Counters, decodeSFlowCounterSample(s, format&0xfff == sflowExpandedCounterSample)...)
+		}
+	}
+	return dg, nil
+}
+
+// decodeSFlowFlowSample 解码 (扩展)流样本
+func decodeSFlowFlowSample(r *sflowReader, expanded bool) []flowRecord {
+	r.uint32() // sequence number
+	if expanded {
+		r.uint32() // source id type
+		r.uint32() // source id index
+	} else {
+		r.uint32() // source id
+	}
+	rate := uint64(r.uint32())
+	r.uint32() // sample pool
+	r.uint32() // drops
+
+	var in, out uint32
+	if expanded {
+		inFormat, inValue := r.uint32(), r.uint32()
+		outFormat, outValue := r.uint32(), r.uint32()
+		in, out = sflowInterface(inFormat, inValue), sflowInterface(outFormat, outValue)
+	} else {
+		inRaw, outRaw := r.uint32(), r.uint32()
+		in, out = sflowInterface(inRaw>>30, inRaw&0x3fffffff), sflowInterface(outRaw>>30, outRaw&0x3fffffff)
+	}
+	if rate == 0 {
+		rate = 1
+	}
+
+	rec := flowRecord{InIf: in, OutIf: out, Packets: rate}
+	decoded := false
+	count := r.uint32()
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		format := r.uint32()
+		fr := r.sub(int(r.uint32()))
+		if r.err != nil || format>>12 != 0 {
+			continue
+		}
+		switch format & 0xfff {
+		case sflowRawPacketHeader:
+			protocol := fr.uint32()
+			frameLength := fr.uint32()
+			fr.uint32() // stripped
+			header := fr.bytes(int(fr.uint32()))
+			if fr.err != nil {
+				continue
+			}
+			rec.Bytes = uint64(frameLength) * rate
+			if protocol == sflowHeaderProtocolEthernet {
+				decoded = decodeEthernet(header, &rec) || decoded
+			}
+		case sflowIPv4Data, sflowIPv6Data:
+			// 交换机未导出原始报文头时使用 IP 数据记录
+			if decoded {
+				continue
+			}
+			length := fr.uint32()
+			protocol := fr.uint32()
+			size := net.IPv4len
+			if format&0xfff == sflowIPv6Data {
+				size = net.IPv6len
+			}
+			src := append(net.IP(nil), fr.bytes(size)...)
+			dst := append(net.IP(nil), fr.bytes(size)...)
+			srcPort, dstPort := fr.uint32(), fr.uint32()
+			if fr.err != nil {
+				continue
+			}
+			rec.SrcAddr, rec.DstAddr = src, dst
+			rec.Protocol = uint8(protocol)
+			rec.SrcPort, rec.DstPort = uint16(srcPort), uint16(dstPort)
+			if rec.Bytes == 0 {
+				rec.Bytes = uint64(length) * rate
+			}
+			decoded = true
+		}
+	}
+	if rec.Bytes == 0 {
+		return nil
+	}
+	return []flowRecord{rec}
+}
+
+// sflowInterface 仅 format 0 表示 ifIndex, 0x3fffffff 表示未知
+func sflowInterface(format, value uint32) uint32 {
+	if format != 0 || value == 0x3fffffff {
+		return 0
+	}
+	return value
+}
+
+// decodeEthernet 解析以太网头 (含 802.1Q/QinQ) 及 IPv4/IPv6、TCP/UDP 头
+func decodeEthernet(frame []byte, rec *flowRecord) bool {
+	if len(frame) < 14 {
+		return false
+	}
+	rec.DstMAC = append(net.HardwareAddr(nil), frame[0:6]...)
+	rec.SrcMAC = append(net.HardwareAddr(nil), frame[6:12]...)
+	etherType := binary.BigEndian.Uint16(frame[12:])
+	payload := frame[14:]
+	for (etherType == 0x8100 || etherType == 0x88a8) && len(payload) >= 4 {
+		if rec.VLAN == 0 {
+			rec.VLAN = binary.BigEndian.Uint16(payload) & 0x0fff
+		}
+		etherType = binary.BigEndian.Uint16(payload[2:])
+		payload = payload[4:]
+	}
+	rec.EtherType = etherType
+
+	var l4 []byte
+	switch etherType {
+	case 0x0800:
+		if len(payload) < 20 {
+			return true
+		}
+		ihl := int(payload[0]&0x0f) * 4
+		rec.Protocol = payload[9]
+		rec.SrcAddr = append(net.IP(nil), payload[12:16]...)
+		rec.DstAddr = append(net.IP(nil), payload[16:20]...)
+		// 非首分片不含传输层头
+		if binary.BigEndian.Uint16(payload[6:])&0x1fff == 0 && ihl >= 20 && len(payload) >= ihl {
+			l4 = payload[ihl:]
+		}
+	case 0x86dd:
+		if len(payload) < 40 {
+			return true
+		}
+		rec.Protocol = payload[6]
+		rec.SrcAddr = append(net.IP(nil), payload[8:24]...)
+		rec.DstAddr = append(net.IP(nil), payload[24:40]...)
+		l4 = payload[40:]
+	default:
+		return true
+	}
+
+	switch rec.Protocol {
+	case 6, 17, 132:
+		if len(l4) >= 4 {
+			rec.SrcPort = binary.BigEndian.Uint16(l4)
+			rec.DstPort = binary.BigEndian.Uint16(l4[2:])
+		}
+	}
+	return true
+}
+
+// decodeSFlowCounterSample 解码 (扩展)计数器样本, 仅处理通用接口计数器
+func decodeSFlowCounterSample(r *sflowReader, expanded bool) []IfStats {
+	r.uint32() // sequence number
+	if expanded {
+		r.uint32() // source id type
+		r.uint32() // source id index
+	} else {
+		r.uint32() // source id
+	}
+
+	var stats []IfStats
+	count := r.uint32()
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		format := r.uint32()
+		cr := r.sub(int(r.uint32()))
+		if r.err != nil || format != sflowGenericIfCounters {
+			continue
+		}
+
+		// 字节计数器为64位, 包计数器为32位
+		ifs := IfStats{HighCapacity: true, pkts32: 0x3f}
+		ifs.Index = int(cr.uint32())
+		ifs.Type = int(cr.uint32())
+		ifs.Speed = cr.uint64()
+		cr.uint32() // ifDirection
+		status := cr.uint32()
+		ifs.InBytes = int64(cr.uint64())
+		ifs.InUcastPkts = int64(cr.uint32())
+		ifs.InMulticastPkts = int64(cr.uint32())
+		ifs.InBroadcastPkts = int64(cr.uint32())
+		ifs.InDiscards = int64(cr.uint32())
+		ifs.InErrors = int64(cr.uint32())
+		cr.uint32() // ifInUnknownProtos
+		ifs.OutBytes = int64(cr.uint64())
+		ifs.OutUcastPkts = int64(cr.uint32())
+		ifs.OutMulticastPkts = int64(cr.uint32())
+		ifs.OutBroadcastPkts = int64(cr.uint32())
+		ifs.OutDiscards = int64(cr.uint32())
+		ifs.OutErrors = int64(cr.uint32())
+		if cr.err != nil {
+			continue
+		}
+
+		// ifStatus: bit0 = ifAdminStatus up, bit1 = ifOperStatus up
+		ifs.AdminStatus, ifs.OperStatus = "down", "down"
+		if status&1 != 0 {
+			ifs.AdminStatus = "up"
+		}
+		if status&2 != 0 {
+			ifs.OperStatus = "up"
+		}
+		ifs.Status = ifs.OperStatus
+		stats = append(stats, ifs)
+	}
+	return stats
+}
+
+// applyInterfaceCounters 将 sFlow 计数器合并到设备样本, 无需 SNMP 轮询即可得到接口统计
+// 已有接口逐字段更新, 名称/描述/类型及光模块 DOM 等计数器中没有的字段保留 SNMP 采集的结果
+func (c *Collector) applyInterfaceCounters(agent string, counters []IfStats, now time.Time) {
+	device, ok := c.deviceByIP(agent)
+	if !ok {
+		return
+	}
+	c.rates.apply(device.ID, 0, now, counters)
+
+	c.pushSample(device.ID, now, func(sample *DeviceMetrics) bool {
+		interfaces := append([]IfStats(nil), sample.Interfaces...)
+		for _, ifs := range counters {
+			i := sort.Search(len(interfaces), func(i int) bool { return interfaces[i].Index >= ifs.Index })
+			if i < len(interfaces) && interfaces[i].Index == ifs.Index {
+				interfaces[i] = overlayCounters(interfaces[i], ifs)
+				continue
+			}
+			interfaces = append(interfaces, IfStats{})
+			copy(interfaces[i+1:], interfaces[i:])
+			interfaces[i] = ifs
+		}
+		sample.Interfaces = interfaces
+		return true
+	})
+}
+
+// overlayCounters 将 sFlow 计数器样本中的状态、计数器与速率覆盖到 prev
+func overlayCounters(prev, cs IfStats) IfStats {
+	if prev.Type == 0 {
+		prev.Type = cs.Type
+	}
+	if cs.Speed > 0 {
+		prev.Speed = cs.Speed
+	}
+	prev.AdminStatus, prev.OperStatus, prev.Status = cs.AdminStatus, cs.OperStatus, cs.Status
+
+	prev.HighCapacity, prev.pkts32 = cs.HighCapacity, cs.pkts32
+	prev.InBytes, prev.OutBytes = cs.InBytes, cs.OutBytes
+	prev.InUcastPkts, prev.OutUcastPkts = cs.InUcastPkts, cs.OutUcastPkts
+	prev.InMulticastPkts, prev.OutMulticastPkts = cs.InMulticastPkts, cs.OutMulticastPkts
+	prev.InBroadcastPkts, prev.OutBroadcastPkts = cs.InBroadcastPkts, cs.OutBroadcastPkts
+	prev.InDiscards, prev.OutDiscards = cs.InDiscards, cs.OutDiscards
+	prev.InErrors, prev.OutErrors = cs.InErrors, cs.OutErrors
+
+	prev.InBps, prev.OutBps = cs.InBps, cs.OutBps
+	prev.InPps, prev.OutPps = cs.InPps, cs.OutPps
+	prev.InUtilization, prev.OutUtilization = cs.InUtilization, cs.OutUtilization
+	prev.RateInterval = cs.RateInterval
+	return prev
+}
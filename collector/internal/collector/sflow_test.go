@@ -0,0 +1,311 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// staticProbe 以固定接口列表模拟 SNMP 探针 (含光模块 DOM)
+type staticProbe struct {
+	name       string
+	interfaces []IfStats
+}
+
+func (p *staticProbe) Name() string                  { return p.name }
+func (p *staticProbe) Applicable(device Device) bool { return true }
+func (p *staticProbe) Collect(ctx context.Context, device Device, sample *DeviceMetrics) error {
+	sample.Interfaces = append([]IfStats(nil), p.interfaces...)
+	return nil
+}
+
+func sampleInterface(c *Collector, deviceID string, index int) (IfStats, bool) {
+	c.scheduler.mu.Lock()
+	state := c.scheduler.states[deviceID]
+	c.scheduler.mu.Unlock()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	for _, ifs := range state.sample.Interfaces {
+		if ifs.Index == index {
+			return ifs, true
+		}
+	}
+	return IfStats{}, false
+}
+
+func TestSFlowCountersKeepDOM(t *testing.T) {
+	c := newTestCollector(t, testConfig)
+	device := Device{ID: "dev-1", IP: "192.0.2.1", Type: "switch"}
+	c.SetDevices([]Device{device})
+
+	counters := func(inBytes int64, oper string) []IfStats {
+		return []IfStats{{
+			Index: 1, Type: 6, Speed: 1e9, HighCapacity: true,
+			AdminStatus: "up", OperStatus: oper, Status: oper,
+			InBytes: inBytes, OutBytes: 2 * inBytes,
+		}}
+	}
+	now := time.Now()
+
+	// sFlow 计数器先于 SNMP 到达: 新建接口
+	c.applyInterfaceCounters(device.IP, counters(1000, "up"), now)
+	if ifs, ok := sampleInterface(c, device.ID, 1); !ok || ifs.InBytes != 1000 || ifs.Optical != nil {
+		t.Fatalf("interface after first sFlow sample = %+v", ifs)
+	}
+
+	// SNMP 轮询带回名称、类型与 DOM 读数
+	rx := -3.5
+	snmp := &staticProbe{name: "snmp", interfaces: []IfStats{{
+		Index: 1, Name: "Gi0/1", Descr: "GigabitEthernet0/1", Alias: "uplink", Type: 117, Speed: 1e10,
+		Status: "up", OperStatus: "up", AdminStatus: "up", InBytes: 1500, OutBytes: 3000,
+		Optical: &OpticalDOM{RxPower: &rx, Alarms: []OpticalAlarm{
+			{Metric: "rxPower", Value: rx, Threshold: -3, Severity: "warning", Direction: "low"},
+		}},
+	}}}
+	c.scheduler.mu.Lock()
+	state := c.scheduler.states[device.ID]
+	c.scheduler.mu.Unlock()
+	state.mu.Lock()
+	state.outcomes[snmp.name] = &probeOutcome{kind: "snmp"}
+	state.mu.Unlock()
+	c.runProbe(context.Background(), state, snmp)
+
+	if ifs, _ := sampleInterface(c, device.ID, 1); ifs.Optical == nil || ifs.Name != "Gi0/1" {
+		t.Fatalf("interface after SNMP poll = %+v", ifs)
+	}
+
+	// 之后的 sFlow 计数器仅更新计数器、状态与速率
+	c.applyInterfaceCounters(device.IP, counters(11000, "down"), now.Add(10*time.Second))
+	ifs, _ := sampleInterface(c, device.ID, 1)
+	if ifs.Optical == nil || ifs.Optical.RxPower == nil || *ifs.Optical.RxPower != rx || len(ifs.Optical.Alarms) != 1 {
+		t.Errorf("sFlow counters wiped DOM readings: %+v", ifs.Optical)
+	}
+	if ifs.Name != "Gi0/1" || ifs.Descr != "GigabitEthernet0/1" || ifs.Alias != "uplink" || ifs.Type != 117 {
+		t.Errorf("sFlow counters overwrote SNMP fields: %+v", ifs)
+	}
+	if ifs.InBytes != 11000 || ifs.OutBytes != 22000 || ifs.Status != "down" || ifs.Speed != 1e9 {
+		t.Errorf("sFlow counters not applied: %+v", ifs)
+	}
+	if ifs.RateInterval != 10 || ifs.InBps != 8000 {
+		t.Errorf("sFlow rates = %+v", ifs)
+	}
+}
+
+// xdrOpaque XDR 不透明数据: 长度 + 按4字节补齐的内容
+func xdrOpaque(b []byte) []byte {
+	return cat(be32(uint32(len(b))), b, make([]byte, (4-len(b)%4)%4))
+}
+
+func sflowPacket(agent string, samples ...[]byte) []byte {
+	return cat(be32(5), be32(1), net.ParseIP(agent).To4(), be32(0), be32(1), be32(1000), be32(uint32(len(samples))), cat(samples...))
+}
+
+// sflowRecord 样本或流/计数器记录: 格式 + 长度 + 内容
+func sflowRecord(format uint32, body ...[]byte) []byte {
+	return cat(be32(format), xdrOpaque(cat(body...)))
+}
+
+func sflowFlowSampleBody(expanded bool, rate, in, out uint32, records ...[]byte) []byte {
+	if expanded {
+		return cat(be32(1), be32(0), be32(in), be32(rate), be32(rate*10), be32(0),
+			be32(0), be32(in), be32(0), be32(out), be32(uint32(len(records))), cat(records...))
+	}
+	return cat(be32(1), be32(in), be32(rate), be32(rate*10), be32(0),
+		be32(in), be32(out), be32(uint32(len(records))), cat(records...))
+}
+
+func sflowRawHeader(frameLength uint32, header []byte) []byte {
+	return sflowRecord(sflowRawPacketHeader, be32(sflowHeaderProtocolEthernet), be32(frameLength), be32(4), xdrOpaque(header))
+}
+
+// ethFrame 以太网帧, tags 为 TPID/TCI 对
+func ethFrame(etherType uint16, payload []byte, tags ...uint16) []byte {
+	b := cat([]byte{0, 0, 0, 0, 0, 2}, []byte{0, 0, 0, 0, 0, 1})
+	for _, tag := range tags {
+		b = cat(b, be16(tag))
+	}
+	return cat(b, be16(etherType), payload)
+}
+
+func ipv4Packet(proto uint8, src, dst string, fragment uint16, l4 []byte) []byte {
+	h := make([]byte, 20)
+	h[0] = 0x45
+	copy(h[6:], be16(fragment))
+	h[9] = proto
+	copy(h[12:], net.ParseIP(src).To4())
+	copy(h[16:], net.ParseIP(dst).To4())
+	return cat(h, l4)
+}
+
+func ipv6Packet(next uint8, src, dst string, l4 []byte) []byte {
+	h := make([]byte, 40)
+	h[0] = 0x60
+	h[6] = next
+	copy(h[8:], net.ParseIP(src).To16())
+	copy(h[24:], net.ParseIP(dst).To16())
+	return cat(h, l4)
+}
+
+func ports(src, dst uint16) []byte { return cat(be16(src), be16(dst), make([]byte, 4)) }
+
+func TestDecodeSFlowFlowSample(t *testing.T) {
+	tests := []struct {
+		name     string
+		expanded bool
+		out      uint32
+		frame    []byte
+		want     flowRecord
+	}{
+		{
+			name:  "ipv4 tcp",
+			out:   2,
+			frame: ethFrame(0x0800, ipv4Packet(6, "10.0.0.1", "10.0.0.2", 0, ports(51000, 443))),
+			want: flowRecord{EtherType: 0x0800, SrcAddr: net.ParseIP("10.0.0.1"), DstAddr: net.ParseIP("10.0.0.2"),
+				Protocol: 6, SrcPort: 51000, DstPort: 443, OutIf: 2},
+		},
+		{
+			name:  "802.1q udp",
+			out:   2,
+			frame: ethFrame(0x0800, ipv4Packet(17, "10.0.0.1", "10.0.0.2", 0, ports(5353, 53)), 0x8100, 0x2064),
+			want: flowRecord{VLAN: 100, EtherType: 0x0800, SrcAddr: net.ParseIP("10.0.0.1"), DstAddr: net.ParseIP("10.0.0.2"),
+				Protocol: 17, SrcPort: 5353, DstPort: 53, OutIf: 2},
+		},
+		{
+			name:  "qinq uses outer vlan",
+			out:   2,
+			frame: ethFrame(0x0800, ipv4Packet(6, "10.0.0.1", "10.0.0.2", 0, ports(1000, 22)), 0x88a8, 200, 0x8100, 300),
+			want: flowRecord{VLAN: 200, EtherType: 0x0800, SrcAddr: net.ParseIP("10.0.0.1"), DstAddr: net.ParseIP("10.0.0.2"),
+				Protocol: 6, SrcPort: 1000, DstPort: 22, OutIf: 2},
+		},
+		{
+			name:     "expanded ipv6 udp",
+			expanded: true,
+			out:      7,
+			frame:    ethFrame(0x86dd, ipv6Packet(17, "2001:db8::1", "2001:db8::2", ports(40000, 4789))),
+			want: flowRecord{EtherType: 0x86dd, SrcAddr: net.ParseIP("2001:db8::1"), DstAddr: net.ParseIP("2001:db8::2"),
+				Protocol: 17, SrcPort: 40000, DstPort: 4789, OutIf: 7},
+		},
+		{
+			name:  "non-first fragment has no ports",
+			out:   2,
+			frame: ethFrame(0x0800, ipv4Packet(17, "10.0.0.1", "10.0.0.2", 185, ports(5353, 53))),
+			want: flowRecord{EtherType: 0x0800, SrcAddr: net.ParseIP("10.0.0.1"), DstAddr: net.ParseIP("10.0.0.2"),
+				Protocol: 17, OutIf: 2},
+		},
+		{
+			name:  "output discarded",
+			out:   1<<30 | 3,
+			frame: ethFrame(0x0806, make([]byte, 28)),
+			want:  flowRecord{EtherType: 0x0806},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format := uint32(sflowFlowSample)
+			if tt.expanded {
+				format = sflowExpandedFlowSample
+			}
+			sample := sflowRecord(format, sflowFlowSampleBody(tt.expanded, 256, 1, tt.out, sflowRawHeader(1000, tt.frame)))
+			dg, err := decodeSFlow(sflowPacket("192.0.2.1", sample))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dg.Agent != "192.0.2.1" || len(dg.Flows) != 1 {
+				t.Fatalf("datagram = %+v", dg)
+			}
+
+			got, want := dg.Flows[0], tt.want
+			// 字节/包数按采样率放大
+			want.InIf, want.Bytes, want.Packets = 1, 256000, 256
+			if got.SrcMAC.String() != "00:00:00:00:00:01" || got.DstMAC.String() != "00:00:00:00:00:02" {
+				t.Errorf("macs = %v -> %v", got.SrcMAC, got.DstMAC)
+			}
+			if !got.SrcAddr.Equal(want.SrcAddr) || !got.DstAddr.Equal(want.DstAddr) {
+				t.Errorf("addresses = %v -> %v, want %v -> %v", got.SrcAddr, got.DstAddr, want.SrcAddr, want.DstAddr)
+			}
+			got.SrcMAC, got.DstMAC, got.SrcAddr, got.DstAddr = nil, nil, nil, nil
+			want.SrcAddr, want.DstAddr = nil, nil
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("flow = %+v\nwant %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestDecodeSFlowCounterSample(t *testing.T) {
+	generic := sflowRecord(sflowGenericIfCounters,
+		be32(3), be32(6), be64(10e9), be32(1), be32(3), // ifIndex, ifType, ifSpeed, ifDirection, ifStatus
+		be64(1<<33), be32(100), be32(20), be32(5), be32(1), be32(2), be32(0),
+		be64(1<<34), be32(200), be32(40), be32(10), be32(3), be32(4),
+		be32(0)) // ifPromiscuousMode
+	ethernet := sflowRecord(2, make([]byte, 52)) // 以太网计数器, 忽略
+	vendor := sflowRecord(9<<12|1, make([]byte, 8))
+	sample := sflowRecord(sflowCounterSample, be32(1), be32(3), be32(3), ethernet, vendor, generic)
+
+	down := sflowRecord(sflowGenericIfCounters,
+		be32(4), be32(6), be64(1e9), be32(1), be32(1),
+		be64(0), make([]byte, 24), be64(0), make([]byte, 24))
+	expanded := sflowRecord(sflowExpandedCounterSample, be32(2), be32(0), be32(4), be32(1), down)
+
+	dg, err := decodeSFlow(sflowPacket("192.0.2.1", sample, expanded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dg.Counters) != 2 || len(dg.Flows) != 0 {
+		t.Fatalf("datagram = %+v", dg)
+	}
+	ifs := dg.Counters[0]
+	if ifs.Index != 3 || ifs.Type != 6 || ifs.Speed != 10e9 || ifs.Status != "up" || ifs.AdminStatus != "up" ||
+		!ifs.HighCapacity || ifs.pkts32 != 0x3f ||
+		ifs.InBytes != 1<<33 || ifs.InUcastPkts != 100 || ifs.InMulticastPkts != 20 || ifs.InBroadcastPkts != 5 ||
+		ifs.InDiscards != 1 || ifs.InErrors != 2 ||
+		ifs.OutBytes != 1<<34 || ifs.OutUcastPkts != 200 || ifs.OutMulticastPkts != 40 || ifs.OutBroadcastPkts != 10 ||
+		ifs.OutDiscards != 3 || ifs.OutErrors != 4 {
+		t.Errorf("counters = %+v", ifs)
+	}
+	if ifs := dg.Counters[1]; ifs.Index != 4 || ifs.AdminStatus != "up" || ifs.OperStatus != "down" || ifs.Status != "down" {
+		t.Errorf("counters = %+v", ifs)
+	}
+}
+
+func TestDecodeSFlowTruncated(t *testing.T) {
+	frame := ethFrame(0x0800, ipv4Packet(6, "10.0.0.1", "10.0.0.2", 0, ports(51000, 443)))
+	flow := sflowRecord(sflowFlowSample, sflowFlowSampleBody(false, 256, 1, 2, sflowRawHeader(1000, frame)))
+	packet := sflowPacket("192.0.2.1", flow, flow)
+
+	// 报文头截断
+	for _, n := range []int{0, 3, 10, 27} {
+		if _, err := decodeSFlow(packet[:n]); err == nil {
+			t.Errorf("datagram truncated to %d bytes decoded", n)
+		}
+	}
+
+	// 第二个样本截断: 保留已解码的样本
+	dg, err := decodeSFlow(packet[:len(packet)-8])
+	if !errors.Is(err, errSFlowShort) {
+		t.Errorf("err = %v, want %v", err, errSFlowShort)
+	}
+	if dg == nil || len(dg.Flows) != 1 {
+		t.Errorf("datagram = %+v", dg)
+	}
+
+	// 样本内部记录长度超出样本: 丢弃该记录
+	short := sflowRecord(sflowFlowSample, sflowFlowSampleBody(false, 256, 1, 2, be32(sflowRawPacketHeader), be32(1000)))
+	if dg, err := decodeSFlow(sflowPacket("192.0.2.1", short)); err != nil || len(dg.Flows) != 0 {
+		t.Errorf("datagram = %+v, err = %v", dg, err)
+	}
+
+	// 截断的以太网头
+	var rec flowRecord
+	if decodeEthernet(frame[:13], &rec) {
+		t.Error("truncated ethernet header decoded")
+	}
+	rec = flowRecord{}
+	if !decodeEthernet(frame[:30], &rec) || rec.EtherType != 0x0800 || rec.SrcAddr != nil {
+		t.Errorf("truncated ipv4 header = %+v", rec)
+	}
+}
@@ -0,0 +1,422 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/netvis/collector/internal/config"
+)
+
+func init() {
+	RegisterProbe("snmp", newSNMPProbe)
+}
+
+// snmpProbe SNMP采集 (系统资源、接口、邻居、终端定位表)
+type snmpProbe struct {
+	c     *Collector
+	name  string
+	types deviceTypeFilter
+}
+
+func newSNMPProbe(c *Collector, cfg config.ProbeConfig) (Probe, error) {
+	return &snmpProbe{c: c, name: cfg.Name, types: newDeviceTypeFilter(cfg.DeviceTypes)}, nil
+}
+
+func (p *snmpProbe) Name() string { return p.name }
+
+func (p *snmpProbe) Applicable(device Device) bool {
+	return p.types.match(device.Type) && p.c.snmpEnabled(device)
+}
+
+func (p *snmpProbe) Collect(ctx context.Context, device Device, sample *DeviceMetrics) error {
+	snmpMetrics, err := p.c.collectSNMP(device)
+	if err != nil {
+		return err
+	}
+
+	sample.CPUUsage = snmpMetrics.CPUUsage
+	sample.MemoryUsage = snmpMetrics.MemoryUsage
+	sample.Temperature = snmpMetrics.Temperature
+	sample.Storage = snmpMetrics.Storage
+	sample.Uptime = snmpMetrics.Uptime
+	sample.Interfaces = snmpMetrics.Interfaces
+
+	if snmpMetrics.System != nil && snmpMetrics.System.ObjectID != "" {
+		p.c.reportFingerprint(device, *snmpMetrics.System)
+	}
+
+	// 邻居表采集成功即上报 (包括空表, 以便服务端清除已消失的链路)
+	if snmpMetrics.Neighbors != nil {
+		p.c.reportTopology(device, snmpMetrics.Neighbors)
+	}
+	// 终端定位表以 ARP + 转发表整体比较增量, 任一表遍历失败 (nil) 时本周期不上报
+	if snmpMetrics.ARP != nil && snmpMetrics.FDB != nil {
+		p.c.reportEndpoints(device, snmpMetrics.ARP, snmpMetrics.FDB)
+	}
+	return nil
+}
+
+// snmpEnabled 判断设备是否具备SNMP采集条件
+func (c *Collector) snmpEnabled(device Device) bool {
+	settings := c.resolveSNMPSettings(device)
+	if parseSNMPVersion(settings.Version) == gosnmp.Version3 {
+		return settings.V3.Username != ""
+	}
+	return settings.Community != ""
+}
+
+// newSNMPClient 根据凭据模板、设备及全局配置构建SNMP客户端
+func (c *Collector) newSNMPClient(device Device) (*gosnmp.GoSNMP, error) {
+	settings := c.resolveSNMPSettings(device)
+
+	snmp := &gosnmp.GoSNMP{
+		Target:         device.IP,
+		Port:           uint16(settings.Port),
+		Version:        parseSNMPVersion(settings.Version),
+		Timeout:        settings.Timeout,
+		Retries:        max(settings.Retries, 0),
+		MaxRepetitions: uint32(settings.MaxRepetitions),
+	}
+
+	if snmp.Version != gosnmp.Version3 {
+		snmp.Community = settings.Community
+		return snmp, nil
+	}
+
+	usm, flags, err := buildUSM(settings.V3)
+	if err != nil {
+		return nil, fmt.Errorf("snmpv3 config for %s: %w", device.IP, err)
+	}
+
+	snmp.SecurityModel = gosnmp.UserSecurityModel
+	snmp.MsgFlags = flags
+	snmp.ContextName = settings.V3.ContextName
+	snmp.SecurityParameters = usm
+
+	// 复用已发现的引擎参数, 避免每次采集都进行引擎ID发现
+	c.engines.load(snmp)
+
+	return snmp, nil
+}
+
+// parseSNMPVersion 解析版本字符串, 未知值按 v2c 处理
+func parseSNMPVersion(version string) gosnmp.SnmpVersion {
+	switch strings.TrimPrefix(strings.ToLower(version), "v") {
+	case "1":
+		return gosnmp.Version1
+	case "3":
+		return gosnmp.Version3
+	default:
+		return gosnmp.Version2c
+	}
+}
+
+// buildUSM 根据配置构建USM安全参数
+func buildUSM(v3 config.SNMPv3Config) (*gosnmp.UsmSecurityParameters, gosnmp.SnmpV3MsgFlags, error) {
+	if v3.Username == "" {
+		return nil, 0, fmt.Errorf("username is required")
+	}
+
+	usm := &gosnmp.UsmSecurityParameters{UserName: v3.Username}
+
+	level := strings.ToLower(v3.SecurityLevel)
+	if level == "" {
+		// 未显式配置时根据口令推断安全级别
+		switch {
+		case v3.PrivPassphrase != "":
+			level = "authpriv"
+		case v3.AuthPassphrase != "":
+			level = "authnopriv"
+		default:
+			level = "noauthnopriv"
+		}
+	}
+
+	var flags gosnmp.SnmpV3MsgFlags
+	switch level {
+	case "noauthnopriv":
+		flags = gosnmp.NoAuthNoPriv
+		return usm, flags, nil
+	case "authnopriv":
+		flags = gosnmp.AuthNoPriv
+	case "authpriv":
+		flags = gosnmp.AuthPriv
+	default:
+		return nil, 0, fmt.Errorf("unknown security level %q", v3.SecurityLevel)
+	}
+
+	auth, err := parseAuthProtocol(v3.AuthProtocol)
+	if err != nil {
+		return nil, 0, err
+	}
+	usm.AuthenticationProtocol = auth
+	usm.AuthenticationPassphrase = v3.AuthPassphrase
+
+	if flags == gosnmp.AuthPriv {
+		priv, err := parsePrivProtocol(v3.PrivProtocol)
+		if err != nil {
+			return nil, 0, err
+		}
+		usm.PrivacyProtocol = priv
+		usm.PrivacyPassphrase = v3.PrivPassphrase
+	}
+
+	return usm, flags, nil
+}
+
+// normalizeProtocol 统一协议名写法 (忽略大小写及分隔符)
+func normalizeProtocol(name string) string {
+	name = strings.ToUpper(name)
+	return strings.NewReplacer("-", "", "_", "", " ", "").Replace(name)
+}
+
+// parseAuthProtocol 解析认证协议, 默认 SHA
+func parseAuthProtocol(name string) (gosnmp.SnmpV3AuthProtocol, error) {
+	switch normalizeProtocol(name) {
+	case "MD5":
+		return gosnmp.MD5, nil
+	case "", "SHA", "SHA1":
+		return gosnmp.SHA, nil
+	case "SHA224":
+		return gosnmp.SHA224, nil
+	case "SHA256":
+		return gosnmp.SHA256, nil
+	case "SHA384":
+		return gosnmp.SHA384, nil
+	case "SHA512":
+		return gosnmp.SHA512, nil
+	default:
+		return gosnmp.NoAuth, fmt.Errorf("unsupported auth protocol %q", name)
+	}
+}
+
+// parsePrivProtocol 解析加密协议, 默认 AES(128)
+func parsePrivProtocol(name string) (gosnmp.SnmpV3PrivProtocol, error) {
+	switch normalizeProtocol(name) {
+	case "DES":
+		return gosnmp.DES, nil
+	case "", "AES", "AES128":
+		return gosnmp.AES, nil
+	case "AES192":
+		return gosnmp.AES192, nil
+	case "AES256":
+		return gosnmp.AES256, nil
+	case "AES192C":
+		return gosnmp.AES192C, nil
+	case "AES256C":
+		return gosnmp.AES256C, nil
+	default:
+		return gosnmp.NoPriv, fmt.Errorf("unsupported priv protocol %q", name)
+	}
+}
+
+// engineInfo 已发现的SNMPv3权威引擎参数
+type engineInfo struct {
+	engineID   string
+	boots      uint32
+	engineTime uint32
+	seenAt     time.Time
+}
+
+// engineCache 按目标缓存SNMPv3引擎ID
+type engineCache struct {
+	mu      sync.Mutex
+	engines map[string]engineInfo
+}
+
+func newEngineCache() *engineCache {
+	return &engineCache{engines: make(map[string]engineInfo)}
+}
+
+func engineKey(snmp *gosnmp.GoSNMP) string {
+	return fmt.Sprintf("%s:%d", snmp.Target, snmp.Port)
+}
+
+// load 将缓存的引擎参数写入客户端, 引擎时间按本地流逝时间推算
+func (e *engineCache) load(snmp *gosnmp.GoSNMP) {
+	usm, ok := snmp.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+	if !ok {
+		return
+	}
+
+	e.mu.Lock()
+	info, found := e.engines[engineKey(snmp)]
+	e.mu.Unlock()
+	if !found {
+		return
+	}
+
+	usm.AuthoritativeEngineID = info.engineID
+	usm.AuthoritativeEngineBoots = info.boots
+	usm.AuthoritativeEngineTime = info.engineTime + uint32(time.Since(info.seenAt).Seconds())
+}
+
+// save 在一次会话结束后记录引擎参数
+func (e *engineCache) save(snmp *gosnmp.GoSNMP) {
+	if snmp.Version != gosnmp.Version3 {
+		return
+	}
+	usm, ok := snmp.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+	if !ok || usm.AuthoritativeEngineID == "" {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.engines[engineKey(snmp)] = engineInfo{
+		engineID:   usm.AuthoritativeEngineID,
+		boots:      usm.AuthoritativeEngineBoots,
+		engineTime: usm.AuthoritativeEngineTime,
+		seenAt:     time.Now(),
+	}
+}
+
+// invalidate 丢弃目标的缓存引擎参数, 并清空客户端中的引擎ID及本地化密钥,
+// 下一次请求时重新进行引擎发现
+func (e *engineCache) invalidate(snmp *gosnmp.GoSNMP) {
+	e.mu.Lock()
+	delete(e.engines, engineKey(snmp))
+	e.mu.Unlock()
+
+	usm, ok := snmp.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+	if !ok {
+		return
+	}
+	usm.AuthoritativeEngineID = ""
+	usm.AuthoritativeEngineBoots = 0
+	usm.AuthoritativeEngineTime = 0
+	usm.SecretKey = nil
+	usm.PrivacyKey = nil
+	snmp.ContextEngineID = ""
+}
+
+// get 执行 Get 请求; 设备以 notInTimeWindow / unknownEngineID 报告拒绝缓存的引擎参数时
+// (设备重启、引擎ID变更), 丢弃缓存并重新发现后重试一次
+func (e *engineCache) get(snmp *gosnmp.GoSNMP, oids []string) (*gosnmp.SnmpPacket, error) {
+	result, err := snmp.Get(oids)
+	if err != nil && isEngineReport(err) {
+		e.invalidate(snmp)
+		result, err = snmp.Get(oids)
+	}
+	return result, err
+}
+
+// isEngineReport 判断错误是否为引擎参数失效的 Report PDU
+func isEngineReport(err error) bool {
+	return errors.Is(err, gosnmp.ErrNotInTimeWindow) || errors.Is(err, gosnmp.ErrUnknownEngineID)
+}
+
+// SNMPMetrics SNMP采集结果
+type SNMPMetrics struct {
+	CPUUsage    float64
+	MemoryUsage float64
+	Temperature float64
+	Storage     []StorageUsage
+	Uptime      int64
+	Interfaces  []IfStats
+	Neighbors   []Neighbor
+	ARP         []ARPEntry
+	FDB         []FDBEntry
+	System      *SystemInfo // 系统组
+}
+
+// SNMP OID 常量
+const (
+	// 系统信息
+	oidSysUpTime = ".1.3.6.1.2.1.1.3.0" // sysUpTimeInstance (timeticks)
+)
+
+// collectSNMP 执行SNMP采集
+func (c *Collector) collectSNMP(device Device) (*SNMPMetrics, error) {
+	// 创建SNMP客户端
+	snmp, err := c.newSNMPClient(device)
+	if err != nil {
+		return nil, err
+	}
+
+	err = snmp.Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer snmp.Conn.Close()
+	defer c.engines.save(snmp)
+
+	metrics := &SNMPMetrics{}
+
+	// 获取系统组 (运行时间、sysObjectID 用于选择厂商资源 MIB, 其余用于设备指纹)
+	var uptimeTicks uint32
+	var system SystemInfo
+	// UDP 的 Connect 不会失败, 系统组 Get 失败即视为设备 SNMP 不可达 (超时、团体名/USM 凭据错误)
+	result, err := c.engines.get(snmp, []string{oidSysUpTime, oidSysObjectID, oidSysDescr, oidSysName, oidSysContact, oidSysLocation})
+	if err != nil {
+		return nil, fmt.Errorf("snmp get system group: %w", err)
+	}
+	metrics.System = &system
+	for _, pdu := range result.Variables {
+		switch pdu.Name {
+		case oidSysUpTime:
+			if uptime, ok := pdu.Value.(uint32); ok {
+				uptimeTicks = uptime
+				metrics.Uptime = int64(uptime) / 100 // timeticks to seconds
+			}
+		case oidSysObjectID:
+			system.ObjectID, _ = pdu.Value.(string)
+		case oidSysDescr:
+			system.Descr = strings.TrimSpace(pduString(pdu))
+		case oidSysName:
+			system.Name = strings.TrimSpace(pduString(pdu))
+		case oidSysContact:
+			system.Contact = strings.TrimSpace(pduString(pdu))
+		case oidSysLocation:
+			system.Location = strings.TrimSpace(pduString(pdu))
+		}
+	}
+
+	// CPU / 内存 / 温度
+	usage := c.collectResources(snmp, system.ObjectID)
+	metrics.CPUUsage = usage.cpu
+	metrics.MemoryUsage = usage.memory
+	metrics.Temperature = usage.temperature
+	metrics.Storage = usage.storage
+
+	// 获取接口信息
+	interfaces, err := c.collectInterfaces(snmp)
+	if err != nil {
+		return nil, fmt.Errorf("snmp walk interfaces: %w", err)
+	}
+	c.rates.apply(device.ID, uptimeTicks, time.Now(), interfaces)
+	c.collectDOM(snmp, system.ObjectID, interfaces)
+	metrics.Interfaces = interfaces
+
+	// LLDP采集 (Topology Discovery)
+	ifNames := make(map[int]string, len(interfaces))
+	for _, ifs := range interfaces {
+		ifNames[ifs.Index] = ifs.Name
+	}
+	neighbors, err := c.collectLLDP(snmp, ifNames)
+	if err == nil {
+		metrics.Neighbors = neighbors
+	}
+
+	// CDP采集 (老旧Cisco设备仅支持CDP), 与LLDP结果合并上报
+	cdpNeighbors, err := c.collectCDP(snmp, ifNames)
+	if err == nil {
+		if metrics.Neighbors == nil {
+			metrics.Neighbors = make([]Neighbor, 0, len(cdpNeighbors))
+		}
+		metrics.Neighbors = append(metrics.Neighbors, cdpNeighbors...)
+	}
+
+	// ARP及MAC转发表 (终端定位)
+	if collectsEndpoints(device.Type) {
+		metrics.ARP = c.collectARP(snmp, ifNames)
+		metrics.FDB = c.collectFDB(snmp, ifNames)
+	}
+
+	return metrics, nil
+}
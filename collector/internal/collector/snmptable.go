@@ -0,0 +1,247 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// snmpTable 按行索引组织的SNMP表: 索引后缀 -> 列OID -> 值
+type snmpTable map[string]map[string]gosnmp.SnmpPDU
+
+// bulkVarbindBudget 单个GETBULK响应的期望变量绑定数上限, 避免响应超出设备报文限制
+const bulkVarbindBudget = 120
+
+// errWalkIncomplete 表遍历中途失败 (如大表超时), 已取回的行不完整
+var errWalkIncomplete = errors.New("snmp table walk incomplete")
+
+// walkTable 并行遍历多列 (lockstep GETBULK), 按行索引关联各列
+// 每次请求携带所有未结束的列, 一个往返即可取回 maxRepetitions 行
+// 任一请求失败即返回错误 (中途失败时包装 errWalkIncomplete), 不返回部分结果, 避免调用方把缺失的行当作已删除
+func walkTable(snmp *gosnmp.GoSNMP, columns ...string) (snmpTable, error) {
+	table := make(snmpTable)
+	if snmp.Version == gosnmp.Version1 {
+		if err := walkTableV1(snmp, table, columns); err != nil {
+			return nil, err
+		}
+		return table, nil
+	}
+
+	maxRep := snmp.MaxRepetitions
+	if maxRep == 0 {
+		maxRep = 25
+	}
+
+	// 每列的当前游标; 列结束后从中移除
+	cursor := make(map[string]string, len(columns))
+	active := make([]string, 0, len(columns))
+	for _, col := range columns {
+		cursor[col] = col
+		active = append(active, col)
+	}
+
+	for len(active) > 0 {
+		oids := make([]string, len(active))
+		for i, col := range active {
+			oids[i] = cursor[col]
+		}
+
+		// 列越多每次取回的行越少, 保持响应大小稳定
+		reps := maxRep
+		if budget := uint32(bulkVarbindBudget / len(active)); budget < reps {
+			reps = max(budget, 1)
+		}
+
+		result, err := snmp.GetBulk(oids, 0, reps)
+		for err == nil && result.Error == gosnmp.TooBig && reps > 1 {
+			reps /= 2
+			result, err = snmp.GetBulk(oids, 0, reps)
+		}
+		if err != nil {
+			if len(table) > 0 {
+				return nil, fmt.Errorf("%w after %d rows: %v", errWalkIncomplete, len(table), err)
+			}
+			return nil, err
+		}
+		if len(result.Variables) == 0 {
+			break
+		}
+
+		done := make(map[string]bool)
+		for i, pdu := range result.Variables {
+			col := active[i%len(active)]
+			if done[col] {
+				continue
+			}
+			if pdu.Type == gosnmp.EndOfMibView || pdu.Type == gosnmp.NoSuchObject ||
+				pdu.Type == gosnmp.NoSuchInstance || !strings.HasPrefix(pdu.Name, col+".") {
+				done[col] = true
+				continue
+			}
+			// 防止异常设备返回不递增的OID导致死循环
+			if !oidGreater(pdu.Name, cursor[col]) {
+				done[col] = true
+				continue
+			}
+			cursor[col] = pdu.Name
+			table.set(strings.TrimPrefix(pdu.Name, col+"."), col, pdu)
+		}
+
+		next := active[:0]
+		for _, col := range active {
+			if !done[col] {
+				next = append(next, col)
+			}
+		}
+		active = next
+	}
+
+	return table, nil
+}
+
+// walkTableV1 SNMPv1 不支持GETBULK, 逐列 GETNEXT 遍历, 任一列失败即返回错误
+func walkTableV1(snmp *gosnmp.GoSNMP, table snmpTable, columns []string) error {
+	for _, col := range columns {
+		err := snmp.Walk(col, func(pdu gosnmp.SnmpPDU) error {
+			table.set(strings.TrimPrefix(pdu.Name, col+"."), col, pdu)
+			return nil
+		})
+		if err != nil {
+			if len(table) > 0 {
+				return fmt.Errorf("%w after %d rows: %v", errWalkIncomplete, len(table), err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (t snmpTable) set(index, column string, pdu gosnmp.SnmpPDU) {
+	row, ok := t[index]
+	if !ok {
+		row = make(map[string]gosnmp.SnmpPDU)
+		t[index] = row
+	}
+	row[column] = pdu
+}
+
+// oidGreater 按子标识符逐级比较OID
+func oidGreater(a, b string) bool {
+	pa := strings.Split(strings.Trim(a, "."), ".")
+	pb := strings.Split(strings.Trim(b, "."), ".")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		x, _ := strconv.ParseUint(pa[i], 10, 64)
+		y, _ := strconv.ParseUint(pb[i], 10, 64)
+		if x != y {
+			return x > y
+		}
+	}
+	return len(pa) > len(pb)
+}
+
+// parseIndex 将OID索引后缀解析为整数序列
+func parseIndex(index string) ([]int, error) {
+	parts := strings.Split(strings.Trim(index, "."), ".")
+	out := make([]int, len(parts))
+	for i, p := range parts {
+		v, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid index %q", index)
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+// pduString 读取字符串类型的值
+func pduString(pdu gosnmp.SnmpPDU) string {
+	switch v := pdu.Value.(type) {
+	case []byte:
+		return strings.TrimRight(string(v), "\x00")
+	case string:
+		return v
+	default:
+		return ""
+	}
+}
+
+// pduBytes 读取OCTET STRING原始字节
+func pduBytes(pdu gosnmp.SnmpPDU) []byte {
+	switch v := pdu.Value.(type) {
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	default:
+		return nil
+	}
+}
+
+// pduInt64 读取整数/计数器类型的值
+func pduInt64(pdu gosnmp.SnmpPDU) (int64, bool) {
+	if pdu.Value == nil {
+		return 0, false
+	}
+	switch pdu.Type {
+	case gosnmp.Integer, gosnmp.Counter32, gosnmp.Gauge32, gosnmp.TimeTicks,
+		gosnmp.Counter64, gosnmp.Uinteger32:
+		return gosnmp.ToBigInt(pdu.Value).Int64(), true
+	default:
+		return 0, false
+	}
+}
+
+// pduUint64 读取无符号计数器的值
+func pduUint64(pdu gosnmp.SnmpPDU) (uint64, bool) {
+	if pdu.Value == nil {
+		return 0, false
+	}
+	switch pdu.Type {
+	case gosnmp.Integer, gosnmp.Counter32, gosnmp.Gauge32, gosnmp.TimeTicks,
+		gosnmp.Counter64, gosnmp.Uinteger32:
+		v := gosnmp.ToBigInt(pdu.Value)
+		if !v.IsUint64() {
+			return 0, false
+		}
+		return v.Uint64(), true
+	default:
+		return 0, false
+	}
+}
+
+// rowString 读取行中某列的字符串值
+func (t snmpTable) rowString(index, column string) string {
+	if pdu, ok := t[index][column]; ok {
+		return pduString(pdu)
+	}
+	return ""
+}
+
+// rowInt 读取行中某列的整数值
+func (t snmpTable) rowInt(index, column string) (int64, bool) {
+	if pdu, ok := t[index][column]; ok {
+		return pduInt64(pdu)
+	}
+	return 0, false
+}
+
+// rowUint 读取行中某列的无符号值
+func (t snmpTable) rowUint(index, column string) (uint64, bool) {
+	if pdu, ok := t[index][column]; ok {
+		return pduUint64(pdu)
+	}
+	return 0, false
+}
+
+// counter 按顺序读取第一个存在的计数器列 (通常 HC 列在前, 32位列兜底)
+func (t snmpTable) counter(index string, columns ...string) int64 {
+	for _, col := range columns {
+		if v, ok := t.rowInt(index, col); ok {
+			return v
+		}
+	}
+	return 0
+}
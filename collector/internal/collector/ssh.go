@@ -0,0 +1,337 @@
+package collector
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/netvis/collector/internal/config"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func init() {
+	RegisterProbe("ssh", newSSHProbe)
+}
+
+// ConfigSnapshot 设备运行配置快照
+// Hash 为去掉时间戳等易变行后的 MD5, 与上次成功上报相同时不再发送
+type ConfigSnapshot struct {
+	CollectorID string            `json:"collectorId"`
+	DeviceID    string            `json:"deviceId"`
+	IP          string            `json:"ip"`
+	Vendor      string            `json:"vendor"`
+	Type        string            `json:"type"` // running
+	Content     string            `json:"content"`
+	Size        int               `json:"size"`
+	Hash        string            `json:"hash"`
+	Outputs     map[string]string `json:"outputs,omitempty"` // 附带采集的命令输出
+	CollectedAt time.Time         `json:"collectedAt"`
+}
+
+// sshLoginOptions SSH 登录参数, ssh 与 netconf 探针共用, 凭据优先取自设备引用的凭据模板
+type sshLoginOptions struct {
+	Port           int           `yaml:"port"`
+	Timeout        time.Duration `yaml:"timeout"`    // 登录及等待单次响应的超时
+	KnownHosts     string        `yaml:"knownHosts"` // known_hosts 文件, 未配置时须显式开启 insecureIgnoreHostKey
+	Username       string        `yaml:"username"`
+	Password       string        `yaml:"password"`
+	PrivateKeyFile string        `yaml:"privateKeyFile"`
+	Passphrase     string        `yaml:"passphrase"`
+
+	InsecureIgnoreHostKey bool `yaml:"insecureIgnoreHostKey"` // 不校验主机密钥 (存在中间人风险)
+	LegacyAlgorithms      bool `yaml:"legacyAlgorithms"`      // 允许老设备仅支持的 diffie-hellman-group1-sha1、CBC 加密及 ssh-rsa/ssh-dss 主机密钥
+}
+
+// sshProbeOptions SSH 采集参数
+type sshProbeOptions struct {
+	sshLoginOptions `yaml:",inline"`
+	Vendor          string   `yaml:"vendor"`   // 覆盖 Device.Vendor, 为空时按设备厂商或自动识别
+	Commands        []string `yaml:"commands"` // 覆盖厂商默认的附带采集命令
+}
+
+// sshProbe 通过 SSH 登录设备执行厂商命令并备份运行配置
+type sshProbe struct {
+	c     *Collector
+	name  string
+	types deviceTypeFilter
+	opts  sshProbeOptions
+	login *sshLogin
+}
+
+func newSSHProbe(c *Collector, cfg config.ProbeConfig) (Probe, error) {
+	opts := sshProbeOptions{sshLoginOptions: sshLoginOptions{Port: 22, Timeout: 30 * time.Second}}
+	if err := cfg.DecodeOptions(&opts); err != nil {
+		return nil, fmt.Errorf("decode ssh probe options: %w", err)
+	}
+	if opts.Vendor != "" && lookupVendorCLI(opts.Vendor) == nil {
+		return nil, fmt.Errorf("unsupported ssh vendor %q", opts.Vendor)
+	}
+	login, err := newSSHLogin(opts.sshLoginOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sshProbe{
+		c:     c,
+		name:  cfg.Name,
+		types: newDeviceTypeFilter(cfg.DeviceTypes),
+		opts:  opts,
+		login: login,
+	}, nil
+}
+
+func (p *sshProbe) Name() string { return p.name }
+
+func (p *sshProbe) Applicable(device Device) bool {
+	return p.types.match(device.Type)
+}
+
+func (p *sshProbe) Collect(ctx context.Context, device Device, sample *DeviceMetrics) error {
+	cred, err := p.login.credential(p.c, device)
+	if err != nil {
+		return err
+	}
+	port := p.opts.Port
+	if cred.Port > 0 {
+		port = cred.Port
+	}
+	target := net.JoinHostPort(device.IP, strconv.Itoa(port))
+
+	start := time.Now()
+	result := CheckResult{Probe: p.name, Target: target}
+	defer func() { sample.Checks = append(sample.Checks, result) }()
+
+	snapshot, err := p.collect(ctx, device, cred, target)
+	if err != nil {
+		result.Detail = err.Error()
+		return err
+	}
+	result.Success = true
+	result.Latency = float64(time.Since(start).Microseconds()) / 1000.0
+
+	p.c.reportConfig(*snapshot)
+	result.Detail = "config " + snapshot.Hash
+	return nil
+}
+
+// collect 登录设备, 执行初始化与采集命令并获取运行配置
+func (p *sshProbe) collect(ctx context.Context, device Device, cred SSHCredential, target string) (*ConfigSnapshot, error) {
+	client, err := p.login.dial(ctx, target, cred)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	cli, err := newCLISession(client, p.opts.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+
+	if err := cli.detectPrompt(ctx); err != nil {
+		return nil, err
+	}
+
+	vendor := p.opts.Vendor
+	if vendor == "" {
+		vendor = device.Vendor
+	}
+	cmds := lookupVendorCLI(vendor)
+	if cmds == nil {
+		cmds = detectVendorCLI(string(client.ServerVersion()), cli.prompt)
+	}
+	if cmds == nil {
+		return nil, fmt.Errorf("unknown cli vendor (prompt %q)", cli.prompt)
+	}
+
+	if cmds.enable != "" && cred.EnablePassword != "" && len(cli.prompt) > 0 && cli.prompt[len(cli.prompt)-1] == '>' {
+		if err := cli.enable(ctx, cmds.enable, cred.EnablePassword); err != nil {
+			return nil, fmt.Errorf("enable: %w", err)
+		}
+	}
+	for _, cmd := range cmds.setup {
+		if _, err := cli.run(ctx, cmd); err != nil {
+			return nil, err
+		}
+	}
+
+	commands := cmds.commands
+	if p.opts.Commands != nil {
+		commands = p.opts.Commands
+	}
+	outputs := make(map[string]string, len(commands))
+	for _, cmd := range commands {
+		out, err := cli.run(ctx, cmd)
+		if err != nil {
+			return nil, err
+		}
+		outputs[cmd] = out
+	}
+
+	content, err := cli.run(ctx, cmds.config)
+	if err != nil {
+		return nil, err
+	}
+	if content == "" {
+		return nil, errors.New("empty running-config")
+	}
+
+	return &ConfigSnapshot{
+		CollectorID: p.c.config.Collector.ID,
+		DeviceID:    device.ID,
+		IP:          device.IP,
+		Vendor:      cmds.name,
+		Type:        "running",
+		Content:     content,
+		Size:        len(content),
+		Hash:        configHash(normalizeConfig(content, cmds.volatile)),
+		Outputs:     outputs,
+		CollectedAt: time.Now(),
+	}, nil
+}
+
+// reportConfig 上报配置快照 (由 reporter 按 Hash 去重后发送至 /collector/configs, 发送成功后才记录哈希)
+func (c *Collector) reportConfig(snapshot ConfigSnapshot) {
+	select {
+	case c.configs <- snapshot:
+	default:
+		c.logger.WithField("ip", snapshot.IP).Warn("Config channel full, dropping snapshot")
+	}
+}
+
+// configHash 规范化后内容的 MD5
+func configHash(normalized string) string {
+	sum := md5.Sum([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// sshLogin 解析后的 SSH 登录参数
+type sshLogin struct {
+	opts  sshLoginOptions
+	hosts ssh.HostKeyCallback
+}
+
+// newSSHLogin 主机密钥默认按 knownHosts 校验, 网络设备多为自生成主机密钥, 需显式开启 insecureIgnoreHostKey 才跳过
+func newSSHLogin(opts sshLoginOptions) (*sshLogin, error) {
+	if opts.KnownHosts == "" {
+		if !opts.InsecureIgnoreHostKey {
+			return nil, errors.New("ssh host key verification requires knownHosts (or insecureIgnoreHostKey: true)")
+		}
+		return &sshLogin{opts: opts, hosts: ssh.InsecureIgnoreHostKey()}, nil
+	}
+	hosts, err := knownhosts.New(opts.KnownHosts)
+	if err != nil {
+		return nil, fmt.Errorf("load known hosts: %w", err)
+	}
+	return &sshLogin{opts: opts, hosts: hosts}, nil
+}
+
+// credential 凭据模板 > 探针配置
+func (l *sshLogin) credential(c *Collector, device Device) (SSHCredential, error) {
+	if device.CredentialProfileID != "" {
+		if profile, ok := c.credentialProfile(device.CredentialProfileID); ok && profile.SSH != nil && profile.SSH.Username != "" {
+			return *profile.SSH, nil
+		}
+	}
+	if l.opts.Username == "" {
+		return SSHCredential{}, errors.New("no ssh credential for device")
+	}
+
+	cred := SSHCredential{Username: l.opts.Username, Password: l.opts.Password, Passphrase: l.opts.Passphrase}
+	if l.opts.PrivateKeyFile != "" {
+		key, err := os.ReadFile(l.opts.PrivateKeyFile)
+		if err != nil {
+			return SSHCredential{}, fmt.Errorf("read private key: %w", err)
+		}
+		cred.PrivateKey = string(key)
+	}
+	return cred, nil
+}
+
+// dial 建立 SSH 连接, 连接生命周期受 ctx 约束
+func (l *sshLogin) dial(ctx context.Context, target string, cred SSHCredential) (*ssh.Client, error) {
+	clientConfig, err := l.clientConfig(cred)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := net.Dialer{Timeout: l.opts.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", target)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnreachable, err)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, target, clientConfig)
+	if err != nil {
+		stop()
+		conn.Close()
+		return nil, fmt.Errorf("ssh handshake: %w", err)
+	}
+	client := ssh.NewClient(sshConn, chans, reqs)
+	go func() {
+		client.Wait()
+		stop()
+	}()
+	return client, nil
+}
+
+// clientConfig 构造 SSH 客户端参数, legacyAlgorithms 开启时兼容老设备仅支持的算法
+func (l *sshLogin) clientConfig(cred SSHCredential) (*ssh.ClientConfig, error) {
+	var auths []ssh.AuthMethod
+	if cred.PrivateKey != "" {
+		var signer ssh.Signer
+		var err error
+		if cred.Passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(cred.PrivateKey), []byte(cred.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey([]byte(cred.PrivateKey))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse private key: %w", err)
+		}
+		auths = append(auths, ssh.PublicKeys(signer))
+	}
+	if cred.Password != "" {
+		password := cred.Password
+		auths = append(auths,
+			ssh.Password(password),
+			// 部分设备仅开启 keyboard-interactive 方式的密码认证
+			ssh.KeyboardInteractive(func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range answers {
+					answers[i] = password
+				}
+				return answers, nil
+			}),
+		)
+	}
+	if len(auths) == 0 {
+		return nil, errors.New("ssh credential has neither password nor private key")
+	}
+
+	cfg := &ssh.ClientConfig{
+		User:            cred.Username,
+		Auth:            auths,
+		HostKeyCallback: l.hosts,
+		Timeout:         l.opts.Timeout,
+	}
+	cfg.SetDefaults()
+	cfg.HostKeyAlgorithms = []string{
+		ssh.KeyAlgoED25519, ssh.KeyAlgoECDSA256, ssh.KeyAlgoECDSA384, ssh.KeyAlgoECDSA521,
+		ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256,
+	}
+	if l.opts.LegacyAlgorithms {
+		cfg.KeyExchanges = append(cfg.KeyExchanges, "diffie-hellman-group1-sha1", "diffie-hellman-group-exchange-sha1")
+		cfg.Ciphers = append(cfg.Ciphers, "aes128-cbc", "aes192-cbc", "aes256-cbc", "3des-cbc")
+		cfg.HostKeyAlgorithms = append(cfg.HostKeyAlgorithms, ssh.KeyAlgoRSA, ssh.KeyAlgoDSA)
+	}
+	return cfg, nil
+}
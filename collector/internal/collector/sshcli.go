@@ -0,0 +1,295 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// vendorCLI 厂商命令集
+type vendorCLI struct {
+	name     string
+	setup    []string       // 会话初始化 (关闭分页等), 设备不支持时仅输出错误提示, 分页仍由 readUntil 处理
+	enable   string         // 用户模式提示符下的提权命令
+	config   string         // 获取运行配置
+	commands []string       // 附带采集的命令
+	volatile *regexp.Regexp // 时间戳等与配置内容无关的行, 计算哈希时忽略
+}
+
+var vendorCLIs = map[string]*vendorCLI{
+	"cisco": {
+		name:     "cisco",
+		setup:    []string{"terminal length 0", "terminal width 0"},
+		enable:   "enable",
+		config:   "show running-config",
+		commands: []string{"show version", "show inventory"},
+		volatile: regexp.MustCompile(`^(! Last configuration change|! NVRAM config last updated|! No configuration change since|Building configuration|Current configuration :|ntp clock-period)`),
+	},
+	"huawei": {
+		name:     "huawei",
+		setup:    []string{"screen-length 0 temporary"},
+		config:   "display current-configuration",
+		commands: []string{"display version", "display device"},
+		volatile: regexp.MustCompile(`^(!Software Version|!Last configuration was|!Time:)`),
+	},
+	"h3c": {
+		name:     "h3c",
+		setup:    []string{"screen-length disable"},
+		config:   "display current-configuration",
+		commands: []string{"display version", "display device manuinfo"},
+	},
+	"juniper": {
+		name:     "juniper",
+		setup:    []string{"set cli screen-length 0", "set cli screen-width 0"},
+		config:   "show configuration | no-more",
+		commands: []string{"show version | no-more", "show chassis hardware | no-more"},
+		volatile: regexp.MustCompile(`^## Last (commit|changed):`),
+	},
+}
+
+// vendorAliases 设备厂商字段及常见系统名称到命令集的映射
+var vendorAliases = map[string]string{
+	"cisco":   "cisco",
+	"ios":     "cisco",
+	"ios-xe":  "cisco",
+	"ruijie":  "cisco", // 锐捷 RGOS 命令与 IOS 一致
+	"huawei":  "huawei",
+	"vrp":     "huawei",
+	"h3c":     "h3c",
+	"comware": "h3c",
+	"hp":      "h3c",
+	"juniper": "juniper",
+	"junos":   "juniper",
+}
+
+func lookupVendorCLI(vendor string) *vendorCLI {
+	return vendorCLIs[vendorAliases[strings.ToLower(strings.TrimSpace(vendor))]]
+}
+
+// detectVendorCLI 未指定厂商时按 SSH 服务端版本及提示符推断
+func detectVendorCLI(serverVersion, prompt string) *vendorCLI {
+	v := strings.ToLower(serverVersion)
+	switch {
+	case strings.Contains(v, "cisco"):
+		return vendorCLIs["cisco"]
+	case strings.Contains(v, "huawei"):
+		return vendorCLIs["huawei"]
+	case strings.Contains(v, "comware"):
+		return vendorCLIs["h3c"]
+	}
+	switch {
+	case junosPrompt.MatchString(prompt):
+		return vendorCLIs["juniper"]
+	case strings.HasPrefix(prompt, "<") || strings.HasPrefix(prompt, "["):
+		return vendorCLIs["huawei"]
+	case strings.HasSuffix(prompt, "#") || strings.HasSuffix(prompt, ">"):
+		return vendorCLIs["cisco"]
+	}
+	return nil
+}
+
+var (
+	// promptPattern 常见提示符: Router# / Router> / <HUAWEI> / [~HUAWEI] / user@host>
+	promptPattern = regexp.MustCompile(`(?:^|\n)([<\[]?[~*]?[\w.\-@/:()]+[>#\]%$]) ?$`)
+	junosPrompt   = regexp.MustCompile(`^\S+@\S+[>#%]$`)
+	// pagerPattern 分页提示: --More-- / ---- More ---- / <--- More ---> / ---(more 45%)---
+	pagerPattern    = regexp.MustCompile(`(?i)[ \t]*<?-+ ?\(?more[^\n]*?\)? ?-+>?\s*$`)
+	ansiPattern     = regexp.MustCompile(`\x1b\[\d+D *\x1b\[\d+D|\x1b\[[0-9;?]*[A-Za-z]`)
+	eraseBackspaces = regexp.MustCompile(`\x08+ *\x08+`)
+)
+
+// cliSession 交互式 shell 会话, 逐条执行命令并按提示符切分输出
+type cliSession struct {
+	session *ssh.Session
+	stdin   io.WriteCloser
+	chunks  chan []byte
+	buf     bytes.Buffer
+	prompt  string
+	timeout time.Duration
+}
+
+func newCLISession(client *ssh.Client, timeout time.Duration) (*cliSession, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("open session: %w", err)
+	}
+	modes := ssh.TerminalModes{ssh.ECHO: 1, ssh.TTY_OP_ISPEED: 38400, ssh.TTY_OP_OSPEED: 38400}
+	if err := session.RequestPty("vt100", 0, 512, modes); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("request pty: %w", err)
+	}
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	if err := session.Shell(); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("start shell: %w", err)
+	}
+
+	s := &cliSession{session: session, stdin: stdin, chunks: make(chan []byte, 64), timeout: timeout}
+	go func() {
+		defer close(s.chunks)
+		for {
+			b := make([]byte, 32*1024)
+			n, err := stdout.Read(b)
+			if n > 0 {
+				s.chunks <- b[:n]
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return s, nil
+}
+
+func (s *cliSession) Close() error {
+	s.stdin.Close()
+	return s.session.Close()
+}
+
+// detectPrompt 等待登录横幅结束并记录提示符
+func (s *cliSession) detectPrompt(ctx context.Context) error {
+	if _, err := s.write("\n"); err != nil {
+		return err
+	}
+	out, err := s.readUntil(ctx, func(b []byte) bool {
+		return promptPattern.Match(b)
+	})
+	if err != nil {
+		return fmt.Errorf("wait for prompt: %w", err)
+	}
+	m := promptPattern.FindStringSubmatch(out)
+	s.prompt = m[1]
+	return nil
+}
+
+// run 执行命令并返回去掉回显与提示符后的输出
+func (s *cliSession) run(ctx context.Context, command string) (string, error) {
+	if _, err := s.write(command + "\n"); err != nil {
+		return "", err
+	}
+	out, err := s.readUntil(ctx, s.atPrompt)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", command, err)
+	}
+
+	out = strings.ReplaceAll(out, "\x08", "")
+	out = strings.TrimSuffix(strings.TrimRight(out, " "), s.prompt)
+	if i := strings.IndexByte(out, '\n'); i >= 0 {
+		out = out[i+1:] // 命令回显
+	} else {
+		out = ""
+	}
+	return strings.TrimRight(out, "\n"), nil
+}
+
+// enable 用户模式下提权, 提示符随之变化
+func (s *cliSession) enable(ctx context.Context, command, password string) error {
+	if _, err := s.write(command + "\n"); err != nil {
+		return err
+	}
+	out, err := s.readUntil(ctx, func(b []byte) bool {
+		return bytes.HasSuffix(bytes.TrimRight(b, " "), []byte(":")) || promptPattern.Match(b)
+	})
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(strings.TrimRight(out, " "), ":") {
+		if _, err := s.write(password + "\n"); err != nil {
+			return err
+		}
+	}
+	return s.detectPrompt(ctx)
+}
+
+func (s *cliSession) atPrompt(b []byte) bool {
+	line := b
+	if i := bytes.LastIndexByte(b, '\n'); i >= 0 {
+		line = b[i+1:]
+	}
+	return string(bytes.TrimRight(line, " ")) == s.prompt
+}
+
+func (s *cliSession) write(data string) (int, error) {
+	return io.WriteString(s.stdin, data)
+}
+
+// readUntil 读取输出直到 done 返回 true, 遇到分页提示自动翻页
+func (s *cliSession) readUntil(ctx context.Context, done func([]byte) bool) (string, error) {
+	s.buf.Reset()
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return s.buf.String(), ctx.Err()
+		case <-timer.C:
+			return s.buf.String(), errors.New("timed out waiting for device output")
+		case chunk, ok := <-s.chunks:
+			if !ok {
+				return s.buf.String(), io.EOF
+			}
+			s.buf.Write(chunk)
+
+			cleaned := cleanTerminalOutput(s.buf.Bytes())
+			if loc := pagerPattern.FindIndex(cleaned); loc != nil {
+				cleaned = cleaned[:loc[0]]
+				if _, err := s.write(" "); err != nil {
+					return "", err
+				}
+			}
+			s.buf.Reset()
+			s.buf.Write(cleaned)
+
+			if done(cleaned) {
+				return string(cleaned), nil
+			}
+			// 有输出时延长超时, 大配置可能持续输出较长时间
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(s.timeout)
+		}
+	}
+}
+
+// cleanTerminalOutput 去掉回车、ANSI 控制序列及翻页后设备用于擦除提示的退格
+// 输出分多次到达, 单独的退格留到命令结束后再去掉, 以便与后续的空格一起识别为擦除序列
+func cleanTerminalOutput(b []byte) []byte {
+	b = ansiPattern.ReplaceAll(b, nil)
+	b = eraseBackspaces.ReplaceAll(b, nil)
+	b = bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n"))
+	b = bytes.ReplaceAll(b, []byte("\r"), nil)
+	return b
+}
+
+// normalizeConfig 去掉易变行, 用于计算配置哈希
+func normalizeConfig(content string, volatile *regexp.Regexp) string {
+	if volatile == nil {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !volatile.MatchString(line) {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
@@ -0,0 +1,273 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// maxFrameLenDigits octet-counting 长度前缀的最大位数
+const maxFrameLenDigits = 10
+
+// SyslogMessage 解析后的 syslog 消息
+type SyslogMessage struct {
+	CollectorID    string    `json:"collectorId"`
+	DeviceID       string    `json:"deviceId,omitempty"` // 来源未匹配到已知设备时为空
+	Source         string    `json:"source"`
+	Transport      string    `json:"transport"` // udp / tcp / tls
+	Format         string    `json:"format"`    // rfc3164 / rfc5424
+	Facility       int       `json:"facility"`
+	Severity       int       `json:"severity"`
+	Priority       int       `json:"priority"`
+	Timestamp      time.Time `json:"timestamp"`
+	Hostname       string    `json:"hostname,omitempty"`
+	AppName        string    `json:"appName,omitempty"`
+	ProcID         string    `json:"procId,omitempty"`
+	MsgID          string    `json:"msgId,omitempty"`
+	StructuredData string    `json:"structuredData,omitempty"`
+	Message        string    `json:"message"`
+	Raw            string    `json:"raw"`
+	ReceivedAt     time.Time `json:"receivedAt"`
+}
+
+// StartSyslog 启动 syslog 接收 (UDP / TCP / TCP+TLS), 阻塞直到 ctx 结束
+func (c *Collector) StartSyslog(ctx context.Context) error {
+	cfg := c.config.Syslog
+
+	// TCP 与 TLS 共用并发连接上限
+	slots := make(chan struct{}, cfg.MaxConnections)
+
+	var listeners []func() error
+	if cfg.UDP != "" {
+		conn, err := net.ListenPacket("udp", cfg.UDP)
+		if err != nil {
+			return fmt.Errorf("syslog udp: %w", err)
+		}
+		listeners = append(listeners, func() error { return c.serveSyslogUDP(ctx, conn) })
+	}
+	if cfg.TCP != "" {
+		ln, err := net.Listen("tcp", cfg.TCP)
+		if err != nil {
+			return fmt.Errorf("syslog tcp: %w", err)
+		}
+		listeners = append(listeners, func() error { return c.serveSyslogStream(ctx, ln, "tcp", slots) })
+	}
+	if cfg.TLS != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return fmt.Errorf("syslog tls certificate: %w", err)
+		}
+		ln, err := tls.Listen("tcp", cfg.TLS, &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		})
+		if err != nil {
+			return fmt.Errorf("syslog tls: %w", err)
+		}
+		listeners = append(listeners, func() error { return c.serveSyslogStream(ctx, ln, "tls", slots) })
+	}
+	if len(listeners) == 0 {
+		return errors.New("syslog enabled but no udp/tcp/tls listen address configured")
+	}
+
+	c.logger.WithField("udp", cfg.UDP).WithField("tcp", cfg.TCP).WithField("tls", cfg.TLS).Info("Starting syslog receiver...")
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, len(listeners))
+	for _, serve := range listeners {
+		wg.Add(1)
+		go func(serve func() error) {
+			defer wg.Done()
+			if err := serve(); err != nil {
+				errCh <- err
+			}
+		}(serve)
+	}
+	wg.Wait()
+	close(errCh)
+	return <-errCh
+}
+
+// serveSyslogUDP 每个数据报为一条消息
+func (c *Collector) serveSyslogUDP(ctx context.Context, conn net.PacketConn) error {
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	buf := make([]byte, c.config.Syslog.MaxMessageSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			c.logger.WithError(err).Warn("Syslog UDP read failed")
+			continue
+		}
+		c.handleSyslog(buf[:n], hostOf(addr), "udp")
+	}
+}
+
+// serveSyslogStream 接收 TCP / TLS 连接, 达到并发上限时直接关闭新连接
+func (c *Collector) serveSyslogStream(ctx context.Context, ln net.Listener, transport string, slots chan struct{}) error {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				continue
+			}
+			return fmt.Errorf("syslog %s accept: %w", transport, err)
+		}
+		select {
+		case slots <- struct{}{}:
+		default:
+			c.logger.WithField("source", hostOf(conn.RemoteAddr())).Warn("Syslog connection limit reached, rejecting connection")
+			conn.Close()
+			continue
+		}
+		go func() {
+			defer func() { <-slots }()
+			c.serveSyslogConn(ctx, conn, transport)
+		}()
+	}
+}
+
+// serveSyslogConn 按 RFC 6587 分帧: 以数字开头为 octet-counting, 否则按换行分隔
+func (c *Collector) serveSyslogConn(ctx context.Context, conn net.Conn, transport string) {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		conn.Close()
+	}()
+
+	source := hostOf(conn.RemoteAddr())
+	reader := bufio.NewReaderSize(conn, 64*1024)
+	maxSize := c.config.Syslog.MaxMessageSize
+	timeout := c.config.Syslog.ReadTimeout
+
+	for {
+		// 每帧重新设置读取期限, 空闲或发送过慢的连接超时后关闭
+		if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+			return
+		}
+		frame, err := readSyslogFrame(reader, maxSize)
+		if len(frame) > 0 {
+			c.handleSyslog(frame, source, transport)
+		}
+		if err != nil {
+			if err != io.EOF && ctx.Err() == nil {
+				c.logger.WithError(err).WithField("source", source).Debug("Syslog connection closed")
+			}
+			return
+		}
+	}
+}
+
+// readSyslogFrame 读取一帧
+func readSyslogFrame(r *bufio.Reader, maxSize int) ([]byte, error) {
+	first, err := r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+
+	if first[0] >= '1' && first[0] <= '9' {
+		// octet-counting: MSG-LEN SP SYSLOG-MSG, 长度前缀最多 maxFrameLenDigits 位
+		size := 0
+		for digits := 0; ; digits++ {
+			b, err := r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			if b == ' ' {
+				break
+			}
+			if b < '0' || b > '9' || digits == maxFrameLenDigits {
+				return nil, fmt.Errorf("invalid octet-counting frame length prefix")
+			}
+			size = size*10 + int(b-'0')
+		}
+		if size > maxSize {
+			return nil, fmt.Errorf("invalid octet-counting frame length %d", size)
+		}
+		frame := make([]byte, size)
+		if _, err := io.ReadFull(r, frame); err != nil {
+			return nil, err
+		}
+		return frame, nil
+	}
+
+	// non-transparent-framing: 以 LF 结尾
+	var frame []byte
+	for {
+		line, isPrefix, err := r.ReadLine()
+		frame = append(frame, line...)
+		if len(frame) > maxSize {
+			return nil, fmt.Errorf("syslog message exceeds %d bytes", maxSize)
+		}
+		if err != nil || !isPrefix {
+			return frame, err
+		}
+	}
+}
+
+// handleSyslog 解析并按源地址归属设备后发送
+func (c *Collector) handleSyslog(raw []byte, source, transport string) {
+	now := time.Now()
+	msg, err := parseSyslog(raw, now)
+	if err != nil {
+		c.logger.WithError(err).WithField("source", source).Debug("Invalid syslog message")
+		return
+	}
+
+	msg.CollectorID = c.config.Collector.ID
+	msg.Source = source
+	msg.Transport = transport
+	msg.ReceivedAt = now
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = now
+	}
+	if d, ok := c.deviceByIP(source); ok {
+		msg.DeviceID = d.ID
+	}
+
+	select {
+	case c.syslog <- msg:
+	default:
+		c.logger.WithField("source", source).Warn("Syslog channel full, dropping message")
+	}
+}
+
+// hostOf 提取地址中的IP
+func hostOf(addr net.Addr) string {
+	switch a := addr.(type) {
+	case *net.UDPAddr:
+		return a.IP.String()
+	case *net.TCPAddr:
+		return a.IP.String()
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
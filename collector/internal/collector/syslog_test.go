@@ -0,0 +1,221 @@
+package collector
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSyslog(t *testing.T) {
+	now := time.Date(2024, 10, 12, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		raw     string
+		now     time.Time // 为零时使用 now
+		want    SyslogMessage
+		wantErr bool
+	}{
+		{
+			name: "rfc5424 structured data",
+			raw: `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 ` +
+				`[exampleSDID@32473 iut="3" eventSource="Application"][examplePriority@32473 class="high"] ` +
+				"\ufeffAn application event log entry",
+			want: SyslogMessage{
+				Format: syslogRFC5424, Priority: 165, Facility: 20, Severity: 5,
+				Timestamp: time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC),
+				Hostname:  "mymachine.example.com", AppName: "evntslog", MsgID: "ID47",
+				StructuredData: `[exampleSDID@32473 iut="3" eventSource="Application"][examplePriority@32473 class="high"]`,
+				Message:        "An application event log entry",
+			},
+		},
+		{
+			name: "rfc5424 without structured data",
+			raw:  "<34>1 2003-10-11T22:14:15.003Z mymachine.example.com su 1234 ID47 - 'su root' failed on /dev/pts/8\n",
+			want: SyslogMessage{
+				Format: syslogRFC5424, Priority: 34, Facility: 4, Severity: 2,
+				Timestamp: time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC),
+				Hostname:  "mymachine.example.com", AppName: "su", ProcID: "1234", MsgID: "ID47",
+				Message: "'su root' failed on /dev/pts/8",
+			},
+		},
+		{
+			name: "rfc5424 escaped bracket in param value, no message",
+			raw:  `<14>1 - host app - - [id@1 path="a\]b" q="x\"y"]`,
+			want: SyslogMessage{
+				Format: syslogRFC5424, Priority: 14, Facility: 1, Severity: 6,
+				Hostname: "host", AppName: "app", StructuredData: `[id@1 path="a\]b" q="x\"y"]`,
+			},
+		},
+		{name: "rfc5424 truncated header", raw: "<14>1 2003-10-11T22:14:15Z host app", wantErr: true},
+		{name: "rfc5424 invalid timestamp", raw: "<14>1 yesterday host app - - - msg", wantErr: true},
+		{name: "rfc5424 unterminated structured data", raw: `<14>1 - host app - - [id@1 a="b"`, wantErr: true},
+		{name: "rfc5424 invalid structured data", raw: "<14>1 - host app - - msg", wantErr: true},
+		{
+			name: "rfc3164",
+			raw:  "<34>Oct 11 22:14:15 mymachine su: 'su root' failed for lonvick",
+			want: SyslogMessage{
+				Format: syslogRFC3164, Priority: 34, Facility: 4, Severity: 2,
+				Timestamp: time.Date(2024, 10, 11, 22, 14, 15, 0, time.UTC),
+				Hostname:  "mymachine", AppName: "su", Message: "'su root' failed for lonvick",
+			},
+		},
+		{
+			name: "rfc3164 cisco sequence prefix without hostname",
+			raw:  "<189>123: *Mar  1 18:46:11.123: %SYS-5-CONFIG_I: Configured from console by vty0",
+			want: SyslogMessage{
+				Format: syslogRFC3164, Priority: 189, Facility: 23, Severity: 5,
+				Timestamp: time.Date(2024, 3, 1, 18, 46, 11, 123000000, time.UTC),
+				AppName:   "%SYS-5-CONFIG_I", Message: "Configured from console by vty0",
+			},
+		},
+		{
+			name: "rfc3164 cisco timestamp with year",
+			raw:  "<187>Mar  1 2023 18:46:11: %LINK-3-UPDOWN: Interface Gi0/1, changed state to down",
+			want: SyslogMessage{
+				Format: syslogRFC3164, Priority: 187, Facility: 23, Severity: 3,
+				Timestamp: time.Date(2023, 3, 1, 18, 46, 11, 0, time.UTC),
+				AppName:   "%LINK-3-UPDOWN", Message: "Interface Gi0/1, changed state to down",
+			},
+		},
+		{
+			name: "rfc3164 star prefix with hostname and pid",
+			raw:  "<38>*Oct  9 07:00:01 router1 sshd[42]: Accepted publickey",
+			want: SyslogMessage{
+				Format: syslogRFC3164, Priority: 38, Facility: 4, Severity: 6,
+				Timestamp: time.Date(2024, 10, 9, 7, 0, 1, 0, time.UTC),
+				Hostname:  "router1", AppName: "sshd", ProcID: "42", Message: "Accepted publickey",
+			},
+		},
+		{
+			name: "rfc3164 year rollover",
+			raw:  "<13>Dec 31 23:59:50 host app: last message of the year",
+			now:  time.Date(2025, 1, 1, 0, 0, 10, 0, time.UTC),
+			want: SyslogMessage{
+				Format: syslogRFC3164, Priority: 13, Facility: 1, Severity: 5,
+				Timestamp: time.Date(2024, 12, 31, 23, 59, 50, 0, time.UTC),
+				Hostname:  "host", AppName: "app", Message: "last message of the year",
+			},
+		},
+		{
+			name: "rfc3164 rfc3339 timestamp",
+			raw:  "<13>2024-05-01T10:00:00.5+08:00 host app: message",
+			want: SyslogMessage{
+				Format: syslogRFC3164, Priority: 13, Facility: 1, Severity: 5,
+				Timestamp: time.Date(2024, 5, 1, 2, 0, 0, 500000000, time.UTC),
+				Hostname:  "host", AppName: "app", Message: "message",
+			},
+		},
+		{
+			name: "rfc3164 without pri",
+			raw:  "Oct 11 22:14:15 host kernel: link up",
+			want: SyslogMessage{
+				Format: syslogRFC3164, Priority: 13, Facility: 1, Severity: 5,
+				Timestamp: time.Date(2024, 10, 11, 22, 14, 15, 0, time.UTC),
+				Hostname:  "host", AppName: "kernel", Message: "link up",
+			},
+		},
+		{
+			name: "unrecognised header uses receive time",
+			raw:  "<11>something happened",
+			want: SyslogMessage{
+				Format: syslogRFC3164, Priority: 11, Facility: 1, Severity: 3,
+				Timestamp: now, Message: "something happened",
+			},
+		},
+		{name: "empty", raw: "\r\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at := now
+			if !tt.now.IsZero() {
+				at = tt.now
+			}
+			got, err := parseSyslog([]byte(tt.raw), at)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parsed invalid message: %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Timestamp.Equal(tt.want.Timestamp) {
+				t.Errorf("timestamp = %v, want %v", got.Timestamp, tt.want.Timestamp)
+			}
+			got.Timestamp, tt.want.Timestamp = time.Time{}, time.Time{}
+			tt.want.Raw = strings.TrimRight(tt.raw, "\r\n")
+			if got != tt.want {
+				t.Errorf("got  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadSyslogFrame(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		maxSize int
+		frames  []string
+		err     error // 读完 frames 后的错误, nil 表示任意非 EOF 错误
+	}{
+		{
+			name:   "octet counting",
+			input:  "9 <13>hello11 <13>a\nb c d",
+			frames: []string{"<13>hello", "<13>a\nb c d"},
+			err:    io.EOF,
+		},
+		{
+			name:   "lf framing",
+			input:  "<13>hello\n<13>crlf\r\n<13>tail",
+			frames: []string{"<13>hello", "<13>crlf", "<13>tail"},
+			err:    io.EOF,
+		},
+		{
+			name:   "mixed framing",
+			input:  "<13>lf\n9 <13>octet<13>next\n",
+			frames: []string{"<13>lf", "<13>octet", "<13>next"},
+			err:    io.EOF,
+		},
+		{name: "length exceeds max size", input: "65 <13>x", maxSize: 64},
+		{name: "invalid length prefix", input: "12a <13>x"},
+		{name: "length prefix too long", input: "12345678901 <13>x"},
+		{name: "truncated octet frame", input: "20 <13>short", err: io.ErrUnexpectedEOF},
+		{name: "lf frame exceeds max size", input: "<13>" + strings.Repeat("a", 100) + "\n", maxSize: 64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxSize := tt.maxSize
+			if maxSize == 0 {
+				maxSize = 1024
+			}
+			// 小缓冲区使超长 LF 帧分多次读取
+			r := bufio.NewReaderSize(strings.NewReader(tt.input), 16)
+			var frames []string
+			var err error
+			for {
+				var frame []byte
+				if frame, err = readSyslogFrame(r, maxSize); err != nil {
+					break
+				}
+				frames = append(frames, string(frame))
+			}
+
+			if strings.Join(frames, "|") != strings.Join(tt.frames, "|") {
+				t.Errorf("frames = %q, want %q", frames, tt.frames)
+			}
+			switch {
+			case tt.err != nil && !errors.Is(err, tt.err):
+				t.Errorf("err = %v, want %v", err, tt.err)
+			case tt.err == nil && (err == nil || errors.Is(err, io.EOF)):
+				t.Errorf("err = %v, want framing error", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package collector
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// syslog 报文格式
+const (
+	syslogRFC3164 = "rfc3164"
+	syslogRFC5424 = "rfc5424"
+)
+
+// rfc3164Layouts BSD syslog 常见时间戳格式 (无年份时按接收时间补全)
+var rfc3164Layouts = []string{
+	"Jan _2 2006 15:04:05.000",
+	"Jan _2 2006 15:04:05", // Cisco service timestamps ... year
+	time.StampMilli,        // Jan _2 15:04:05.000
+	time.Stamp,             // Jan _2 15:04:05
+}
+
+// parseSyslog 解析单条 syslog 报文, 自动识别 RFC 5424 与 RFC 3164
+// 无法识别的 RFC 3164 头部按 RFC 3164 4.3.3 处理: 使用接收时间, 整体作为消息内容
+func parseSyslog(raw []byte, now time.Time) (SyslogMessage, error) {
+	raw = bytes.TrimRight(raw, "\r\n\x00")
+	if len(raw) == 0 {
+		return SyslogMessage{}, errors.New("empty message")
+	}
+
+	msg := SyslogMessage{
+		Format:   syslogRFC3164,
+		Priority: 13, // user.notice
+		Raw:      string(raw),
+	}
+
+	rest := string(raw)
+	if pri, n, ok := parsePRI(rest); ok {
+		msg.Priority = pri
+		rest = rest[n:]
+	}
+	msg.Facility = msg.Priority / 8
+	msg.Severity = msg.Priority % 8
+
+	if strings.HasPrefix(rest, "1 ") {
+		if err := parseRFC5424(&msg, rest[2:]); err != nil {
+			return SyslogMessage{}, err
+		}
+		return msg, nil
+	}
+
+	parseRFC3164(&msg, rest, now)
+	return msg, nil
+}
+
+// parsePRI 解析 <PRI>, 返回优先级及其占用的字节数
+func parsePRI(s string) (int, int, bool) {
+	if len(s) < 3 || s[0] != '<' {
+		return 0, 0, false
+	}
+	end := strings.IndexByte(s, '>')
+	if end < 2 || end > 4 {
+		return 0, 0, false
+	}
+	pri, err := strconv.Atoi(s[1:end])
+	if err != nil || pri < 0 || pri > 191 {
+		return 0, 0, false
+	}
+	return pri, end + 1, true
+}
+
+// parseRFC5424 解析 VERSION 之后的部分:
+// TIMESTAMP SP HOSTNAME SP APP-NAME SP PROCID SP MSGID SP STRUCTURED-DATA [SP MSG]
+func parseRFC5424(msg *SyslogMessage, s string) error {
+	msg.Format = syslogRFC5424
+
+	fields := make([]string, 5)
+	for i := range fields {
+		sp := strings.IndexByte(s, ' ')
+		if sp < 0 {
+			return errors.New("rfc5424: truncated header")
+		}
+		fields[i], s = s[:sp], s[sp+1:]
+	}
+
+	if fields[0] != "-" {
+		ts, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return errors.New("rfc5424: invalid timestamp")
+		}
+		msg.Timestamp = ts
+	}
+	msg.Hostname = nilValue(fields[1])
+	msg.AppName = nilValue(fields[2])
+	msg.ProcID = nilValue(fields[3])
+	msg.MsgID = nilValue(fields[4])
+
+	sd, rest, err := splitStructuredData(s)
+	if err != nil {
+		return err
+	}
+	msg.StructuredData = nilValue(sd)
+	msg.Message = strings.TrimPrefix(strings.TrimPrefix(rest, " "), "\ufeff")
+	return nil
+}
+
+// splitStructuredData 切分 STRUCTURED-DATA, 处理 PARAM-VALUE 中的转义与引号
+func splitStructuredData(s string) (string, string, error) {
+	if strings.HasPrefix(s, "-") {
+		return "-", s[1:], nil
+	}
+
+	inElement, inQuote := false, false
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case inQuote && ch == '\\':
+			i++
+		case ch == '"' && inElement:
+			inQuote = !inQuote
+		case ch == '[' && !inQuote:
+			inElement = true
+		case ch == ']' && !inQuote:
+			inElement = false
+			if i+1 == len(s) || s[i+1] != '[' {
+				return s[:i+1], s[i+1:], nil
+			}
+		case !inElement && !inQuote:
+			return "", "", errors.New("rfc5424: invalid structured data")
+		}
+	}
+	return "", "", errors.New("rfc5424: unterminated structured data")
+}
+
+func nilValue(s string) string {
+	if s == "-" {
+		return ""
+	}
+	return s
+}
+
+// parseRFC3164 解析 TIMESTAMP SP HOSTNAME SP TAG[PID]: MSG
+// 部分设备 (如 Cisco) 省略主机名或在时间戳前加序号/星号, 尽量容错
+func parseRFC3164(msg *SyslogMessage, s string, now time.Time) {
+	ts, rest, ok := parse3164Timestamp(s, now)
+	if !ok {
+		msg.Timestamp = now
+		msg.Message = strings.TrimSpace(s)
+		return
+	}
+	msg.Timestamp = ts
+	rest = strings.TrimLeft(rest, " ")
+
+	// 首个字段以冒号结尾或含 '[' 时为 TAG, 否则为主机名
+	if sp := strings.IndexByte(rest, ' '); sp > 0 {
+		token := rest[:sp]
+		if !strings.HasSuffix(token, ":") && !strings.Contains(token, "[") {
+			msg.Hostname = token
+			rest = rest[sp+1:]
+		}
+	}
+
+	if tag, procID, body, ok := parseTag(rest); ok {
+		msg.AppName, msg.ProcID, rest = tag, procID, body
+	}
+	msg.Message = strings.TrimSpace(rest)
+}
+
+// parse3164Timestamp 解析 BSD 时间戳, 返回剩余部分
+func parse3164Timestamp(s string, now time.Time) (time.Time, string, bool) {
+	// Cisco 序号前缀 "123: "
+	if colon := strings.Index(s, ": "); colon > 0 {
+		if _, err := strconv.Atoi(s[:colon]); err == nil {
+			s = s[colon+2:]
+		}
+	}
+	s = strings.TrimLeft(s, "*.")
+
+	// 高精度 rsyslog 格式 (RFC3339) 也常出现在 BSD 头部
+	if sp := strings.IndexByte(s, ' '); sp > 0 {
+		if ts, err := time.Parse(time.RFC3339Nano, s[:sp]); err == nil {
+			return ts, s[sp+1:], true
+		}
+	}
+
+	for _, layout := range rfc3164Layouts {
+		if len(s) < len(layout) {
+			continue
+		}
+		head := strings.TrimSuffix(s[:len(layout)], ":")
+		ts, err := time.ParseInLocation(layout, head, now.Location())
+		if err != nil {
+			continue
+		}
+		rest := strings.TrimPrefix(s[len(layout):], ":")
+		if ts.Year() == 0 {
+			ts = ts.AddDate(now.Year(), 0, 0)
+			// 跨年: 设备时间不应明显晚于接收时间
+			if ts.After(now.Add(24 * time.Hour)) {
+				ts = ts.AddDate(-1, 0, 0)
+			}
+		}
+		return ts, rest, true
+	}
+	return time.Time{}, s, false
+}
+
+// parseTag 解析 "tag[pid]: msg" 或 "tag: msg"
+func parseTag(s string) (tag, procID, rest string, ok bool) {
+	colon := strings.Index(s, ": ")
+	if colon <= 0 || colon > 64 {
+		return "", "", s, false
+	}
+	head := s[:colon]
+	if strings.ContainsAny(head, " \t") {
+		return "", "", s, false
+	}
+	if open := strings.IndexByte(head, '['); open > 0 && strings.HasSuffix(head, "]") {
+		return head[:open], head[open+1 : len(head)-1], s[colon+2:], true
+	}
+	return head, "", s[colon+2:], true
+}
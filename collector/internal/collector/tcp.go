@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/netvis/collector/internal/config"
+)
+
+func init() {
+	RegisterProbe("tcp", newTCPProbe)
+}
+
+// tcpProbeOptions TCP端口探测参数
+type tcpProbeOptions struct {
+	Ports   []int         `yaml:"ports"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// tcpProbe TCP端口连通性探测
+type tcpProbe struct {
+	name  string
+	types deviceTypeFilter
+	opts  tcpProbeOptions
+}
+
+func newTCPProbe(c *Collector, cfg config.ProbeConfig) (Probe, error) {
+	opts := tcpProbeOptions{Timeout: 3 * time.Second}
+	if err := cfg.DecodeOptions(&opts); err != nil {
+		return nil, fmt.Errorf("decode tcp probe options: %w", err)
+	}
+	if len(opts.Ports) == 0 {
+		return nil, fmt.Errorf("tcp probe requires at least one port")
+	}
+	return &tcpProbe{name: cfg.Name, types: newDeviceTypeFilter(cfg.DeviceTypes), opts: opts}, nil
+}
+
+func (p *tcpProbe) Name() string { return p.name }
+
+func (p *tcpProbe) Applicable(device Device) bool {
+	return p.types.match(device.Type)
+}
+
+func (p *tcpProbe) Collect(ctx context.Context, device Device, sample *DeviceMetrics) error {
+	dialer := net.Dialer{Timeout: p.opts.Timeout}
+	for _, port := range p.opts.Ports {
+		target := net.JoinHostPort(device.IP, strconv.Itoa(port))
+		start := time.Now()
+		conn, err := dialer.DialContext(ctx, "tcp", target)
+
+		result := CheckResult{Probe: p.name, Target: target}
+		if err != nil {
+			result.Detail = err.Error()
+		} else {
+			conn.Close()
+			result.Success = true
+			result.Latency = float64(time.Since(start).Microseconds()) / 1000.0
+		}
+		sample.Checks = append(sample.Checks, result)
+	}
+	return nil
+}
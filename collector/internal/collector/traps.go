@@ -0,0 +1,286 @@
+package collector
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/netvis/collector/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+// Trap 相关 OID
+const (
+	oidSysUpTimeInstance = ".1.3.6.1.2.1.1.3.0"
+	oidSnmpTrapOID       = ".1.3.6.1.6.3.1.1.4.1.0"
+	oidSnmpTrapAddress   = ".1.3.6.1.6.3.18.1.3.0" // 经代理转发时的原始发送方地址
+	oidSnmpTraps         = ".1.3.6.1.6.3.1.1.5"    // 通用 trap 前缀 (RFC 3584)
+)
+
+// trapNames 常见通知名称
+var trapNames = map[string]string{
+	".1.3.6.1.6.3.1.1.5.1":      "coldStart",
+	".1.3.6.1.6.3.1.1.5.2":      "warmStart",
+	".1.3.6.1.6.3.1.1.5.3":      "linkDown",
+	".1.3.6.1.6.3.1.1.5.4":      "linkUp",
+	".1.3.6.1.6.3.1.1.5.5":      "authenticationFailure",
+	".1.3.6.1.6.3.1.1.5.6":      "egpNeighborLoss",
+	".1.3.6.1.2.1.15.7.1":       "bgpEstablished",
+	".1.3.6.1.2.1.15.7.2":       "bgpBackwardTransition",
+	".1.3.6.1.2.1.15.0.1":       "bgpEstablishedNotification",
+	".1.3.6.1.2.1.15.0.2":       "bgpBackwardTransNotification",
+	".1.3.6.1.2.1.14.16.2.2":    "ospfNbrStateChange",
+	".1.3.6.1.2.1.47.2.0.1":     "entConfigChange",
+	".1.3.6.1.2.1.17.0.1":       "newRoot",
+	".1.3.6.1.2.1.17.0.2":       "topologyChange",
+	".1.3.6.1.4.1.9.9.43.2.0.1": "ciscoConfigManEvent",
+}
+
+// trapDropReportInterval 限速丢弃统计的日志间隔
+const trapDropReportInterval = time.Minute
+
+// TrapVarbind 解码后的变量绑定
+type TrapVarbind struct {
+	OID   string `json:"oid"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// TrapEvent 收到的 Trap/Inform
+type TrapEvent struct {
+	CollectorID  string        `json:"collectorId"`
+	DeviceID     string        `json:"deviceId,omitempty"` // 来源未匹配到已知设备时为空
+	Source       string        `json:"source"`
+	AgentAddress string        `json:"agentAddress,omitempty"` // v1 agent-addr 或 snmpTrapAddress
+	Version      string        `json:"version"`                // 1 / 2c / 3
+	PDUType      string        `json:"pduType"`                // trap / inform
+	TrapOID      string        `json:"trapOid"`
+	Name         string        `json:"name,omitempty"`
+	Uptime       int64         `json:"uptime"` // 发送方 sysUpTime (1/100秒)
+	User         string        `json:"user,omitempty"`
+	Varbinds     []TrapVarbind `json:"varbinds"`
+	ReceivedAt   time.Time     `json:"receivedAt"`
+}
+
+// StartTraps 启动 Trap/Inform 接收, 阻塞直到 ctx 结束
+// INFORM 的确认由 gosnmp 在回调返回后发送, 被丢弃的 INFORM 不确认 (发送方按其重传策略重发)
+func (c *Collector) StartTraps(ctx context.Context) error {
+	cfg := c.config.Traps
+
+	params, err := c.trapParams(cfg)
+	if err != nil {
+		return err
+	}
+
+	communities := make(map[string]bool, len(cfg.Communities))
+	for _, community := range cfg.Communities {
+		communities[community] = true
+	}
+	limiter := newSourceLimiter(cfg.RateLimit, cfg.Burst)
+
+	listener := gosnmp.NewTrapListener()
+	listener.Params = params
+	listener.OnNewTrap = func(packet *gosnmp.SnmpPacket, addr *net.UDPAddr) {
+		source := addr.IP.String()
+		if !limiter.allow(source, time.Now()) {
+			suppressAck(packet)
+			return
+		}
+		if packet.Version != gosnmp.Version3 && len(communities) > 0 && !communities[packet.Community] {
+			c.logger.WithField("source", source).Debug("Trap with unknown community dropped")
+			suppressAck(packet)
+			return
+		}
+		// 空用户名条目仅用于引擎发现 (发现报文由 gosnmp 直接回复 Report, 不进入回调)
+		if usm, ok := packet.SecurityParameters.(*gosnmp.UsmSecurityParameters); ok && packet.Version == gosnmp.Version3 && usm.UserName == "" {
+			c.logger.WithField("source", source).Debug("SNMPv3 trap without user dropped")
+			suppressAck(packet)
+			return
+		}
+
+		event := c.decodeTrap(packet, source)
+		select {
+		case c.traps <- event:
+		default:
+			c.logger.WithField("source", source).Warn("Trap channel full, dropping trap")
+			suppressAck(packet)
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(trapDropReportInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				listener.Close()
+				return
+			case now := <-ticker.C:
+				for source, count := range limiter.drain(now, 10*trapDropReportInterval) {
+					c.logger.WithFields(logrus.Fields{"source": source, "dropped": count}).Warn("Trap rate limit exceeded")
+				}
+			}
+		}
+	}()
+
+	c.logger.WithField("listen", cfg.Listen).Info("Starting SNMP trap receiver...")
+	if err := listener.Listen(cfg.Listen); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("trap listener: %w", err)
+	}
+	return nil
+}
+
+// suppressAck 使被丢弃的 INFORM 不被确认
+// 依赖 gosnmp (v1.42.1 trap.go TrapListener.listenUDP) 在 OnNewTrap 返回后才检查 PDUType,
+// 仅对仍为 InformRequest 的报文发送响应; 升级 gosnmp 时由 TestTrapDroppedInformNotAcked 校验
+func suppressAck(packet *gosnmp.SnmpPacket) {
+	if packet.PDUType == gosnmp.InformRequest {
+		packet.PDUType = gosnmp.SNMPv2Trap
+	}
+}
+
+// trapParams 构建接收参数: v3 用户按用户名匹配, 空用户名条目仅用于 Inform 发送方的引擎发现,
+// 以空用户名发送的通知在回调中丢弃
+func (c *Collector) trapParams(cfg config.TrapConfig) (*gosnmp.GoSNMP, error) {
+	engineID, err := c.trapEngineID(cfg.EngineID)
+	if err != nil {
+		return nil, err
+	}
+
+	table := gosnmp.NewSnmpV3SecurityParametersTable(gosnmp.Logger{})
+	if err := table.Add("", &gosnmp.UsmSecurityParameters{}); err != nil {
+		return nil, err
+	}
+	for _, user := range cfg.Users {
+		usm, _, err := buildUSM(user)
+		if err != nil {
+			return nil, fmt.Errorf("trap user %q: %w", user.Username, err)
+		}
+		if err := table.Add(user.Username, usm); err != nil {
+			return nil, fmt.Errorf("trap user %q: %w", user.Username, err)
+		}
+	}
+
+	return &gosnmp.GoSNMP{
+		Version:                     gosnmp.Version3,
+		SecurityModel:               gosnmp.UserSecurityModel,
+		SecurityParameters:          &gosnmp.UsmSecurityParameters{AuthoritativeEngineID: engineID},
+		TrapSecurityParametersTable: table,
+	}, nil
+}
+
+// trapEngineID 解析本机 snmpEngineID; 未配置时按 RFC 3411 文本格式由采集器ID生成
+func (c *Collector) trapEngineID(configured string) (string, error) {
+	if configured != "" {
+		raw, err := hex.DecodeString(strings.TrimPrefix(strings.ReplaceAll(configured, ":", ""), "0x"))
+		if err != nil {
+			return "", fmt.Errorf("invalid trap engineID: %w", err)
+		}
+		if len(raw) < 5 || len(raw) > 32 {
+			return "", fmt.Errorf("invalid trap engineID: length must be 5..32 bytes")
+		}
+		return string(raw), nil
+	}
+
+	// 0x80 | enterprise 8072 (net-snmp), format 4 (text)
+	id := "\x80\x00\x1f\x88\x04" + "netvis-" + c.config.Collector.ID
+	if len(id) > 32 {
+		id = id[:32]
+	}
+	return id, nil
+}
+
+// decodeTrap 解码 Trap/Inform 并归属到设备
+func (c *Collector) decodeTrap(packet *gosnmp.SnmpPacket, source string) TrapEvent {
+	event := TrapEvent{
+		CollectorID: c.config.Collector.ID,
+		Source:      source,
+		Version:     packet.Version.String(),
+		PDUType:     "trap",
+		Varbinds:    make([]TrapVarbind, 0, len(packet.Variables)),
+		ReceivedAt:  time.Now(),
+	}
+	if packet.PDUType == gosnmp.InformRequest {
+		event.PDUType = "inform"
+	}
+	if usm, ok := packet.SecurityParameters.(*gosnmp.UsmSecurityParameters); ok && packet.Version == gosnmp.Version3 {
+		event.User = usm.UserName
+	}
+
+	if packet.PDUType == gosnmp.Trap {
+		// SNMPv1 trap 按 RFC 3584 转换为 v2 通知 OID
+		event.AgentAddress = packet.AgentAddress
+		event.Uptime = int64(packet.Timestamp)
+		if packet.GenericTrap >= 0 && packet.GenericTrap < 6 {
+			event.TrapOID = fmt.Sprintf("%s.%d", oidSnmpTraps, packet.GenericTrap+1)
+		} else {
+			event.TrapOID = fmt.Sprintf("%s.0.%d", normalizeOID(packet.Enterprise), packet.SpecificTrap)
+		}
+	}
+
+	for _, pdu := range packet.Variables {
+		name := normalizeOID(pdu.Name)
+		switch name {
+		case oidSysUpTimeInstance:
+			if v, ok := pduInt64(pdu); ok {
+				event.Uptime = v
+			}
+			continue
+		case oidSnmpTrapOID:
+			event.TrapOID = normalizeOID(trapValue(pdu))
+			continue
+		case oidSnmpTrapAddress:
+			event.AgentAddress = trapValue(pdu)
+		}
+		event.Varbinds = append(event.Varbinds, TrapVarbind{
+			OID:   name,
+			Type:  pdu.Type.String(),
+			Value: trapValue(pdu),
+		})
+	}
+	event.Name = trapNames[event.TrapOID]
+
+	// 优先按报文源地址匹配, 经代理/NAT转发时回退到 agent 地址
+	if d, ok := c.deviceByIP(source); ok {
+		event.DeviceID = d.ID
+	} else if d, ok := c.deviceByIP(event.AgentAddress); ok && event.AgentAddress != "" {
+		event.DeviceID = d.ID
+	}
+	return event
+}
+
+// trapValue 将变量值格式化为字符串
+func trapValue(pdu gosnmp.SnmpPDU) string {
+	switch pdu.Type {
+	case gosnmp.OctetString:
+		return printableOrHex(pduBytes(pdu))
+	case gosnmp.ObjectIdentifier, gosnmp.IPAddress:
+		if s, ok := pdu.Value.(string); ok {
+			return s
+		}
+	case gosnmp.Integer, gosnmp.Counter32, gosnmp.Gauge32, gosnmp.TimeTicks, gosnmp.Counter64, gosnmp.Uinteger32:
+		return gosnmp.ToBigInt(pdu.Value).String()
+	case gosnmp.Null, gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView:
+		return ""
+	}
+	if pdu.Value == nil {
+		return ""
+	}
+	return fmt.Sprint(pdu.Value)
+}
+
+// normalizeOID 统一为带前导点的形式
+func normalizeOID(oid string) string {
+	if oid == "" || strings.HasPrefix(oid, ".") {
+		return oid
+	}
+	if _, err := strconv.Atoi(strings.SplitN(oid, ".", 2)[0]); err != nil {
+		return oid
+	}
+	return "." + oid
+}
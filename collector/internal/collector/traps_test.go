@@ -0,0 +1,84 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// startTestTrapReceiver 在本地随机端口启动 trap 接收, 返回端口
+func startTestTrapReceiver(t *testing.T, communities string) (*Collector, uint16) {
+	t.Helper()
+	probe, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := probe.LocalAddr().(*net.UDPAddr).Port
+	probe.Close()
+
+	c := newTestCollector(t, fmt.Sprintf("collector:\n  id: test\ntraps:\n  enabled: true\n  listen: 127.0.0.1:%d\n  communities: %s\n  rateLimit: 1000\n", port, communities))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- c.StartTraps(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return c, uint16(port)
+}
+
+func sendInform(t *testing.T, port uint16, community string) error {
+	t.Helper()
+	client := &gosnmp.GoSNMP{
+		Target: "127.0.0.1", Port: port, Community: community, Version: gosnmp.Version2c,
+		Timeout: 300 * time.Millisecond, Retries: 0,
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Conn.Close()
+	_, err := client.SendTrap(gosnmp.SnmpTrap{IsInform: true, Variables: []gosnmp.SnmpPDU{
+		{Name: ".1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(100)},
+		{Name: ".1.3.6.1.6.3.1.1.4.1.0", Type: gosnmp.ObjectIdentifier, Value: ".1.3.6.1.6.3.1.1.5.3"},
+	}})
+	return err
+}
+
+// 被丢弃的 INFORM 不得确认, 依赖 gosnmp 在 OnNewTrap 返回后检查 PDUType (见 suppressAck)
+func TestTrapDroppedInformNotAcked(t *testing.T) {
+	c, port := startTestTrapReceiver(t, "[public]")
+
+	// 等待监听就绪: 接受的 INFORM 收到确认
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		err := sendInform(t, port, "public")
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("accepted inform not acknowledged: %v", err)
+		}
+	}
+	select {
+	case event := <-c.Traps():
+		if event.PDUType != "inform" || event.Name != "linkDown" {
+			t.Errorf("trap = %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("accepted inform not delivered")
+	}
+
+	// 团体名不匹配的 INFORM 被丢弃且不确认
+	if err := sendInform(t, port, "private"); err == nil {
+		t.Fatal("dropped inform was acknowledged")
+	}
+	select {
+	case event := <-c.Traps():
+		t.Errorf("dropped inform delivered: %+v", event)
+	default:
+	}
+}
@@ -8,12 +8,19 @@ import (
 )
 
 type Config struct {
-	API       APIConfig       `yaml:"api"`
-	Collector CollectorConfig `yaml:"collector"`
-	SNMP      SNMPConfig      `yaml:"snmp"`
-	Ping      PingConfig      `yaml:"ping"`
-	Logging   LoggingConfig   `yaml:"logging"`
-	Metrics   MetricsConfig   `yaml:"metrics"`
+	API          APIConfig          `yaml:"api"`
+	Collector    CollectorConfig    `yaml:"collector"`
+	SNMP         SNMPConfig         `yaml:"snmp"`
+	Ping         PingConfig         `yaml:"ping"`
+	Probes       []ProbeConfig      `yaml:"probes"`
+	Reachability ReachabilityConfig `yaml:"reachability"`
+	Dampening    DampeningConfig    `yaml:"dampening"`
+	Traps        TrapConfig         `yaml:"traps"`
+	Syslog       SyslogConfig       `yaml:"syslog"`
+	Flows        FlowConfig         `yaml:"flows"`
+	GNMI         GNMIConfig         `yaml:"gnmi"`
+	Logging      LoggingConfig      `yaml:"logging"`
+	Metrics      MetricsConfig      `yaml:"metrics"`
 }
 
 type APIConfig struct {
@@ -24,23 +31,163 @@ type APIConfig struct {
 }
 
 type CollectorConfig struct {
-	ID          string        `yaml:"id"`
-	Name        string        `yaml:"name"`
-	Interval    time.Duration `yaml:"interval"`
-	Concurrency int           `yaml:"concurrency"`
+	ID                string        `yaml:"id"`
+	Name              string        `yaml:"name"`
+	Interval          time.Duration `yaml:"interval"`
+	Concurrency       int           `yaml:"concurrency"`
+	TopologyResync    time.Duration `yaml:"topologyResync"`    // 邻居未变化时的强制全量重发间隔
+	EndpointResync    time.Duration `yaml:"endpointResync"`    // ARP/MAC表增量上报的全量重发间隔
+	FingerprintResync time.Duration `yaml:"fingerprintResync"` // 设备指纹未变化时的强制重发间隔
 }
 
 type SNMPConfig struct {
-	Community string        `yaml:"community"`
-	Version   string        `yaml:"version"`
-	Timeout   time.Duration `yaml:"timeout"`
-	Retries   int           `yaml:"retries"`
-	Port      int           `yaml:"port"`
+	Community      string        `yaml:"community"`
+	Version        string        `yaml:"version"`
+	Timeout        time.Duration `yaml:"timeout"`
+	Retries        int           `yaml:"retries"`
+	Port           int           `yaml:"port"`
+	MaxRepetitions int           `yaml:"maxRepetitions"`
+	V3             SNMPv3Config  `yaml:"v3"`
+}
+
+// SNMPv3Config SNMPv3 (USM) 认证参数
+type SNMPv3Config struct {
+	Username       string `yaml:"username" json:"username"`
+	SecurityLevel  string `yaml:"securityLevel" json:"securityLevel,omitempty"` // noAuthNoPriv / authNoPriv / authPriv
+	AuthProtocol   string `yaml:"authProtocol" json:"authProtocol,omitempty"`   // MD5 / SHA / SHA-224 / SHA-256 / SHA-384 / SHA-512
+	AuthPassphrase string `yaml:"authPassphrase" json:"authPassphrase,omitempty"`
+	PrivProtocol   string `yaml:"privProtocol" json:"privProtocol,omitempty"` // DES / AES / AES-192 / AES-256
+	PrivPassphrase string `yaml:"privPassphrase" json:"privPassphrase,omitempty"`
+	ContextName    string `yaml:"contextName" json:"contextName,omitempty"`
 }
 
 type PingConfig struct {
-	Count    int           `yaml:"count"`
-	Timeout  time.Duration `yaml:"timeout"`
+	Count      int           `yaml:"count"`
+	Timeout    time.Duration `yaml:"timeout"`  // 单次探测总超时
+	Interval   time.Duration `yaml:"interval"` // 报文发送间隔
+	Size       int           `yaml:"size"`     // ICMP载荷字节数
+	TTL        int           `yaml:"ttl"`
+	Privileged bool          `yaml:"privileged"` // 使用原始ICMP套接字 (需root或CAP_NET_RAW), 否则使用UDP ICMP
+}
+
+// ProbeConfig 单个采集探针配置, 按列表顺序执行
+type ProbeConfig struct {
+	Name        string        `yaml:"name"`
+	Type        string        `yaml:"type"`        // 探针类型, 为空时与 name 相同
+	DeviceTypes []string      `yaml:"deviceTypes"` // 适用的设备类型, 为空表示全部
+	Interval    time.Duration `yaml:"interval"`    // 采集间隔, 为空时使用 collector.interval
+	Options     yaml.Node     `yaml:"options"`     // 探针私有参数, 由探针自行解析
+}
+
+// Kind 返回探针类型
+func (p ProbeConfig) Kind() string {
+	if p.Type != "" {
+		return p.Type
+	}
+	return p.Name
+}
+
+// DecodeOptions 将 options 解析到探针参数结构体
+func (p ProbeConfig) DecodeOptions(v interface{}) error {
+	if p.Options.Kind == 0 {
+		return nil
+	}
+	return p.Options.Decode(v)
+}
+
+// 可达性判定模式
+const (
+	ReachPingOnly = "ping-only" // 仅以 ping 判定
+	ReachSNMPOnly = "snmp-only" // 仅以 snmp 判定 (适用于屏蔽ICMP的防火墙)
+	ReachAnyOf    = "any-of"    // probes 中任一成功即可达
+	ReachAllOf    = "all-of"    // probes 全部成功才可达
+	ReachTCP      = "tcp"       // 以 tcp 探针对 port 的检测结果判定
+)
+
+// ReachabilityConfig 设备可达性判定策略, deviceTypes 按 Device.Type 覆盖默认策略
+type ReachabilityConfig struct {
+	Default     ReachabilityPolicy            `yaml:"default"`
+	DeviceTypes map[string]ReachabilityPolicy `yaml:"deviceTypes"`
+}
+
+// ReachabilityPolicy 可达性判定策略
+type ReachabilityPolicy struct {
+	Mode   string   `yaml:"mode"`
+	Probes []string `yaml:"probes"` // any-of / all-of 参与判定的探针名称或类型, 默认 ping + snmp
+	Port   int      `yaml:"port"`   // tcp 模式判定端口
+}
+
+// DampeningConfig 状态抖动抑制, 同时作用于设备状态与接口状态
+type DampeningConfig struct {
+	Failures  int           `yaml:"failures"`  // 探针/接口连续失败N次才判定故障
+	Successes int           `yaml:"successes"` // 连续成功N次才判定恢复
+	HoldDown  time.Duration `yaml:"holdDown"`  // 状态变化后的最短保持时间, 期间不再切换
+}
+
+// TrapConfig SNMP Trap/Inform 接收
+type TrapConfig struct {
+	Enabled     bool           `yaml:"enabled"`
+	Listen      string         `yaml:"listen"`      // 监听地址, 默认 :162
+	Communities []string       `yaml:"communities"` // v1/v2c 允许的团体名, 为空不校验
+	Users       []SNMPv3Config `yaml:"users"`       // v3 USM 用户
+	EngineID    string         `yaml:"engineID"`    // 本机 snmpEngineID (十六进制), 接收 v3 Inform 时由发送方发现; 为空时按采集器ID生成
+	RateLimit   float64        `yaml:"rateLimit"`   // 每个来源每秒允许的 trap 数
+	Burst       int            `yaml:"burst"`       // 每个来源允许的突发数
+}
+
+// SyslogConfig Syslog 接收 (RFC 3164 / RFC 5424), 监听地址为空表示不启用该传输
+type SyslogConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	UDP            string        `yaml:"udp"` // 如 :514
+	TCP            string        `yaml:"tcp"` // 如 :514, 支持 octet-counting 与换行分帧
+	TLS            string        `yaml:"tls"` // 如 :6514
+	CertFile       string        `yaml:"certFile"`
+	KeyFile        string        `yaml:"keyFile"`
+	MaxMessageSize int           `yaml:"maxMessageSize"` // 单条消息最大字节数
+	ReadTimeout    time.Duration `yaml:"readTimeout"`    // TCP/TLS 每帧读取超时 (含等待下一帧的空闲时间)
+	MaxConnections int           `yaml:"maxConnections"` // TCP/TLS 并发连接上限, 超出时拒绝新连接
+}
+
+// FlowConfig 流量采集 (NetFlow v5/v9 / IPFIX / sFlow v5), 按接口汇总后上报
+type FlowConfig struct {
+	Enabled         bool          `yaml:"enabled"`
+	NetFlow         string        `yaml:"netflow"`         // 监听地址, NetFlow v5/v9 与 IPFIX 共用, 如 :2055
+	SFlow           string        `yaml:"sflow"`           // sFlow v5 监听地址, 如 :6343
+	Window          time.Duration `yaml:"window"`          // 汇总窗口
+	TopN            int           `yaml:"topN"`            // 每个接口上报的 top talker / 应用数
+	TemplateTimeout time.Duration `yaml:"templateTimeout"` // v9/IPFIX 模板未刷新时的过期时间
+	MaxTemplates    int           `yaml:"maxTemplates"`    // 每个导出方缓存的 v9/IPFIX 模板上限
+}
+
+// gNMI 订阅模式
+const (
+	GNMIModeSample   = "sample"    // 按 interval 周期推送
+	GNMIModeOnChange = "on_change" // 值变化时推送
+)
+
+// GNMIConfig gNMI 流式遥测订阅
+// 设备类型在 deviceTypes 中或服务端为设备下发 gnmi 配置时建立订阅
+type GNMIConfig struct {
+	Enabled            bool                          `yaml:"enabled"`
+	Port               int                           `yaml:"port"`
+	Username           string                        `yaml:"username"`
+	Password           string                        `yaml:"password"`
+	TLS                bool                          `yaml:"tls"`
+	InsecureSkipVerify bool                          `yaml:"insecureSkipVerify"`
+	CAFile             string                        `yaml:"caFile"`
+	Encoding           string                        `yaml:"encoding"`      // json_ietf / json / proto
+	Timeout            time.Duration                 `yaml:"timeout"`       // 建立订阅的超时
+	BackoffMin         time.Duration                 `yaml:"backoffMin"`    // 断线重连的初始退避
+	BackoffMax         time.Duration                 `yaml:"backoffMax"`    // 断线重连的最大退避
+	FlushInterval      time.Duration                 `yaml:"flushInterval"` // 合并推送更新后发送指标的最短间隔
+	Subscriptions      []GNMISubscription            `yaml:"subscriptions"` // 默认订阅路径
+	DeviceTypes        map[string][]GNMISubscription `yaml:"deviceTypes"`   // 按 Device.Type 覆盖订阅路径
+}
+
+// GNMISubscription 单条订阅
+type GNMISubscription struct {
+	Path     string        `yaml:"path"` // OpenConfig 路径, 如 /interfaces/interface[name=*]/state/counters
+	Mode     string        `yaml:"mode"` // sample / on_change
 	Interval time.Duration `yaml:"interval"`
 }
 
@@ -75,12 +222,122 @@ func Load(path string) (*Config, error) {
 	if config.Collector.Concurrency == 0 {
 		config.Collector.Concurrency = 10
 	}
+	if config.Collector.TopologyResync == 0 {
+		config.Collector.TopologyResync = time.Hour
+	}
+	if config.Collector.EndpointResync == 0 {
+		config.Collector.EndpointResync = time.Hour
+	}
+	if config.Collector.FingerprintResync == 0 {
+		config.Collector.FingerprintResync = 24 * time.Hour
+	}
 	if config.SNMP.Port == 0 {
 		config.SNMP.Port = 161
 	}
+	if config.SNMP.Version == "" {
+		config.SNMP.Version = "2c"
+	}
+	if config.SNMP.Timeout == 0 {
+		config.SNMP.Timeout = 5 * time.Second
+	}
+	if config.SNMP.MaxRepetitions == 0 {
+		config.SNMP.MaxRepetitions = 25
+	}
+	// 未配置时重试 2 次, 负数表示不重试
+	if config.SNMP.Retries == 0 {
+		config.SNMP.Retries = 2
+	}
+	if config.Reachability.Default.Mode == "" {
+		config.Reachability.Default.Mode = ReachPingOnly
+	}
+	if config.Dampening.Failures == 0 {
+		config.Dampening.Failures = 3
+	}
+	if config.Dampening.Successes == 0 {
+		config.Dampening.Successes = 2
+	}
+	if config.Traps.Listen == "" {
+		config.Traps.Listen = ":162"
+	}
+	if config.Traps.RateLimit == 0 {
+		config.Traps.RateLimit = 10
+	}
+	if config.Traps.Burst == 0 {
+		config.Traps.Burst = 50
+	}
+	if config.Syslog.MaxMessageSize == 0 {
+		config.Syslog.MaxMessageSize = 64 * 1024
+	}
+	if config.Syslog.ReadTimeout == 0 {
+		config.Syslog.ReadTimeout = 5 * time.Minute
+	}
+	if config.Syslog.MaxConnections == 0 {
+		config.Syslog.MaxConnections = 512
+	}
+	if config.Flows.NetFlow == "" {
+		config.Flows.NetFlow = ":2055"
+	}
+	if config.Flows.SFlow == "" {
+		config.Flows.SFlow = ":6343"
+	}
+	if config.Flows.Window == 0 {
+		config.Flows.Window = time.Minute
+	}
+	if config.Flows.TopN == 0 {
+		config.Flows.TopN = 10
+	}
+	if config.Flows.TemplateTimeout == 0 {
+		config.Flows.TemplateTimeout = 30 * time.Minute
+	}
+	if config.Flows.MaxTemplates == 0 {
+		config.Flows.MaxTemplates = 1024
+	}
+	if config.GNMI.Port == 0 {
+		config.GNMI.Port = 57400
+	}
+	if config.GNMI.Encoding == "" {
+		config.GNMI.Encoding = "json_ietf"
+	}
+	if config.GNMI.Timeout == 0 {
+		config.GNMI.Timeout = 10 * time.Second
+	}
+	if config.GNMI.BackoffMin == 0 {
+		config.GNMI.BackoffMin = time.Second
+	}
+	if config.GNMI.BackoffMax == 0 {
+		config.GNMI.BackoffMax = 2 * time.Minute
+	}
+	if config.GNMI.FlushInterval == 0 {
+		config.GNMI.FlushInterval = time.Second
+	}
+	if len(config.GNMI.Subscriptions) == 0 {
+		config.GNMI.Subscriptions = DefaultGNMISubscriptions
+	}
 	if config.Ping.Count == 0 {
 		config.Ping.Count = 3
 	}
+	if config.Ping.Timeout == 0 {
+		config.Ping.Timeout = 5 * time.Second
+	}
+	if config.Ping.Interval == 0 {
+		config.Ping.Interval = time.Second
+	}
+	if config.Ping.Size == 0 {
+		config.Ping.Size = 24
+	}
+	if config.Ping.TTL == 0 {
+		config.Ping.TTL = 64
+	}
 
 	return config, nil
 }
+
+// DefaultGNMISubscriptions 未配置时订阅的 OpenConfig 路径: 接口计数器与状态、CPU、内存
+var DefaultGNMISubscriptions = []GNMISubscription{
+	{Path: "/interfaces/interface/state/counters", Mode: GNMIModeSample, Interval: 10 * time.Second},
+	{Path: "/interfaces/interface/state/oper-status", Mode: GNMIModeOnChange},
+	{Path: "/interfaces/interface/state/admin-status", Mode: GNMIModeOnChange},
+	{Path: "/interfaces/interface/state/ifindex", Mode: GNMIModeOnChange},
+	{Path: "/system/cpus/cpu/state/total", Mode: GNMIModeSample, Interval: 10 * time.Second},
+	{Path: "/system/memory/state", Mode: GNMIModeSample, Interval: 30 * time.Second},
+}
@@ -0,0 +1,56 @@
+package reporter
+
+import (
+	"context"
+	"time"
+
+	"github.com/netvis/collector/internal/collector"
+)
+
+// StartConfigs 启动配置快照上报, 失败时保留并在下次刷新时重试
+// 配置 Hash 与该设备同类型配置上次成功上报的相同时不发送
+func (r *Reporter) StartConfigs(ctx context.Context, configsCh <-chan collector.ConfigSnapshot) error {
+	r.logger.Info("Starting config reporter...")
+
+	runBatch(ctx, configsCh, eventFlushInterval, r.sendConfigs, func(count int, err error) {
+		r.logger.WithError(err).WithField("count", count).Warn("Failed to report config snapshots")
+	})
+	return nil
+}
+
+// sendConfigs 去重后上报一批配置快照, 服务端按哈希跳过与最近备份相同的配置
+// 发送成功后才记录哈希, 失败或被丢弃的快照在下次采集时重新发送
+func (r *Reporter) sendConfigs(snapshots []collector.ConfigSnapshot) error {
+	pending := make([]collector.ConfigSnapshot, 0, len(snapshots))
+	hashes := make(map[string]string)
+	for _, s := range snapshots {
+		key := configKey(s)
+		if r.configsSent[key] == s.Hash || hashes[key] == s.Hash {
+			continue
+		}
+		hashes[key] = s.Hash
+		pending = append(pending, s)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	payload := map[string]interface{}{
+		"collectorId": r.config.Collector.ID,
+		"timestamp":   time.Now().UTC(),
+		"configs":     pending,
+	}
+	if err := r.postJSON("/collector/configs", payload); err != nil {
+		return err
+	}
+
+	for _, s := range pending {
+		r.configsSent[configKey(s)] = s.Hash
+	}
+	r.logger.WithField("count", len(pending)).Info("Config snapshots reported")
+	return nil
+}
+
+func configKey(s collector.ConfigSnapshot) string {
+	return s.DeviceID + "/" + s.Type
+}
@@ -0,0 +1,115 @@
+package reporter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/netvis/collector/internal/collector"
+	"github.com/sirupsen/logrus"
+)
+
+// endpointState 某设备最近一次成功上报的终端定位表
+type endpointState struct {
+	arp    map[string]collector.ARPEntry
+	fdb    map[string]collector.FDBEntry
+	sentAt time.Time
+}
+
+// endpointDelta 终端定位表增量; Full 为 true 时 Upserted 即完整表, 服务端应整表替换
+type endpointDelta struct {
+	CollectorID string                        `json:"collectorId"`
+	DeviceID    string                        `json:"deviceId"`
+	IP          string                        `json:"ip"`
+	Full        bool                          `json:"full"`
+	ARP         changeSet[collector.ARPEntry] `json:"arp"`
+	FDB         changeSet[collector.FDBEntry] `json:"fdb"`
+	CollectedAt time.Time                     `json:"collectedAt"`
+}
+
+// changeSet 新增/变更与删除的表项
+type changeSet[T any] struct {
+	Upserted []T `json:"upserted"`
+	Removed  []T `json:"removed"`
+}
+
+// StartEndpoints 启动终端定位表上报
+// 与上次成功上报的快照比较后仅发送增量, 首次及超过 endpointResync 后发送全量
+func (r *Reporter) StartEndpoints(ctx context.Context, endpointsCh <-chan collector.EndpointTable) error {
+	r.logger.Info("Starting endpoint table reporter...")
+
+	runStream(ctx, endpointsCh,
+		func(data collector.EndpointTable) string { return data.DeviceID },
+		r.sendEndpoints,
+		func(data collector.EndpointTable, err error) {
+			r.logger.WithError(err).WithField("device", data.IP).Warn("Failed to report endpoint table")
+		},
+	)
+	return nil
+}
+
+// sendEndpoints 计算增量并上报单台设备的终端定位表
+func (r *Reporter) sendEndpoints(data collector.EndpointTable) error {
+	arp := make(map[string]collector.ARPEntry, len(data.ARP))
+	for _, e := range data.ARP {
+		arp[fmt.Sprintf("%s@%d", e.IP, e.IfIndex)] = e
+	}
+	fdb := make(map[string]collector.FDBEntry, len(data.FDB))
+	for _, e := range data.FDB {
+		fdb[fmt.Sprintf("%d/%s", e.VLAN, e.MAC)] = e
+	}
+
+	delta := endpointDelta{
+		CollectorID: r.config.Collector.ID,
+		DeviceID:    data.DeviceID,
+		IP:          data.IP,
+		CollectedAt: data.CollectedAt,
+	}
+
+	last, ok := r.endpointsSent[data.DeviceID]
+	if !ok || time.Since(last.sentAt) >= r.config.Collector.EndpointResync {
+		delta.Full = true
+		delta.ARP.Upserted = data.ARP
+		delta.FDB.Upserted = data.FDB
+	} else {
+		delta.ARP = diffEntries(last.arp, arp)
+		delta.FDB = diffEntries(last.fdb, fdb)
+		if len(delta.ARP.Upserted)+len(delta.ARP.Removed)+len(delta.FDB.Upserted)+len(delta.FDB.Removed) == 0 {
+			return nil
+		}
+	}
+
+	if err := r.postJSON("/collector/endpoints", delta); err != nil {
+		return err
+	}
+
+	sentAt := time.Now()
+	if !delta.Full {
+		sentAt = last.sentAt // 增量不刷新全量重发计时
+	}
+	r.endpointsSent[data.DeviceID] = endpointState{arp: arp, fdb: fdb, sentAt: sentAt}
+
+	r.logger.WithFields(logrus.Fields{
+		"device":     data.IP,
+		"full":       delta.Full,
+		"arpChanged": len(delta.ARP.Upserted) + len(delta.ARP.Removed),
+		"fdbChanged": len(delta.FDB.Upserted) + len(delta.FDB.Removed),
+	}).Info("Endpoint table reported successfully")
+	return nil
+}
+
+// diffEntries 比较两次快照, 值不同的表项视为变更
+func diffEntries[T comparable](prev, cur map[string]T) changeSet[T] {
+	var changes changeSet[T]
+	for k, v := range cur {
+		if old, ok := prev[k]; !ok || old != v {
+			changes.Upserted = append(changes.Upserted, v)
+		}
+	}
+	for k, v := range prev {
+		if _, ok := cur[k]; !ok {
+			changes.Removed = append(changes.Removed, v)
+		}
+	}
+	return changes
+}
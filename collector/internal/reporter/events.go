@@ -0,0 +1,34 @@
+package reporter
+
+import (
+	"context"
+	"time"
+
+	"github.com/netvis/collector/internal/collector"
+)
+
+// StartEvents 启动状态变化事件上报
+// 事件按到达顺序批量发送, 失败时保留并在下次刷新时重试
+func (r *Reporter) StartEvents(ctx context.Context, eventsCh <-chan collector.StateEvent) error {
+	r.logger.Info("Starting event reporter...")
+
+	runBatch(ctx, eventsCh, eventFlushInterval, r.sendEvents, func(count int, err error) {
+		r.logger.WithError(err).WithField("count", count).Warn("Failed to report state events")
+	})
+	return nil
+}
+
+// sendEvents 上报一批状态事件
+func (r *Reporter) sendEvents(events []collector.StateEvent) error {
+	payload := map[string]interface{}{
+		"collectorId": r.config.Collector.ID,
+		"timestamp":   time.Now().UTC(),
+		"events":      events,
+	}
+	if err := r.postJSON("/collector/events", payload); err != nil {
+		return err
+	}
+
+	r.logger.WithField("count", len(events)).Debug("State events reported")
+	return nil
+}
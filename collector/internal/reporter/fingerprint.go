@@ -0,0 +1,66 @@
+package reporter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/netvis/collector/internal/collector"
+	"github.com/sirupsen/logrus"
+)
+
+// StartFingerprints 启动设备指纹上报
+// 指纹未变化时不重复发送, 超过 fingerprintResync 后强制重发; 发送失败的数据定期重试
+func (r *Reporter) StartFingerprints(ctx context.Context, fingerprintCh <-chan collector.Fingerprint) error {
+	r.logger.Info("Starting fingerprint reporter...")
+
+	runStream(ctx, fingerprintCh,
+		func(fp collector.Fingerprint) string { return fp.DeviceID },
+		r.sendFingerprint,
+		func(fp collector.Fingerprint, err error) {
+			r.logger.WithError(err).WithField("device", fp.IP).Warn("Failed to report fingerprint")
+		},
+	)
+	return nil
+}
+
+// sendFingerprint 去重后上报单台设备的指纹
+func (r *Reporter) sendFingerprint(fp collector.Fingerprint) error {
+	hash, err := fingerprintHash(fp)
+	if err != nil {
+		return err
+	}
+
+	if last, ok := r.fingerprintSent[fp.DeviceID]; ok && last.hash == hash &&
+		time.Since(last.sentAt) < r.config.Collector.FingerprintResync {
+		return nil
+	}
+
+	if fp.CollectorID == "" {
+		fp.CollectorID = r.config.Collector.ID
+	}
+	if err := r.postJSON("/collector/fingerprint", fp); err != nil {
+		return err
+	}
+
+	r.fingerprintSent[fp.DeviceID] = sentState{hash: hash, sentAt: time.Now()}
+	r.logger.WithFields(logrus.Fields{
+		"device": fp.IP,
+		"vendor": fp.Vendor,
+		"model":  fp.Model,
+	}).Info("Fingerprint reported successfully")
+	return nil
+}
+
+// fingerprintHash 计算指纹摘要 (忽略采集时间)
+func fingerprintHash(fp collector.Fingerprint) (string, error) {
+	fp.CollectedAt = time.Time{}
+	body, err := json.Marshal(fp)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,33 @@
+package reporter
+
+import (
+	"context"
+	"time"
+
+	"github.com/netvis/collector/internal/collector"
+)
+
+// StartFlows 启动流量汇总上报, 缓冲与重试方式与指标一致
+func (r *Reporter) StartFlows(ctx context.Context, flowsCh <-chan collector.FlowAggregate) error {
+	r.logger.Info("Starting flow reporter...")
+
+	runBatch(ctx, flowsCh, metricsFlushInterval, r.sendFlows, func(count int, err error) {
+		r.logger.WithError(err).WithField("count", count).Warn("Failed to report flow aggregates")
+	})
+	return nil
+}
+
+// sendFlows 上报一批按接口汇总的流量
+func (r *Reporter) sendFlows(flows []collector.FlowAggregate) error {
+	payload := map[string]interface{}{
+		"collectorId": r.config.Collector.ID,
+		"timestamp":   time.Now().UTC(),
+		"flows":       flows,
+	}
+	if err := r.postJSON("/collector/flows", payload); err != nil {
+		return err
+	}
+
+	r.logger.WithField("count", len(flows)).Debug("Flow aggregates reported")
+	return nil
+}
@@ -0,0 +1,44 @@
+package reporter
+
+import (
+	"context"
+
+	"github.com/netvis/collector/internal/collector"
+	"github.com/sirupsen/logrus"
+)
+
+// StartInventory 启动硬件清单上报
+// 清单 Hash 与该设备上次成功上报的相同时不发送; 发送失败的数据定期重试
+func (r *Reporter) StartInventory(ctx context.Context, inventoryCh <-chan collector.Inventory) error {
+	r.logger.Info("Starting inventory reporter...")
+
+	runStream(ctx, inventoryCh,
+		func(inv collector.Inventory) string { return inv.DeviceID },
+		r.sendInventory,
+		func(inv collector.Inventory, err error) {
+			r.logger.WithError(err).WithField("device", inv.IP).Warn("Failed to report inventory")
+		},
+	)
+	return nil
+}
+
+// sendInventory 去重后上报单台设备的硬件清单
+func (r *Reporter) sendInventory(inv collector.Inventory) error {
+	if r.inventorySent[inv.DeviceID] == inv.Hash {
+		return nil
+	}
+
+	if inv.CollectorID == "" {
+		inv.CollectorID = r.config.Collector.ID
+	}
+	if err := r.postJSON("/collector/inventory", inv); err != nil {
+		return err
+	}
+
+	r.inventorySent[inv.DeviceID] = inv.Hash
+	r.logger.WithFields(logrus.Fields{
+		"device": inv.IP,
+		"items":  len(inv.Items),
+	}).Info("Inventory reported successfully")
+	return nil
+}
@@ -15,11 +15,14 @@ import (
 
 // Reporter 数据上报器
 type Reporter struct {
-	config     *config.Config
-	logger     *logrus.Logger
-	httpClient *http.Client
-	buffer     []collector.DeviceMetrics
-	bufferSize int
+	config          *config.Config
+	logger          *logrus.Logger
+	httpClient      *http.Client
+	topologySent    map[string]sentState
+	endpointsSent   map[string]endpointState
+	fingerprintSent map[string]sentState
+	inventorySent   map[string]string // 设备 -> 最近一次上报的清单 Hash
+	configsSent     map[string]string // 设备/配置类型 -> 最近一次上报的配置 Hash
 }
 
 // New 创建上报器实例
@@ -30,50 +33,23 @@ func New(cfg *config.Config, logger *logrus.Logger) *Reporter {
 		httpClient: &http.Client{
 			Timeout: cfg.API.Timeout,
 		},
-		buffer:     make([]collector.DeviceMetrics, 0),
-		bufferSize: 100,
+		topologySent:    make(map[string]sentState),
+		endpointsSent:   make(map[string]endpointState),
+		fingerprintSent: make(map[string]sentState),
+		inventorySent:   make(map[string]string),
+		configsSent:     make(map[string]string),
 	}
 }
 
 // Start 启动上报器
+// 指标缓冲满 100 条或每 10 秒批量上报, 失败时保留在缓冲区重试
 func (r *Reporter) Start(ctx context.Context, metricsCh <-chan collector.DeviceMetrics) error {
 	r.logger.Info("Starting reporter...")
 
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			r.flush()
-			return nil
-		case metrics := <-metricsCh:
-			r.buffer = append(r.buffer, metrics)
-			if len(r.buffer) >= r.bufferSize {
-				r.flush()
-			}
-		case <-ticker.C:
-			if len(r.buffer) > 0 {
-				r.flush()
-			}
-		}
-	}
-}
-
-// flush 批量上报数据
-func (r *Reporter) flush() {
-	if len(r.buffer) == 0 {
-		return
-	}
-
-	data := r.buffer
-	r.buffer = make([]collector.DeviceMetrics, 0)
-
-	if err := r.report(data); err != nil {
-		r.logger.WithError(err).Error("Failed to report metrics")
-		// 重新放回buffer
-		r.buffer = append(data, r.buffer...)
-	}
+	runBatch(ctx, metricsCh, metricsFlushInterval, r.report, func(count int, err error) {
+		r.logger.WithError(err).WithField("count", count).Error("Failed to report metrics")
+	})
+	return nil
 }
 
 // report 上报数据到API
@@ -114,6 +90,36 @@ func (r *Reporter) report(metrics []collector.DeviceMetrics) error {
 	return nil
 }
 
+// postJSON 以JSON格式POST到API, 状态码>=400视为失败
+func (r *Reporter) postJSON(path string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+
+	url := fmt.Sprintf("%s%s", r.config.API.Endpoint, path)
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if r.config.API.Token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", r.config.API.Token))
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("server returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
 // RegisterCollector 注册采集器
 func (r *Reporter) RegisterCollector() error {
 	payload := map[string]interface{}{
@@ -187,12 +193,12 @@ func (r *Reporter) Heartbeat() error {
 	return nil
 }
 
-// GetDevices 从API获取设备列表
-func (r *Reporter) GetDevices() ([]collector.Device, error) {
+// GetDevices 从API获取设备列表及其引用的SNMP凭据模板
+func (r *Reporter) GetDevices() ([]collector.Device, []collector.CredentialProfile, error) {
 	url := fmt.Sprintf("%s/collector/devices", r.config.API.Endpoint)
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		return nil, nil, fmt.Errorf("create request: %w", err)
 	}
 
 	if r.config.API.Token != "" {
@@ -201,27 +207,28 @@ func (r *Reporter) GetDevices() ([]collector.Device, error) {
 
 	resp, err := r.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("send request: %w", err)
+		return nil, nil, fmt.Errorf("send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("server returned status %d", resp.StatusCode)
+		return nil, nil, fmt.Errorf("server returned status %d", resp.StatusCode)
 	}
 
 	var result struct {
-		Code    int                `json:"code"`
-		Message string             `json:"message"`
-		Data    []collector.Device `json:"data"`
+		Code     int                           `json:"code"`
+		Message  string                        `json:"message"`
+		Data     []collector.Device            `json:"data"`
+		Profiles []collector.CredentialProfile `json:"profiles"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+		return nil, nil, fmt.Errorf("decode response: %w", err)
 	}
 
 	if result.Code != 0 {
-		return nil, fmt.Errorf("api error: %s", result.Message)
+		return nil, nil, fmt.Errorf("api error: %s", result.Message)
 	}
 
-	return result.Data, nil
+	return result.Data, result.Profiles, nil
 }
@@ -0,0 +1,84 @@
+package reporter
+
+import (
+	"context"
+	"time"
+)
+
+// streamRetryInterval 按设备消息流的失败重试间隔
+const streamRetryInterval = 30 * time.Second
+
+// 批量消息流参数
+const (
+	metricsFlushInterval = 10 * time.Second
+	eventFlushInterval   = 5 * time.Second
+	batchSize            = 100
+	batchBufferLimit     = 10000 // 服务端长时间不可用时丢弃最旧的消息
+)
+
+// runStream 消费按设备划分的消息流
+// 发送失败的消息每台设备仅保留最新一份, 定期重试; 新消息到达时覆盖旧的待重试消息
+func runStream[T any](ctx context.Context, ch <-chan T, key func(T) string, send func(T) error, onError func(T, error)) {
+	ticker := time.NewTicker(streamRetryInterval)
+	defer ticker.Stop()
+
+	pending := make(map[string]T)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-ch:
+			k := key(msg)
+			delete(pending, k)
+			if err := send(msg); err != nil {
+				onError(msg, err)
+				pending[k] = msg
+			}
+		case <-ticker.C:
+			for k, msg := range pending {
+				if err := send(msg); err != nil {
+					continue
+				}
+				delete(pending, k)
+			}
+		}
+	}
+}
+
+// runBatch 消费批量消息流 (指标/事件/日志): 按到达顺序缓冲, 满 batchSize 或每 interval 批量发送
+// 发送失败的消息保留在缓冲区, 下次刷新时重试
+func runBatch[T any](ctx context.Context, ch <-chan T, interval time.Duration, send func([]T) error, onError func(int, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	buffer := make([]T, 0)
+	flush := func() {
+		if len(buffer) == 0 {
+			return
+		}
+		if err := send(buffer); err != nil {
+			onError(len(buffer), err)
+			if over := len(buffer) - batchBufferLimit; over > 0 {
+				buffer = buffer[over:]
+			}
+			return
+		}
+		buffer = make([]T, 0)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			flush()
+			return
+		case msg := <-ch:
+			buffer = append(buffer, msg)
+			if len(buffer) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
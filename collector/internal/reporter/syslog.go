@@ -0,0 +1,33 @@
+package reporter
+
+import (
+	"context"
+	"time"
+
+	"github.com/netvis/collector/internal/collector"
+)
+
+// StartSyslog 启动 syslog 上报, 缓冲与重试方式与指标一致
+func (r *Reporter) StartSyslog(ctx context.Context, syslogCh <-chan collector.SyslogMessage) error {
+	r.logger.Info("Starting syslog reporter...")
+
+	runBatch(ctx, syslogCh, metricsFlushInterval, r.sendSyslog, func(count int, err error) {
+		r.logger.WithError(err).WithField("count", count).Warn("Failed to report syslog messages")
+	})
+	return nil
+}
+
+// sendSyslog 上报一批 syslog 消息
+func (r *Reporter) sendSyslog(messages []collector.SyslogMessage) error {
+	payload := map[string]interface{}{
+		"collectorId": r.config.Collector.ID,
+		"timestamp":   time.Now().UTC(),
+		"messages":    messages,
+	}
+	if err := r.postJSON("/collector/syslog", payload); err != nil {
+		return err
+	}
+
+	r.logger.WithField("count", len(messages)).Debug("Syslog messages reported")
+	return nil
+}
@@ -0,0 +1,70 @@
+package reporter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/netvis/collector/internal/collector"
+	"github.com/sirupsen/logrus"
+)
+
+// sentState 某设备最近一次成功上报的内容摘要
+type sentState struct {
+	hash   string
+	sentAt time.Time
+}
+
+// StartTopology 启动拓扑上报
+// 邻居集合未变化时不重复发送, 超过 topologyResync 后强制全量重发; 发送失败的数据定期重试
+func (r *Reporter) StartTopology(ctx context.Context, topologyCh <-chan collector.TopologyData) error {
+	r.logger.Info("Starting topology reporter...")
+
+	runStream(ctx, topologyCh,
+		func(data collector.TopologyData) string { return data.DeviceID },
+		r.sendTopology,
+		func(data collector.TopologyData, err error) {
+			r.logger.WithError(err).WithField("device", data.IP).Warn("Failed to report topology")
+		},
+	)
+	return nil
+}
+
+// sendTopology 去重后上报单台设备的拓扑
+func (r *Reporter) sendTopology(data collector.TopologyData) error {
+	hash, err := topologyHash(data.Neighbors)
+	if err != nil {
+		return err
+	}
+
+	if last, ok := r.topologySent[data.DeviceID]; ok && last.hash == hash &&
+		time.Since(last.sentAt) < r.config.Collector.TopologyResync {
+		return nil
+	}
+
+	if data.CollectorID == "" {
+		data.CollectorID = r.config.Collector.ID
+	}
+	if err := r.postJSON("/collector/topology", data); err != nil {
+		return err
+	}
+
+	r.topologySent[data.DeviceID] = sentState{hash: hash, sentAt: time.Now()}
+	r.logger.WithFields(logrus.Fields{
+		"device":    data.IP,
+		"neighbors": len(data.Neighbors),
+	}).Info("Topology reported successfully")
+	return nil
+}
+
+// topologyHash 计算邻居集合摘要 (邻居已按本地端口排序)
+func topologyHash(neighbors []collector.Neighbor) (string, error) {
+	body, err := json.Marshal(neighbors)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,33 @@
+package reporter
+
+import (
+	"context"
+	"time"
+
+	"github.com/netvis/collector/internal/collector"
+)
+
+// StartTraps 启动 Trap/Inform 事件上报
+func (r *Reporter) StartTraps(ctx context.Context, trapsCh <-chan collector.TrapEvent) error {
+	r.logger.Info("Starting trap reporter...")
+
+	runBatch(ctx, trapsCh, eventFlushInterval, r.sendTraps, func(count int, err error) {
+		r.logger.WithError(err).WithField("count", count).Warn("Failed to report traps")
+	})
+	return nil
+}
+
+// sendTraps 上报一批 Trap/Inform
+func (r *Reporter) sendTraps(traps []collector.TrapEvent) error {
+	payload := map[string]interface{}{
+		"collectorId": r.config.Collector.ID,
+		"timestamp":   time.Now().UTC(),
+		"traps":       traps,
+	}
+	if err := r.postJSON("/collector/traps", payload); err != nil {
+		return err
+	}
+
+	r.logger.WithField("count", len(traps)).Debug("Traps reported")
+	return nil
+}
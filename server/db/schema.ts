@@ -1,4 +1,4 @@
-import { pgTable, text, timestamp, boolean, uuid, integer } from 'drizzle-orm/pg-core';
+import { pgTable, text, timestamp, boolean, uuid, integer, index } from 'drizzle-orm/pg-core';
 
 // 用户表
 export const users = pgTable('users', {
@@ -55,6 +55,7 @@ export const devices = pgTable('devices', {
   snmpEnabled: boolean('snmp_enabled').notNull().default(false),
   snmpCommunity: text('snmp_community').default('public'),
   snmpVersion: text('snmp_version').default('v2c'),
+  snmpTemplateId: uuid('snmp_template_id'), // 引用 SNMP 凭据模板 (snmp_templates), 优先于设备字段
   lastSeen: timestamp('last_seen'),
   createdAt: timestamp('created_at').notNull().defaultNow(),
   updatedAt: timestamp('updated_at').notNull().defaultNow(),
@@ -224,7 +225,7 @@ export const apiKeys = pgTable('api_keys', {
   name: text('name').notNull(),
   key: text('key').notNull().unique(),
   secret: text('secret').notNull(),
-  permissions: text('permissions').array(), // read, write, admin
+  permissions: text('permissions').array(), // read, write, admin, collector
   rateLimit: integer('rate_limit').notNull().default(1000),
   isActive: boolean('is_active').notNull().default(true),
   lastUsedAt: timestamp('last_used_at'),
@@ -371,6 +372,10 @@ export const complianceResults = pgTable('compliance_results', {
 // Syslog日志表
 export const syslogMessages = pgTable('syslog_messages', {
   id: uuid('id').primaryKey().defaultRandom(),
+  deviceId: uuid('device_id').references(() => devices.id, { onDelete: 'set null' }), // 来源未匹配到已知设备时为空
+  sourceIp: text('source_ip'), // 报文来源地址
+  transport: text('transport'), // udp / tcp / tls
+  format: text('format'), // rfc3164 / rfc5424
   facility: integer('facility'),
   severity: integer('severity').notNull(),
   priority: integer('priority'),
@@ -379,10 +384,14 @@ export const syslogMessages = pgTable('syslog_messages', {
   appName: text('app_name'),
   procId: text('proc_id'),
   msgId: text('msg_id'),
+  structuredData: text('structured_data'), // RFC 5424 结构化数据原文
   message: text('message').notNull(),
   raw: text('raw'),
   createdAt: timestamp('created_at').notNull().defaultNow(),
-});
+}, (t) => [
+  // 按设备查询日志
+  index('syslog_messages_device_id_timestamp_idx').on(t.deviceId, t.timestamp),
+]);
 
 // SNMP模板表
 export const snmpTemplates = pgTable('snmp_templates', {
@@ -394,6 +403,14 @@ export const snmpTemplates = pgTable('snmp_templates', {
   securityLevel: text('security_level'),
   authProtocol: text('auth_protocol'),
   privProtocol: text('priv_protocol'),
+  username: text('username'), // v3 USM 用户名
+  authPassphrase: text('auth_passphrase'),
+  privPassphrase: text('priv_passphrase'),
+  contextName: text('context_name'),
+  port: integer('port').notNull().default(161),
+  timeout: integer('timeout').notNull().default(5), // 秒
+  retries: integer('retries').notNull().default(2),
+  maxRepetitions: integer('max_repetitions').notNull().default(25),
   oids: text('oids').notNull(), // JSON string of OID definitions
   createdAt: timestamp('created_at').notNull().defaultNow(),
   updatedAt: timestamp('updated_at').notNull().defaultNow(),
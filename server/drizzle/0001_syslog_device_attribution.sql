@@ -0,0 +1,7 @@
+ALTER TABLE "syslog_messages" ADD COLUMN "device_id" uuid;--> statement-breakpoint
+ALTER TABLE "syslog_messages" ADD COLUMN "source_ip" text;--> statement-breakpoint
+ALTER TABLE "syslog_messages" ADD COLUMN "transport" text;--> statement-breakpoint
+ALTER TABLE "syslog_messages" ADD COLUMN "format" text;--> statement-breakpoint
+ALTER TABLE "syslog_messages" ADD COLUMN "structured_data" text;--> statement-breakpoint
+ALTER TABLE "syslog_messages" ADD CONSTRAINT "syslog_messages_device_id_devices_id_fk" FOREIGN KEY ("device_id") REFERENCES "public"."devices"("id") ON DELETE set null ON UPDATE no action;--> statement-breakpoint
+CREATE INDEX "syslog_messages_device_id_timestamp_idx" ON "syslog_messages" USING btree ("device_id","timestamp");
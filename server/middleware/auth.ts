@@ -1,5 +1,8 @@
 import type { Context, Next } from 'hono';
 import jwt from 'jsonwebtoken';
+import crypto from 'crypto';
+import { db, schema } from '../db';
+import { eq, and } from 'drizzle-orm';
 
 const JWT_SECRET = process.env.JWT_SECRET;
 if (!JWT_SECRET) {
@@ -65,3 +68,61 @@ export function requireRole(...allowedRoles: string[]) {
     await next();
   };
 }
+
+// 采集器令牌: 环境变量 COLLECTOR_TOKEN 或具备 collector 权限的有效 API Key
+const COLLECTOR_TOKEN = process.env.COLLECTOR_TOKEN;
+
+const tokenEquals = (a: string, b: string) => {
+  const x = Buffer.from(a);
+  const y = Buffer.from(b);
+  return x.length === y.length && crypto.timingSafeEqual(x, y);
+};
+
+// 验证采集器令牌
+export async function verifyCollectorToken(token: string): Promise<boolean> {
+  if (COLLECTOR_TOKEN && tokenEquals(token, COLLECTOR_TOKEN)) {
+    return true;
+  }
+
+  const [key] = await db.select({
+    id: schema.apiKeys.id,
+    permissions: schema.apiKeys.permissions,
+    expiresAt: schema.apiKeys.expiresAt,
+  })
+    .from(schema.apiKeys)
+    .where(and(eq(schema.apiKeys.key, token), eq(schema.apiKeys.isActive, true)))
+    .limit(1);
+
+  if (!key || !key.permissions?.includes('collector')) {
+    return false;
+  }
+  if (key.expiresAt && key.expiresAt.getTime() < Date.now()) {
+    return false;
+  }
+
+  db.update(schema.apiKeys)
+    .set({ lastUsedAt: new Date() })
+    .where(eq(schema.apiKeys.id, key.id))
+    .catch(() => { /* ignore */ });
+  return true;
+}
+
+// 采集器认证中间件 (采集器以 api.token 作为 Bearer 令牌)
+export async function collectorAuth(c: Context, next: Next) {
+  const authHeader = c.req.header('Authorization');
+
+  if (!authHeader || !authHeader.startsWith('Bearer ')) {
+    return c.json({ code: 401, message: '未提供采集器令牌' }, 401);
+  }
+
+  try {
+    if (!(await verifyCollectorToken(authHeader.substring(7)))) {
+      return c.json({ code: 401, message: '采集器令牌无效或已过期' }, 401);
+    }
+  } catch (error) {
+    console.error('Verify collector token error:', error);
+    return c.json({ code: 500, message: '验证采集器令牌失败' }, 500);
+  }
+
+  await next();
+}
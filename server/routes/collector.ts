@@ -2,9 +2,14 @@ import { Hono } from 'hono';
 import { zValidator } from '@hono/zod-validator';
 import { z } from 'zod';
 import { db, schema } from '../db';
-import { eq, desc } from 'drizzle-orm';
-import { authMiddleware, requireRole } from '../middleware/auth';
+import { eq, ne, desc, and, inArray } from 'drizzle-orm';
+import { authMiddleware, requireRole, collectorAuth } from '../middleware/auth';
 import type { JwtPayload } from '../middleware/auth';
+import { findSSHCredential } from './ssh';
+import { recordHardwareInventory } from './inventory';
+import { applyEndpointDelta } from './port-mapping';
+import { recordTraps } from './snmp';
+import { recordFlows } from './traffic';
 
 const collectorRoutes = new Hono<{
   Variables: {
@@ -90,6 +95,23 @@ collectorRoutes.post('/heartbeat', zValidator('json', z.object({
   }
 });
 
+// 采集器设备状态 -> 设备表状态
+// online / degraded / unreachable / snmp-failed / unknown (旧版采集器上报 online / offline)
+const toDeviceStatus = (status: string): 'online' | 'offline' | 'warning' | 'unknown' => {
+  switch (status) {
+    case 'online':
+      return 'online';
+    case 'degraded':
+    case 'snmp-failed':
+      return 'warning';
+    case 'unreachable':
+    case 'offline':
+      return 'offline';
+    default:
+      return 'unknown';
+  }
+};
+
 // 上报指标
 const metricsSchema = z.object({
   collectorId: z.string(),
@@ -98,6 +120,7 @@ const metricsSchema = z.object({
     deviceId: z.string(),
     ip: z.string(),
     status: z.string(),
+    reason: z.string().optional(),
     latency: z.number(),
     packetLoss: z.number().optional(),
     cpuUsage: z.number().optional(),
@@ -121,7 +144,7 @@ collectorRoutes.post('/metrics', zValidator('json', metricsSchema), async (c) =>
       const metricsToInsert = data.metrics.map(m => ({
         deviceId: m.deviceId,
         collectorId: data.collectorId,
-        status: m.status,
+        status: toDeviceStatus(m.status),
         latency: Math.round(m.latency), // integer in schema
         packetLoss: Math.round(m.packetLoss || 0),
         cpuUsage: Math.round(m.cpuUsage || 0),
@@ -138,7 +161,7 @@ collectorRoutes.post('/metrics', zValidator('json', metricsSchema), async (c) =>
         try {
            await db.update(schema.devices)
             .set({ 
-              status: m.status as 'online' | 'offline',
+              status: toDeviceStatus(m.status),
               updatedAt: new Date(),
             })
             .where(eq(schema.devices.id, m.deviceId));
@@ -156,6 +179,467 @@ collectorRoutes.post('/metrics', zValidator('json', metricsSchema), async (c) =>
   }
 });
 
+// 上报状态变化事件 (采集器经抖动抑制确认后发送)
+// 设备事件立即同步设备状态; down 事件生成告警, 对应的 up 事件将其置为已恢复
+const eventsSchema = z.object({
+  collectorId: z.string(),
+  timestamp: z.string(),
+  events: z.array(z.object({
+    deviceId: z.string(),
+    ip: z.string(),
+    kind: z.enum(['device', 'interface']),
+    event: z.enum(['down', 'up', 'change']),
+    ifIndex: z.number().optional(),
+    interface: z.string().optional(),
+    state: z.string(),
+    previousState: z.string(),
+    duration: z.number(),
+    reason: z.string().optional(),
+    timestamp: z.string(),
+  })),
+});
+
+// 同一设备/接口的 down 与 up 事件使用相同的告警消息, 以便恢复时匹配
+const eventAlertMessage = (e: z.infer<typeof eventsSchema>['events'][number]) =>
+  e.kind === 'device'
+    ? `设备 ${e.ip} 不可达`
+    : `设备 ${e.ip} 接口 ${e.interface || e.ifIndex} 已断开`;
+
+collectorRoutes.post('/events', collectorAuth, zValidator('json', eventsSchema), async (c) => {
+  const data = c.req.valid('json');
+
+  try {
+    // 忽略已删除设备的事件, 避免外键错误导致整批重试
+    const ids = [...new Set(data.events.map(e => e.deviceId))];
+    const known = new Set(ids.length === 0 ? [] : (await db.select({ id: schema.devices.id })
+      .from(schema.devices)
+      .where(inArray(schema.devices.id, ids))).map(d => d.id));
+
+    for (const e of data.events) {
+      if (!known.has(e.deviceId)) {
+        continue;
+      }
+      const at = new Date(e.timestamp);
+
+      if (e.kind === 'device') {
+        await db.update(schema.devices)
+          .set({ status: toDeviceStatus(e.state), updatedAt: at })
+          .where(eq(schema.devices.id, e.deviceId));
+      }
+
+      if (e.event === 'down') {
+        await db.insert(schema.alerts).values({
+          deviceId: e.deviceId,
+          severity: e.kind === 'device' ? 'critical' : 'warning',
+          status: 'pending',
+          message: eventAlertMessage(e),
+          details: JSON.stringify({
+            source: 'collector',
+            collectorId: data.collectorId,
+            kind: e.kind,
+            ifIndex: e.ifIndex,
+            interface: e.interface,
+            state: e.state,
+            previousState: e.previousState,
+            reason: e.reason,
+            timestamp: e.timestamp,
+          }),
+        });
+      } else if (e.event === 'up') {
+        await db.update(schema.alerts)
+          .set({ status: 'resolved', resolvedAt: at })
+          .where(and(
+            eq(schema.alerts.deviceId, e.deviceId),
+            eq(schema.alerts.message, eventAlertMessage(e)),
+            ne(schema.alerts.status, 'resolved'),
+          ));
+      }
+    }
+
+    return c.json({
+      code: 0,
+      message: `已接收 ${data.events.length} 条状态事件`,
+    });
+  } catch (error) {
+    console.error('Store events error:', error);
+    return c.json({ code: 500, message: '存储状态事件失败' }, 500);
+  }
+});
+
+// 上报 Trap/Inform (采集器内置接收器解码后批量转发)
+const trapsSchema = z.object({
+  collectorId: z.string(),
+  timestamp: z.string(),
+  traps: z.array(z.object({
+    collectorId: z.string(),
+    deviceId: z.string().optional(),
+    source: z.string(),
+    agentAddress: z.string().optional(),
+    version: z.string(),
+    pduType: z.string(),
+    trapOid: z.string(),
+    name: z.string().optional(),
+    uptime: z.number(),
+    user: z.string().optional(),
+    varbinds: z.array(z.object({
+      oid: z.string(),
+      type: z.string(),
+      value: z.string(),
+    })),
+    receivedAt: z.string(),
+  })),
+});
+
+collectorRoutes.post('/traps', collectorAuth, zValidator('json', trapsSchema), async (c) => {
+  const data = c.req.valid('json');
+
+  try {
+    recordTraps(data.traps.map(t => ({ ...t, receivedAt: new Date(t.receivedAt) })));
+    return c.json({
+      code: 0,
+      message: `已接收 ${data.traps.length} 条Trap`,
+    });
+  } catch (error) {
+    console.error('Store traps error:', error);
+    return c.json({ code: 500, message: '存储Trap失败' }, 500);
+  }
+});
+
+// 上报流量汇总 (NetFlow/IPFIX/sFlow 按接口汇总后的 top talkers / 应用, 不含原始流)
+const flowTalkerSchema = z.object({
+  address: z.string(),
+  bytes: z.number(),
+  packets: z.number(),
+  flows: z.number(),
+});
+
+const flowAppSchema = z.object({
+  protocol: z.string(),
+  port: z.number(),
+  name: z.string().optional(),
+  bytes: z.number(),
+  packets: z.number(),
+  flows: z.number(),
+});
+
+const flowsSchema = z.object({
+  collectorId: z.string(),
+  timestamp: z.string(),
+  flows: z.array(z.object({
+    collectorId: z.string(),
+    deviceId: z.string().optional(),
+    exporter: z.string(),
+    ifIndex: z.number(),
+    direction: z.enum(['in', 'out']),
+    windowStart: z.string(),
+    windowEnd: z.string(),
+    bytes: z.number(),
+    packets: z.number(),
+    flows: z.number(),
+    // 窗口内无明细时采集器以 null 发送
+    topTalkers: z.array(flowTalkerSchema).nullish().transform(v => v ?? []),
+    topApps: z.array(flowAppSchema).nullish().transform(v => v ?? []),
+  })),
+});
+
+collectorRoutes.post('/flows', collectorAuth, zValidator('json', flowsSchema), async (c) => {
+  const data = c.req.valid('json');
+
+  try {
+    recordFlows(data.flows.map(f => ({
+      ...f,
+      windowStart: new Date(f.windowStart),
+      windowEnd: new Date(f.windowEnd),
+    })));
+    return c.json({
+      code: 0,
+      message: `已接收 ${data.flows.length} 条流量汇总`,
+    });
+  } catch (error) {
+    console.error('Store flows error:', error);
+    return c.json({ code: 500, message: '存储流量汇总失败' }, 500);
+  }
+});
+
+// 上报终端定位表增量 (采集器按设备比较 ARP / MAC 转发表后发送)
+const arpEntrySchema = z.object({
+  ip: z.string(),
+  mac: z.string(),
+  ifIndex: z.number(),
+  interface: z.string().optional(),
+  type: z.string().optional(),
+});
+
+const fdbEntrySchema = z.object({
+  mac: z.string(),
+  vlan: z.number().optional(),
+  bridgePort: z.number(),
+  ifIndex: z.number().optional(),
+  interface: z.string().optional(),
+  status: z.string().optional(),
+});
+
+// 采集器未变化的一侧以 null 发送
+const changeSetSchema = <T extends z.ZodTypeAny>(entry: T) => z.object({
+  upserted: z.array(entry).nullish().transform(v => v ?? []),
+  removed: z.array(entry).nullish().transform(v => v ?? []),
+});
+
+const endpointsSchema = z.object({
+  collectorId: z.string(),
+  deviceId: z.string(),
+  ip: z.string(),
+  full: z.boolean(),
+  arp: changeSetSchema(arpEntrySchema),
+  fdb: changeSetSchema(fdbEntrySchema),
+  collectedAt: z.string(),
+});
+
+collectorRoutes.post('/endpoints', collectorAuth, zValidator('json', endpointsSchema), async (c) => {
+  const data = c.req.valid('json');
+
+  try {
+    const size = applyEndpointDelta({ ...data, collectedAt: new Date(data.collectedAt) });
+    return c.json({
+      code: 0,
+      message: `终端定位表已更新 (ARP ${size.arp} 条, 转发表 ${size.fdb} 条)`,
+    });
+  } catch (error) {
+    console.error('Store endpoints error:', error);
+    return c.json({ code: 500, message: '存储终端定位表失败' }, 500);
+  }
+});
+
+// 上报Syslog (采集器内置接收器解析后批量转发)
+const syslogSchema = z.object({
+  collectorId: z.string(),
+  timestamp: z.string(),
+  messages: z.array(z.object({
+    // 非UUID的设备ID按未归属处理, 不拒绝整批
+    deviceId: z.string().uuid().optional().catch(undefined),
+    source: z.string(),
+    transport: z.string().optional(),
+    format: z.string().optional(),
+    facility: z.number(),
+    severity: z.number(),
+    priority: z.number(),
+    timestamp: z.string(),
+    hostname: z.string().optional(),
+    appName: z.string().optional(),
+    procId: z.string().optional(),
+    msgId: z.string().optional(),
+    structuredData: z.string().optional(),
+    message: z.string(),
+    raw: z.string().optional(),
+  })),
+});
+
+collectorRoutes.post('/syslog', collectorAuth, zValidator('json', syslogSchema), async (c) => {
+  const data = c.req.valid('json');
+
+  try {
+    if (data.messages.length > 0) {
+      // 设备已删除时保留日志但不关联设备
+      const ids = [...new Set(data.messages.flatMap(m => m.deviceId ? [m.deviceId] : []))];
+      const known = new Set(ids.length === 0 ? [] : (await db.select({ id: schema.devices.id })
+        .from(schema.devices)
+        .where(inArray(schema.devices.id, ids))).map(d => d.id));
+
+      await db.insert(schema.syslogMessages).values(data.messages.map(m => ({
+        deviceId: m.deviceId && known.has(m.deviceId) ? m.deviceId : null,
+        sourceIp: m.source,
+        transport: m.transport,
+        format: m.format,
+        facility: m.facility,
+        severity: m.severity,
+        priority: m.priority,
+        timestamp: new Date(m.timestamp),
+        hostname: m.hostname || m.source,
+        appName: m.appName,
+        procId: m.procId,
+        msgId: m.msgId,
+        structuredData: m.structuredData,
+        message: m.message,
+        raw: m.raw,
+      })));
+    }
+
+    return c.json({
+      code: 0,
+      message: `已接收并存储 ${data.messages.length} 条日志`,
+    });
+  } catch (error) {
+    console.error('Store syslog error:', error);
+    return c.json({ code: 500, message: '存储日志失败' }, 500);
+  }
+});
+
+
+// 上报配置快照 (采集器 ssh 探针备份的运行配置)
+const configsSchema = z.object({
+  collectorId: z.string(),
+  timestamp: z.string(),
+  configs: z.array(z.object({
+    deviceId: z.string(),
+    ip: z.string().optional(),
+    vendor: z.string().optional(),
+    type: z.string().default('running'),
+    content: z.string(),
+    size: z.number(),
+    hash: z.string(),
+    outputs: z.record(z.string()).optional(),
+    collectedAt: z.string(),
+  })),
+});
+
+collectorRoutes.post('/configs', collectorAuth, zValidator('json', configsSchema), async (c) => {
+  const data = c.req.valid('json');
+
+  try {
+    let stored = 0;
+    for (const cfg of data.configs) {
+      // 与该设备最近一次备份相同则跳过 (采集器重启后会重新上报)
+      const [latest] = await db.select({ hash: schema.configBackups.hash })
+        .from(schema.configBackups)
+        .where(and(
+          eq(schema.configBackups.deviceId, cfg.deviceId),
+          eq(schema.configBackups.type, cfg.type),
+        ))
+        .orderBy(desc(schema.configBackups.createdAt))
+        .limit(1);
+      if (latest?.hash === cfg.hash) {
+        continue;
+      }
+
+      await db.insert(schema.configBackups).values({
+        deviceId: cfg.deviceId,
+        type: cfg.type,
+        version: `v${new Date(cfg.collectedAt).getTime()}`,
+        content: cfg.content,
+        size: cfg.size,
+        hash: cfg.hash,
+        description: '采集器自动备份',
+      });
+      stored++;
+    }
+
+    return c.json({
+      code: 0,
+      message: `已接收 ${data.configs.length} 份配置, 新增备份 ${stored} 份`,
+    });
+  } catch (error) {
+    console.error('Store configs error:', error);
+    return c.json({ code: 500, message: '存储配置失败' }, 500);
+  }
+});
+
+
+// 上报设备指纹 (采集器按 SNMP 系统组识别的厂商/型号), 仅补全设备记录中为空的字段
+const fingerprintSchema = z.object({
+  collectorId: z.string(),
+  deviceId: z.string(),
+  ip: z.string(),
+  sysDescr: z.string(),
+  sysObjectId: z.string(),
+  sysName: z.string().optional(),
+  sysContact: z.string().optional(),
+  sysLocation: z.string().optional(),
+  vendor: z.string().optional(),
+  vendorName: z.string().optional(),
+  model: z.string().optional(),
+  os: z.string().optional(),
+  version: z.string().optional(),
+  deviceType: z.string().optional(),
+  typeMismatch: z.boolean().optional(),
+  collectedAt: z.string(),
+});
+
+collectorRoutes.post('/fingerprint', collectorAuth, zValidator('json', fingerprintSchema), async (c) => {
+  const data = c.req.valid('json');
+
+  try {
+    const [device] = await db.select({
+      type: schema.devices.type,
+      vendor: schema.devices.vendor,
+      model: schema.devices.model,
+      location: schema.devices.location,
+    })
+      .from(schema.devices)
+      .where(eq(schema.devices.id, data.deviceId))
+      .limit(1);
+    if (!device) {
+      return c.json({ code: 404, message: '设备不存在' }, 404);
+    }
+
+    const updates: Partial<{ vendor: string; model: string; location: string }> = {};
+    if (!device.vendor && data.vendor) updates.vendor = data.vendor;
+    if (!device.model && data.model) updates.model = data.model;
+    if (!device.location && data.sysLocation) updates.location = data.sysLocation;
+
+    if (Object.keys(updates).length > 0) {
+      await db.update(schema.devices)
+        .set({ ...updates, updatedAt: new Date() })
+        .where(eq(schema.devices.id, data.deviceId));
+    }
+
+    if (data.typeMismatch) {
+      console.warn(`Device ${data.ip} is registered as ${device.type} but fingerprints as ${data.deviceType}`);
+    }
+
+    return c.json({
+      code: 0,
+      message: '设备指纹已接收',
+      data: { updated: Object.keys(updates) },
+    });
+  } catch (error) {
+    console.error('Store fingerprint error:', error);
+    return c.json({ code: 500, message: '存储设备指纹失败' }, 500);
+  }
+});
+
+
+// 上报硬件清单 (ENTITY-MIB, 清单哈希变化时发送)
+const inventorySchema = z.object({
+  collectorId: z.string(),
+  deviceId: z.string(),
+  ip: z.string(),
+  hash: z.string(),
+  items: z.array(z.object({
+    index: z.number(),
+    class: z.string(),
+    name: z.string().optional(),
+    descr: z.string().optional(),
+    containedIn: z.number(),
+    parentRelPos: z.number(),
+    vendorType: z.string().optional(),
+    hardwareRev: z.string().optional(),
+    firmwareRev: z.string().optional(),
+    softwareRev: z.string().optional(),
+    serialNumber: z.string().optional(),
+    manufacturer: z.string().optional(),
+    model: z.string().optional(),
+    fru: z.boolean().optional(),
+    transceiver: z.boolean().optional(),
+    ifIndex: z.number().optional(),
+  })),
+  collectedAt: z.string(),
+});
+
+collectorRoutes.post('/inventory', collectorAuth, zValidator('json', inventorySchema), async (c) => {
+  const data = c.req.valid('json');
+
+  try {
+    const changes = await recordHardwareInventory(data.deviceId, data.hash, data.items, new Date(data.collectedAt));
+    return c.json({
+      code: 0,
+      message: `已接收 ${data.items.length} 个硬件部件, 变更 ${changes} 项`,
+    });
+  } catch (error) {
+    console.error('Store inventory error:', error);
+    return c.json({ code: 500, message: '存储硬件清单失败' }, 500);
+  }
+});
+
 
 // 获取采集器列表
 collectorRoutes.get('/list', authMiddleware, requireRole('admin'), async (c) => {
@@ -179,25 +663,122 @@ collectorRoutes.get('/list', authMiddleware, requireRole('admin'), async (c) =>
   }
 });
 
-// 获取设备列表（供采集器拉取）
-collectorRoutes.get('/devices', async (c) => {
+// 获取设备列表（供采集器拉取, 需采集器令牌）
+// 引用了 SNMP 凭据模板的设备通过 credentialProfileId 引用模板 (ID 即模板ID), 未引用模板的设备直接携带版本/团体名
+// 关联了SSH凭据的设备使用附带 ssh 字段的模板, ID 为 `<模板ID>:ssh:<凭据ID>` (无SNMP模板时为 `ssh:<凭据ID>`)
+type CollectorProfile = {
+  id: string;
+  name?: string;
+  version?: string;
+  community?: string;
+  port?: number;
+  timeout?: number;
+  retries?: number;
+  maxRepetitions?: number;
+  v3?: {
+    username: string;
+    securityLevel?: string;
+    authProtocol?: string;
+    authPassphrase?: string;
+    privProtocol?: string;
+    privPassphrase?: string;
+    contextName?: string;
+  };
+  ssh?: {
+    username: string;
+    password?: string;
+    privateKey?: string;
+    passphrase?: string;
+    port: number;
+  };
+};
+
+const toSNMPProfile = (t: typeof schema.snmpTemplates.$inferSelect): CollectorProfile => {
+  const version = t.version.replace(/^v/i, '');
+  return {
+    id: t.id,
+    name: t.name,
+    version,
+    community: version === '3' ? undefined : t.community || undefined,
+    port: t.port,
+    timeout: t.timeout,
+    retries: t.retries,
+    maxRepetitions: t.maxRepetitions,
+    ...(version === '3' && t.username && {
+      v3: {
+        username: t.username,
+        securityLevel: t.securityLevel || undefined,
+        authProtocol: t.authProtocol || undefined,
+        authPassphrase: t.authPassphrase || undefined,
+        privProtocol: t.privProtocol || undefined,
+        privPassphrase: t.privPassphrase || undefined,
+        contextName: t.contextName || undefined,
+      },
+    }),
+  };
+};
+
+collectorRoutes.get('/devices', collectorAuth, async (c) => {
   try {
     const devices = await db.select({
       id: schema.devices.id,
       ip: schema.devices.ipAddress,
       type: schema.devices.type,
-    }).from(schema.devices);
+      vendor: schema.devices.vendor,
+      snmpEnabled: schema.devices.snmpEnabled,
+      snmpVersion: schema.devices.snmpVersion,
+      snmpCommunity: schema.devices.snmpCommunity,
+      template: schema.snmpTemplates,
+    })
+      .from(schema.devices)
+      .leftJoin(schema.snmpTemplates, eq(schema.devices.snmpTemplateId, schema.snmpTemplates.id));
+
+    const profiles = new Map<string, CollectorProfile>();
+
+    const data = devices.map(d => {
+      const snmpProfile = d.snmpEnabled && d.template ? toSNMPProfile(d.template) : undefined;
+      let credentialProfileId = snmpProfile?.id;
+      if (snmpProfile && !profiles.has(snmpProfile.id)) {
+        profiles.set(snmpProfile.id, snmpProfile);
+      }
 
-    return c.json({
-      code: 0,
-      data: devices.map(d => ({
+      const sshCred = findSSHCredential(d.id);
+      if (sshCred) {
+        credentialProfileId = snmpProfile ? `${snmpProfile.id}:ssh:${sshCred.id}` : `ssh:${sshCred.id}`;
+        if (!profiles.has(credentialProfileId)) {
+          profiles.set(credentialProfileId, {
+            ...snmpProfile,
+            id: credentialProfileId,
+            ssh: {
+              username: sshCred.username,
+              password: sshCred.password,
+              privateKey: sshCred.privateKey,
+              passphrase: sshCred.passphrase,
+              port: sshCred.port,
+            },
+          });
+        }
+      }
+
+      // 未引用模板的设备使用设备字段, 未启用SNMP的设备不下发团体名
+      return {
         id: d.id,
         ip: d.ip || '',
         type: d.type,
-        community: 'public', // 默认SNMP团体名
-      })),
+        vendor: d.vendor || undefined,
+        community: d.snmpEnabled && !snmpProfile ? d.snmpCommunity || 'public' : '',
+        snmpVersion: d.snmpEnabled && !snmpProfile ? (d.snmpVersion || 'v2c').replace(/^v/i, '') : undefined,
+        credentialProfileId,
+      };
+    });
+
+    return c.json({
+      code: 0,
+      data,
+      profiles: Array.from(profiles.values()),
     });
   } catch (error) {
+    console.error('Get collector devices error:', error);
     return c.json({ code: 500, message: '获取设备列表失败' }, 500);
   }
 });
@@ -243,6 +824,7 @@ const topologySchema = z.object({
     remoteSystemName: z.string(),
     remoteIp: z.string().optional(),
     linkType: z.string().default('ethernet'),
+    protocol: z.enum(['lldp', 'cdp']).optional(), // 同一链路可能同时由LLDP与CDP上报, 按本地端口合并
   })),
 });
 
@@ -36,6 +36,7 @@ const deviceBodySchema = z.object({
   location: z.string().optional(),
   groupId: z.string().uuid().optional(),
   status: z.enum(['online', 'offline', 'warning', 'error', 'unknown']).optional(),
+  snmpTemplateId: z.string().uuid().nullable().optional(), // SNMP 凭据模板
 });
 
 // 获取设备列表
@@ -120,6 +121,7 @@ deviceRoutes.post('/', authMiddleware, requireRole('admin', 'user'), zValidator(
         location: data.location,
         groupId: data.groupId,
         status: data.status || 'unknown',
+        snmpTemplateId: data.snmpTemplateId,
       })
       .returning();
 
@@ -179,6 +181,7 @@ deviceRoutes.put('/:id', authMiddleware, requireRole('admin', 'user'), zValidato
     if (data.location !== undefined) updateData.location = data.location;
     if (data.groupId !== undefined) updateData.groupId = data.groupId;
     if (data.status !== undefined) updateData.status = data.status;
+    if (data.snmpTemplateId !== undefined) updateData.snmpTemplateId = data.snmpTemplateId;
 
     await db
       .update(schema.devices)
@@ -41,6 +41,85 @@ const assetChanges = new Map<string, {
   acknowledged: boolean;
 }>();
 
+// 硬件清单 (采集器 ENTITY-MIB 上报, 每台设备保留最新一份)
+export interface HardwareComponent {
+  index: number;
+  class: string;
+  name?: string;
+  descr?: string;
+  containedIn: number;
+  parentRelPos: number;
+  vendorType?: string;
+  hardwareRev?: string;
+  firmwareRev?: string;
+  softwareRev?: string;
+  serialNumber?: string;
+  manufacturer?: string;
+  model?: string;
+  fru?: boolean;
+  transceiver?: boolean;
+  ifIndex?: number;
+}
+
+const hardwareInventory = new Map<string, {
+  deviceId: string;
+  hash: string;
+  items: HardwareComponent[];
+  collectedAt: Date;
+}>();
+
+// 记录硬件清单, 与上一份比较, 按序列号记录部件的增加、移除与更换
+export async function recordHardwareInventory(deviceId: string, hash: string, items: HardwareComponent[], collectedAt: Date) {
+  const previous = hardwareInventory.get(deviceId);
+  hardwareInventory.set(deviceId, { deviceId, hash, items, collectedAt });
+  if (!previous || previous.hash === hash) {
+    return 0;
+  }
+
+  const [device] = await db.select({ name: schema.devices.name })
+    .from(schema.devices)
+    .where(eq(schema.devices.id, deviceId))
+    .limit(1);
+  const deviceName = device?.name || deviceId;
+
+  // 按实体名称 (槽位) 对比序列号, 无名称时使用索引
+  const key = (item: HardwareComponent) => item.name || `#${item.index}`;
+  const before = new Map(previous.items.filter(i => i.serialNumber).map(i => [key(i), i]));
+  const after = new Map(items.filter(i => i.serialNumber).map(i => [key(i), i]));
+
+  let changes = 0;
+  const record = (changeType: 'new' | 'modified' | 'removed', field: string, oldValue?: string, newValue?: string) => {
+    const id = crypto.randomUUID();
+    assetChanges.set(id, {
+      id,
+      deviceId,
+      deviceName,
+      changeType,
+      field,
+      oldValue,
+      newValue,
+      detectedAt: collectedAt,
+      acknowledged: false,
+    });
+    changes++;
+  };
+
+  for (const [slot, item] of after) {
+    const old = before.get(slot);
+    if (!old) {
+      record('new', slot, undefined, item.serialNumber);
+    } else if (old.serialNumber !== item.serialNumber) {
+      record('modified', slot, old.serialNumber, item.serialNumber);
+    }
+  }
+  for (const [slot, item] of before) {
+    if (!after.has(slot)) {
+      record('removed', slot, item.serialNumber, undefined);
+    }
+  }
+  return changes;
+}
+
 // 获取资产概览
 inventoryRoutes.get('/overview', authMiddleware, async (c) => {
   try {
@@ -178,6 +257,16 @@ inventoryRoutes.put('/changes/:id/acknowledge', authMiddleware, async (c) => {
   }
 });
 
+// 获取设备硬件清单
+inventoryRoutes.get('/hardware/:deviceId', authMiddleware, async (c) => {
+  const inventory = hardwareInventory.get(c.req.param('deviceId'));
+  if (!inventory) {
+    return c.json({ code: 404, message: '暂无硬件清单' }, 404);
+  }
+
+  return c.json({ code: 0, data: inventory });
+});
+
 // 获取资产统计报表
 inventoryRoutes.get('/report', authMiddleware, async (c) => {
   try {
@@ -18,6 +18,7 @@ const querySchema = z.object({
   pageSize: z.string().optional().transform(v => parseInt(v || '20')),
   level: z.enum(['all', 'info', 'warn', 'error']).optional(),
   source: z.string().optional(),
+  deviceId: z.string().uuid().optional(),
   startTime: z.string().optional(),
   endTime: z.string().optional(),
   keyword: z.string().optional(),
@@ -25,7 +26,7 @@ const querySchema = z.object({
 
 // 系统日志查询 (Real DB)
 logsRoutes.get('/system', authMiddleware, requireRole('admin'), zValidator('query', querySchema), async (c) => {
-  const { page, pageSize, level, source, startTime, endTime, keyword } = c.req.valid('query');
+  const { page, pageSize, level, source, deviceId, startTime, endTime, keyword } = c.req.valid('query');
 
   try {
     const offset = (page - 1) * pageSize;
@@ -47,6 +48,9 @@ logsRoutes.get('/system', authMiddleware, requireRole('admin'), zValidator('quer
     if (source) {
         conditions.push(eq(schema.syslogMessages.hostname, source));
     }
+    if (deviceId) {
+        conditions.push(eq(schema.syslogMessages.deviceId, deviceId));
+    }
     if (startTime) {
         conditions.push(gte(schema.syslogMessages.timestamp, new Date(startTime)));
     }
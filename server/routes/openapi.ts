@@ -17,7 +17,7 @@ const openApiRoutes = new Hono<{
 
 const createKeySchema = z.object({
   name: z.string().min(1, '名称不能为空'),
-  permissions: z.array(z.enum(['read', 'write', 'admin'])).default(['read']),
+  permissions: z.array(z.enum(['read', 'write', 'admin', 'collector'])).default(['read']),
   rateLimit: z.number().min(100).max(100000).default(1000),
   expiresInDays: z.number().min(1).max(365).optional(),
 });
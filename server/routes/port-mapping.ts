@@ -35,6 +35,63 @@ const vlans = new Map<string, {
   createdAt: Date;
 }>();
 
+// 终端定位表 (采集器上报的 ARP 与 MAC 转发表, 每台设备保留最新一份)
+export interface ARPEntry {
+  ip: string;
+  mac: string;
+  ifIndex: number;
+  interface?: string;
+  type?: string;
+}
+
+export interface FDBEntry {
+  mac: string;
+  vlan?: number;
+  bridgePort: number;
+  ifIndex?: number;
+  interface?: string;
+  status?: string;
+}
+
+export interface EndpointDelta {
+  deviceId: string;
+  ip: string;
+  full: boolean;
+  arp: { upserted: ARPEntry[]; removed: ARPEntry[] };
+  fdb: { upserted: FDBEntry[]; removed: FDBEntry[] };
+  collectedAt: Date;
+}
+
+const endpointTables = new Map<string, {
+  deviceId: string;
+  ip: string;
+  arp: Map<string, ARPEntry>;
+  fdb: Map<string, FDBEntry>;
+  collectedAt: Date;
+}>();
+
+// 表项键与采集器一致: ARP 按 IP@ifIndex, 转发表按 VLAN/MAC
+const arpKey = (e: ARPEntry) => `${e.ip}@${e.ifIndex}`;
+const fdbKey = (e: FDBEntry) => `${e.vlan || 0}/${e.mac}`;
+
+// 应用终端定位表增量, full 为 true 时整表替换
+export function applyEndpointDelta(delta: EndpointDelta) {
+  let table = endpointTables.get(delta.deviceId);
+  if (!table || delta.full) {
+    table = { deviceId: delta.deviceId, ip: delta.ip, arp: new Map(), fdb: new Map(), collectedAt: delta.collectedAt };
+    endpointTables.set(delta.deviceId, table);
+  }
+
+  delta.arp.removed.forEach(e => table.arp.delete(arpKey(e)));
+  delta.arp.upserted.forEach(e => table.arp.set(arpKey(e), e));
+  delta.fdb.removed.forEach(e => table.fdb.delete(fdbKey(e)));
+  delta.fdb.upserted.forEach(e => table.fdb.set(fdbKey(e), e));
+  table.ip = delta.ip;
+  table.collectedAt = delta.collectedAt;
+
+  return { arp: table.arp.size, fdb: table.fdb.size };
+}
+
 // 初始化示例数据
 [
   { id: 'pm-1', name: '核心上联', deviceId: 'dev-1', devicePort: 'GE1/0/1', remoteDevice: 'Core-SW', remotePort: 'GE0/0/1', protocol: 'trunk', vlan: 1, status: 'active' as const, speed: '10G', duplex: 'full', description: '核心交换机上联' },
@@ -107,6 +164,41 @@ portMappingRoutes.delete('/vlans/:id', authMiddleware, requireRole('admin'), asy
   return c.json({ code: 0, message: 'VLAN已删除' });
 });
 
+// 获取设备终端定位表
+portMappingRoutes.get('/endpoints/:deviceId', authMiddleware, async (c) => {
+  const table = endpointTables.get(c.req.param('deviceId'));
+  if (!table) return c.json({ code: 404, message: '暂无终端定位表' }, 404);
+  return c.json({
+    code: 0,
+    data: {
+      deviceId: table.deviceId,
+      ip: table.ip,
+      arp: Array.from(table.arp.values()),
+      fdb: Array.from(table.fdb.values()),
+      collectedAt: table.collectedAt,
+    },
+  });
+});
+
+// 终端定位: 按 MAC 或 IP 查找所在设备及端口
+portMappingRoutes.get('/endpoints', authMiddleware, async (c) => {
+  const ip = c.req.query('ip');
+  let mac = c.req.query('mac')?.toLowerCase();
+  if (!ip && !mac) return c.json({ code: 400, message: '请提供 mac 或 ip' }, 400);
+
+  const tables = Array.from(endpointTables.values());
+  if (!mac && ip) {
+    // 先经 ARP 表解析 MAC
+    mac = tables.flatMap(t => Array.from(t.arp.values())).find(e => e.ip === ip)?.mac.toLowerCase();
+    if (!mac) return c.json({ code: 0, data: [] });
+  }
+
+  const locations = tables.flatMap(t => Array.from(t.fdb.values())
+    .filter(e => e.mac.toLowerCase() === mac)
+    .map(e => ({ deviceId: t.deviceId, deviceIp: t.ip, ...e, collectedAt: t.collectedAt })));
+  return c.json({ code: 0, data: locations });
+});
+
 // 端口状态统计
 portMappingRoutes.get('/stats', authMiddleware, async (c) => {
   const mappings = Array.from(portMappings.values());
@@ -14,6 +14,35 @@ const snmpRoutes = new Hono<{
 
 import snmp from 'net-snmp';
 
+// Trap/Inform 记录 (采集器接收后上报, 保留最近 MAX_TRAPS 条)
+export interface TrapRecord {
+  id: string;
+  collectorId: string;
+  deviceId?: string;
+  source: string;
+  agentAddress?: string;
+  version: string;
+  pduType: string;
+  trapOid: string;
+  name?: string;
+  uptime: number;
+  user?: string;
+  varbinds: { oid: string; type: string; value: string }[];
+  receivedAt: Date;
+}
+
+const MAX_TRAPS = 10000;
+const traps: TrapRecord[] = [];
+
+export function recordTraps(records: Omit<TrapRecord, 'id'>[]) {
+  for (const r of records) {
+    traps.push({ id: crypto.randomUUID(), ...r });
+  }
+  if (traps.length > MAX_TRAPS) {
+    traps.splice(0, traps.length - MAX_TRAPS);
+  }
+}
+
 // 模板Schema
 const templateSchema = z.object({
   name: z.string().min(1, '模板名称不能为空'),
@@ -23,6 +52,14 @@ const templateSchema = z.object({
   securityLevel: z.string().optional(),
   authProtocol: z.string().optional(),
   privProtocol: z.string().optional(),
+  username: z.string().optional(),
+  authPassphrase: z.string().optional(),
+  privPassphrase: z.string().optional(),
+  contextName: z.string().optional(),
+  port: z.number().int().min(1).max(65535).default(161),
+  timeout: z.number().int().min(1).max(60).default(5),
+  retries: z.number().int().min(0).max(10).default(2),
+  maxRepetitions: z.number().int().min(1).max(100).default(25),
   oids: z.array(z.object({
     name: z.string(),
     oid: z.string(),
@@ -31,11 +68,20 @@ const templateSchema = z.object({
   })),
 });
 
+// 隐藏 v3 口令 (采集器通过 /collector/devices 获取明文)
+const MASKED = '******';
+const maskTemplate = (t: typeof schema.snmpTemplates.$inferSelect) => ({
+  ...t,
+  authPassphrase: t.authPassphrase ? MASKED : undefined,
+  privPassphrase: t.privPassphrase ? MASKED : undefined,
+  oids: JSON.parse(t.oids), // Parse JSON oids
+});
+
 // 获取模板列表
 snmpRoutes.get('/templates', authMiddleware, async (c) => {
   try {
     const templates = await db.select().from(schema.snmpTemplates).orderBy(desc(schema.snmpTemplates.createdAt));
-    return c.json({ code: 0, data: templates.map(t => ({...t, oids: JSON.parse(t.oids)})) }); // Parse JSON oids
+    return c.json({ code: 0, data: templates.map(maskTemplate) });
   } catch (error) {
     console.error('Get SNMP templates error:', error);
     return c.json({ code: 500, message: '获取模板列表失败' }, 500);
@@ -49,7 +95,7 @@ snmpRoutes.get('/templates/:id', authMiddleware, async (c) => {
     const result = await db.select().from(schema.snmpTemplates).where(eq(schema.snmpTemplates.id, id));
     const template = result[0];
     if (!template) return c.json({ code: 404, message: '模板不存在' }, 404);
-    return c.json({ code: 0, data: {...template, oids: JSON.parse(template.oids)} });
+    return c.json({ code: 0, data: maskTemplate(template) });
   } catch (error) { return c.json({ code: 500, message: '获取模板失败' }, 500); }
 });
 
@@ -66,6 +112,14 @@ snmpRoutes.post('/templates', authMiddleware, requireRole('admin'), zValidator('
         securityLevel: data.securityLevel,
         authProtocol: data.authProtocol,
         privProtocol: data.privProtocol,
+        username: data.username,
+        authPassphrase: data.authPassphrase,
+        privPassphrase: data.privPassphrase,
+        contextName: data.contextName,
+        port: data.port,
+        timeout: data.timeout,
+        retries: data.retries,
+        maxRepetitions: data.maxRepetitions,
         oids: JSON.stringify(data.oids),
     }).returning();
 
@@ -75,7 +129,7 @@ snmpRoutes.post('/templates', authMiddleware, requireRole('admin'), zValidator('
       userId: currentUser.userId, action: 'create', resource: 'snmp_templates', resourceId: template.id, details: JSON.stringify({ name: data.name }),
     });
 
-    return c.json({ code: 0, message: '模板创建成功', data: {...template, oids: JSON.parse(template.oids)} });
+    return c.json({ code: 0, message: '模板创建成功', data: maskTemplate(template) });
   } catch (error) {
     console.error('Create SNMP template error:', error);
     return c.json({ code: 500, message: '创建模板失败' }, 500);
@@ -91,6 +145,9 @@ snmpRoutes.put('/templates/:id', authMiddleware, requireRole('admin'), zValidato
     if (!existing) return c.json({ code: 404, message: '模板不存在' }, 404);
 
     if (data.oids) (data as any).oids = JSON.stringify(data.oids); // Convert to string
+    // 回传的掩码表示口令未修改
+    if (data.authPassphrase === MASKED) delete data.authPassphrase;
+    if (data.privPassphrase === MASKED) delete data.privPassphrase;
 
     await db.update(schema.snmpTemplates).set({ ...data, updatedAt: new Date() } as any).where(eq(schema.snmpTemplates.id, id));
     return c.json({ code: 0, message: '模板更新成功' });
@@ -107,6 +164,22 @@ snmpRoutes.delete('/templates/:id', authMiddleware, requireRole('admin'), async
   } catch (error) { return c.json({ code: 500, message: '删除模板失败' }, 500); }
 });
 
+// 获取最近的 Trap/Inform (按接收时间倒序)
+snmpRoutes.get('/traps', authMiddleware, async (c) => {
+  const deviceId = c.req.query('deviceId');
+  const name = c.req.query('name');
+  const limit = Math.min(parseInt(c.req.query('limit') || '100'), MAX_TRAPS);
+
+  const result: TrapRecord[] = [];
+  for (let i = traps.length - 1; i >= 0 && result.length < limit; i--) {
+    const t = traps[i]!;
+    if (deviceId && t.deviceId !== deviceId) continue;
+    if (name && t.name !== name && t.trapOid !== name) continue;
+    result.push(t);
+  }
+  return c.json({ code: 0, data: result });
+});
+
 // 测试SNMP连接 (Real)
 snmpRoutes.post('/test', authMiddleware, zValidator('json', z.object({
   ip: z.string().regex(/^(?:(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\.){3}(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)$/, "IP格式不正确"),
@@ -336,4 +336,14 @@ sshRoutes.post('/batch-execute', authMiddleware, requireRole('admin'), zValidato
   }
 });
 
-export { sshRoutes };
+// 按设备查找关联的SSH凭据（供采集器下发凭据模板）
+const findSSHCredential = (deviceId: string) => {
+  for (const cred of sshCredentials.values()) {
+    if (cred.deviceIds.includes(deviceId)) {
+      return cred;
+    }
+  }
+  return undefined;
+};
+
+export { sshRoutes, findSSHCredential };
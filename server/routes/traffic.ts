@@ -171,6 +171,53 @@ trafficRoutes.get('/realtime', authMiddleware, async (c) => {
     return c.json({ code: 0, data: [], timestamp: new Date() });
 });
 
+// 流量汇总 (采集器按 导出方+接口+方向 汇总 NetFlow/IPFIX/sFlow 后上报, 保留最近 MAX_FLOW_AGGREGATES 条)
+export interface FlowAggregateRecord {
+  collectorId: string;
+  deviceId?: string;
+  exporter: string;
+  ifIndex: number;
+  direction: 'in' | 'out';
+  windowStart: Date;
+  windowEnd: Date;
+  bytes: number;
+  packets: number;
+  flows: number;
+  topTalkers: { address: string; bytes: number; packets: number; flows: number }[];
+  topApps: { protocol: string; port: number; name?: string; bytes: number; packets: number; flows: number }[];
+}
+
+const MAX_FLOW_AGGREGATES = 50000;
+const flowAggregates: FlowAggregateRecord[] = [];
+
+export function recordFlows(records: FlowAggregateRecord[]) {
+  flowAggregates.push(...records);
+  if (flowAggregates.length > MAX_FLOW_AGGREGATES) {
+    flowAggregates.splice(0, flowAggregates.length - MAX_FLOW_AGGREGATES);
+  }
+}
+
+// 查询流量汇总 (按设备/导出方/接口/方向过滤, 最新在前)
+trafficRoutes.get('/flows', authMiddleware, async (c) => {
+  const deviceId = c.req.query('deviceId');
+  const exporter = c.req.query('exporter');
+  const ifIndex = c.req.query('ifIndex');
+  const direction = c.req.query('direction');
+  const limit = Math.min(parseInt(c.req.query('limit') || '100'), MAX_FLOW_AGGREGATES);
+
+  const result: FlowAggregateRecord[] = [];
+  for (let i = flowAggregates.length - 1; i >= 0 && result.length < limit; i--) {
+    const f = flowAggregates[i]!;
+    if (deviceId && f.deviceId !== deviceId) continue;
+    if (exporter && f.exporter !== exporter) continue;
+    if (ifIndex && f.ifIndex !== parseInt(ifIndex)) continue;
+    if (direction && f.direction !== direction) continue;
+    result.push(f);
+  }
+
+  return c.json({ code: 0, data: result });
+});
+
 // 流量告警阈值配置
 const trafficThresholds = new Map<string, {
   id: string;
@@ -44,6 +44,8 @@ export class SyslogServer {
             priority,
             timestamp: new Date(), 
             hostname: rinfo.address, 
+            sourceIp: rinfo.address,
+            transport: 'udp',
             message: rest.trim(),
             raw: raw,
         });
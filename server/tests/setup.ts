@@ -61,6 +61,8 @@ mock.module('../middleware/auth', () => ({
     await next();
   },
   requireRole: () => async (c: unknown, next: Function) => await next(),
+  collectorAuth: async (c: unknown, next: Function) => await next(),
+  verifyCollectorToken: () => Promise.resolve(true),
   verifyToken: () => ({ userId: 'admin', role: 'admin' }),
   generateToken: () => 'mock_token',
   JwtPayload: {},